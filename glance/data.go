@@ -0,0 +1,136 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glance
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"github.com/globocom/go-openstack/internal/checksum"
+	"hash"
+	"io"
+	"net/http"
+)
+
+// ChecksumError is returned when the checksum of the data of an image does not
+// match the checksum computed while uploading or downloading it.
+type ChecksumError struct {
+	ImageId string
+
+	// Expected is the checksum stored in glance, and Actual is the checksum
+	// computed from the data that was sent or received.
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("Checksum mismatch for the image %s: expected %s, got %s.", e.ImageId, e.Expected, e.Actual)
+}
+
+// newHash returns a hash for the given algorithm of os_hash_algo, or nil if the
+// algorithm is not supported.
+func newHash(algo string) hash.Hash {
+	switch algo {
+	case "md5":
+		return md5.New()
+	case "sha256":
+		return sha256.New()
+	case "sha512":
+		return sha512.New()
+	}
+	return nil
+}
+
+// UploadImage uploads the data of the image with the given id, reading it from
+// r until EOF. The data is streamed, and not buffered in memory.
+//
+// After the upload, UploadImage gets the image and compares its checksums
+// with the ones computed from the uploaded data, returning a *ChecksumError
+// if they differ. The multihash (os_hash_value) is only verified when its
+// algorithm is md5, sha256 or sha512.
+func (c *Client) UploadImage(id string, r io.Reader) error {
+	// The algorithm of the multihash is only known after the upload, so the
+	// data is hashed with all supported algorithms.
+	hashes := map[string]hash.Hash{}
+	writers := []io.Writer{}
+	for _, algo := range []string{"md5", "sha256", "sha512"} {
+		hashes[algo] = newHash(algo)
+		writers = append(writers, hashes[algo])
+	}
+	body := io.TeeReader(r, io.MultiWriter(writers...))
+	resp, err := c.send("upload the data of the image "+id, "PUT", "/images/"+id+"/file", "application/octet-stream", body, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	image, err := c.GetImage(id)
+	if err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(hashes["md5"].Sum(nil)); image.Checksum != "" && image.Checksum != actual {
+		return &ChecksumError{ImageId: id, Expected: image.Checksum, Actual: actual}
+	}
+	if h := hashes[image.OsHashAlgo]; h != nil && image.OsHashValue != "" {
+		if actual := hex.EncodeToString(h.Sum(nil)); image.OsHashValue != actual {
+			return &ChecksumError{ImageId: id, Expected: image.OsHashValue, Actual: actual}
+		}
+	}
+	return nil
+}
+
+// DownloadImage returns a reader of the data of the image with the given id.
+// The data is streamed from glance, and the caller must close the reader.
+//
+// The reader verifies the checksum of the data against the Content-MD5 header
+// sent by glance: if they differ, the read that reaches the end of the data
+// returns a *ChecksumError instead of io.EOF. The data is not verified when
+// glance does not send the header.
+func (c *Client) DownloadImage(id string) (io.ReadCloser, error) {
+	resp, err := c.send("download the data of the image "+id, "GET", "/images/"+id+"/file", "", nil, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return nil, err
+	}
+	mismatch := func(expected, actual string) error {
+		return &ChecksumError{ImageId: id, Expected: expected, Actual: actual}
+	}
+	return checksum.NewReader(resp.Body, md5.New(), resp.Header.Get("Content-MD5"), mismatch), nil
+}
+
+// VerifyImage downloads the data of the image with the given id and compares
+// it with the checksums stored in the image, returning a *ChecksumError if
+// they differ. The data is discarded.
+func (c *Client) VerifyImage(id string) error {
+	image, err := c.GetImage(id)
+	if err != nil {
+		return err
+	}
+	r, err := c.DownloadImage(id)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	hashes := map[string]hash.Hash{}
+	writers := []io.Writer{}
+	if image.Checksum != "" {
+		hashes[image.Checksum] = md5.New()
+	}
+	if h := newHash(image.OsHashAlgo); h != nil && image.OsHashValue != "" {
+		hashes[image.OsHashValue] = h
+	}
+	for _, h := range hashes {
+		writers = append(writers, h)
+	}
+	if _, err = io.Copy(io.MultiWriter(writers...), r); err != nil {
+		return err
+	}
+	for expected, h := range hashes {
+		if actual := hex.EncodeToString(h.Sum(nil)); actual != expected {
+			return &ChecksumError{ImageId: id, Expected: expected, Actual: actual}
+		}
+	}
+	return nil
+}
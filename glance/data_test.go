@@ -0,0 +1,98 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glance
+
+import (
+	"fmt"
+	"io/ioutil"
+	. "launchpad.net/gocheck"
+	"strings"
+)
+
+// Checksums of the string "image data".
+const (
+	dataMD5    = "e09a574ca3760a3e28a3e5920fe4627e"
+	dataSHA256 = "b41b86dcfdc6219bc2fb987591ad9995bcf3a1e40c2bdd3fdbec622371e6e1af"
+	dataSHA512 = "5faacedd877308e66e78eeca9ee53a9ba2cbc5c969e67e1cdbf712999ab73b173a2eb6a823653b13d89046df72fc5658b00e955eaf6472ac96329de41135b79a"
+)
+
+func (s *S) TestUploadImage(c *C) {
+	testServer.PrepareResponse(204, nil, "")
+	testServer.PrepareResponse(200, nil, fmt.Sprintf(`{"id": "1bea47ed", "status": "active", "checksum": "%s", "os_hash_algo": "sha512", "os_hash_value": "%s"}`, dataMD5, dataSHA512))
+	client := newTestClient()
+	err := client.UploadImage("1bea47ed", strings.NewReader("image data"))
+	c.Assert(err, IsNil)
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "PUT")
+	c.Assert(req.URL.Path, Equals, "/v2/images/1bea47ed/file")
+	c.Assert(req.Header.Get("Content-Type"), Equals, "application/octet-stream")
+	c.Assert(string(b), Equals, "image data")
+}
+
+func (s *S) TestUploadImageChecksumMismatch(c *C) {
+	testServer.PrepareResponse(204, nil, "")
+	testServer.PrepareResponse(200, nil, `{"id": "1bea47ed", "status": "active", "checksum": "0123"}`)
+	client := newTestClient()
+	err := client.UploadImage("1bea47ed", strings.NewReader("image data"))
+	c.Assert(err, FitsTypeOf, &ChecksumError{})
+	c.Assert(err, ErrorMatches, "^Checksum mismatch for the image 1bea47ed: expected 0123, got "+dataMD5+".$")
+}
+
+func (s *S) TestUploadImageVerifiesMultihash(c *C) {
+	testServer.PrepareResponse(204, nil, "")
+	testServer.PrepareResponse(200, nil, fmt.Sprintf(`{"id": "1bea47ed", "status": "active", "checksum": "%s", "os_hash_algo": "sha256", "os_hash_value": "%s"}`, dataMD5, dataSHA256))
+	testServer.PrepareResponse(204, nil, "")
+	testServer.PrepareResponse(200, nil, fmt.Sprintf(`{"id": "1bea47ed", "status": "active", "checksum": "%s", "os_hash_algo": "sha256", "os_hash_value": "0123"}`, dataMD5))
+	client := newTestClient()
+	err := client.UploadImage("1bea47ed", strings.NewReader("image data"))
+	c.Assert(err, IsNil)
+	err = client.UploadImage("1bea47ed", strings.NewReader("image data"))
+	c.Assert(err, ErrorMatches, "^Checksum mismatch for the image 1bea47ed: expected 0123, got "+dataSHA256+".$")
+}
+
+func (s *S) TestUploadImageFailure(c *C) {
+	testServer.PrepareResponse(409, nil, "Image status transition from active to saving is not allowed")
+	client := newTestClient()
+	err := client.UploadImage("1bea47ed", strings.NewReader("image data"))
+	c.Assert(err, ErrorMatches, "^Failed to upload the data of the image 1bea47ed, status: 409.\nBody: .*")
+}
+
+func (s *S) TestDownloadImage(c *C) {
+	testServer.PrepareResponse(200, map[string]string{"Content-MD5": dataMD5}, "image data")
+	client := newTestClient()
+	r, err := client.DownloadImage("1bea47ed")
+	c.Assert(err, IsNil)
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, "image data")
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2/images/1bea47ed/file")
+}
+
+func (s *S) TestDownloadImageChecksumMismatch(c *C) {
+	testServer.PrepareResponse(200, map[string]string{"Content-MD5": dataMD5}, "corrupted data")
+	client := newTestClient()
+	r, err := client.DownloadImage("1bea47ed")
+	c.Assert(err, IsNil)
+	defer r.Close()
+	_, err = ioutil.ReadAll(r)
+	c.Assert(err, FitsTypeOf, &ChecksumError{})
+	c.Assert(err.(*ChecksumError).Expected, Equals, dataMD5)
+}
+
+func (s *S) TestVerifyImage(c *C) {
+	testServer.PrepareResponse(200, nil, fmt.Sprintf(`{"id": "1bea47ed", "checksum": "%s", "os_hash_algo": "sha512", "os_hash_value": "%s"}`, dataMD5, dataSHA512))
+	testServer.PrepareResponse(200, nil, "image data")
+	testServer.PrepareResponse(200, nil, fmt.Sprintf(`{"id": "1bea47ed", "checksum": "%s", "os_hash_algo": "sha512", "os_hash_value": "0123"}`, dataMD5))
+	testServer.PrepareResponse(200, nil, "image data")
+	client := newTestClient()
+	err := client.VerifyImage("1bea47ed")
+	c.Assert(err, IsNil)
+	err = client.VerifyImage("1bea47ed")
+	c.Assert(err, ErrorMatches, "^Checksum mismatch for the image 1bea47ed: expected 0123, got "+dataSHA512+".$")
+}
@@ -0,0 +1,142 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package glance provides types, methods and functions for interactions with the
+// Glance Image Service API v2.
+package glance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/globocom/go-openstack/keystone"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Client represents a client for the Glance API. It encapsulates a
+// keystone.Client instance that provides the token and the endpoint of the
+// "image" service used by this client.
+type Client struct {
+	KeystoneClient *keystone.Client
+}
+
+// apiVersion is the version of the image API used by the client.
+var apiVersion = &keystone.Version{Id: "v2"}
+
+// endpoint returns the base URL of the v2 API. The service catalog may
+// advertise the unversioned URL of glance, or the URL of another version, so
+// the URL is rewritten to point to the v2 API (see keystone.VersionedURL).
+func (c *Client) endpoint() (string, error) {
+	if c.KeystoneClient == nil {
+		return "", errors.New("KeystoneClient is nil.")
+	}
+	endpoint := c.KeystoneClient.Endpoint("image", "admin")
+	if endpoint == "" {
+		return "", errors.New("Image endpoint not found in the service catalog.")
+	}
+	return keystone.VersionedURL(endpoint, apiVersion)
+}
+
+// Error is returned when the image API responds a request with an unexpected
+// status.
+type Error struct {
+	// Op describes the operation that failed, like "get the image 123".
+	Op string
+
+	StatusCode int
+	Body       string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("Failed to %s, status: %d.\nBody: %s.", e.Op, e.StatusCode, e.Body)
+}
+
+// IsNotFound reports whether err is an Error with the status 404.
+func IsNotFound(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// send sends a request to the given path of the image API, with the given
+// body and content type. It returns an Error if the status of the response is
+// not one of the expected statuses. Otherwise, the caller must close the body
+// of the returned response.
+func (c *Client) send(op, method, path, contentType string, body io.Reader, expected ...int) (*http.Response, error) {
+	return c.sendContext(context.Background(), op, method, path, contentType, body, expected...)
+}
+
+// sendContext works like send, but aborts the request when the given context
+// is done.
+func (c *Client) sendContext(ctx context.Context, op, method, path, contentType string, body io.Reader, expected ...int) (*http.Response, error) {
+	endpoint, err := c.endpoint()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Auth-Token", c.KeystoneClient.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to %s: %s", op, err)
+	}
+	for _, e := range expected {
+		if resp.StatusCode == e {
+			return resp, nil
+		}
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	return nil, &Error{Op: op, StatusCode: resp.StatusCode, Body: string(b)}
+}
+
+// request sends a JSON request to the given path of the image API, returning
+// an Error if the status of the response is not one of the expected statuses.
+//
+// The in parameter, when not nil, is encoded as JSON and sent as the body of
+// the request, using the given content type (application/json when empty).
+// The body of the response is decoded into out, when it is not nil. The op
+// parameter describes the operation and is used in error messages.
+func (c *Client) request(op, method, path, contentType string, in, out interface{}, expected ...int) error {
+	return c.requestContext(context.Background(), op, method, path, contentType, in, out, expected...)
+}
+
+// requestContext works like request, but aborts the request when the given
+// context is done.
+func (c *Client) requestContext(ctx context.Context, op, method, path, contentType string, in, out interface{}, expected ...int) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	resp, err := c.sendContext(ctx, op, method, path, contentType, body, expected...)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	result, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Failed to %s: %s", op, err)
+	}
+	if out != nil && len(result) > 0 {
+		if err = json.Unmarshal(result, out); err != nil {
+			return fmt.Errorf("Failed to %s, the server did not respond a valid JSON.", op)
+		}
+	}
+	return nil
+}
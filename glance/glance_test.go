@@ -0,0 +1,98 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glance
+
+import (
+	"github.com/globocom/go-openstack/keystone"
+	ostesting "github.com/globocom/go-openstack/testing"
+	. "launchpad.net/gocheck"
+	"testing"
+)
+
+type S struct{}
+
+var _ = Suite(&S{})
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var testServer = ostesting.NewTestHTTPServer("http://localhost:6666", 1e9)
+
+func (s *S) SetUpSuite(c *C) {
+	testServer.Start()
+}
+
+func (s *S) TearDownTest(c *C) {
+	testServer.FlushRequests()
+}
+
+// newTestClient returns a client that sends its requests to the test server.
+func newTestClient() *Client {
+	kclient := keystone.Client{
+		Token: "123token",
+		Catalogs: []keystone.ServiceCatalog{
+			{
+				Name: "Image Service",
+				Type: "image",
+				Endpoints: []keystone.Endpoint{
+					{Interface: "admin", URL: "http://localhost:6666"},
+				},
+			},
+		},
+	}
+	return &Client{KeystoneClient: &kclient}
+}
+
+func (s *S) TestEndpoint(c *C) {
+	client := newTestClient()
+	endpoint, err := client.endpoint()
+	c.Assert(err, IsNil)
+	c.Assert(endpoint, Equals, "http://localhost:6666/v2")
+	client.KeystoneClient.Catalogs[0].Endpoints[0].URL = "http://localhost:6666/v2/"
+	endpoint, err = client.endpoint()
+	c.Assert(err, IsNil)
+	c.Assert(endpoint, Equals, "http://localhost:6666/v2")
+}
+
+func (s *S) TestEndpointWithVersionedURL(c *C) {
+	client := newTestClient()
+	client.KeystoneClient.Catalogs[0].Endpoints[0].URL = "http://glance.mycloud.com:9292/v1"
+	endpoint, err := client.endpoint()
+	c.Assert(err, IsNil)
+	c.Assert(endpoint, Equals, "http://glance.mycloud.com:9292/v2")
+	client.KeystoneClient.Catalogs[0].Endpoints[0].URL = "https://mycloud.com/image/v2.1/"
+	endpoint, err = client.endpoint()
+	c.Assert(err, IsNil)
+	c.Assert(endpoint, Equals, "https://mycloud.com/image/v2")
+}
+
+func (s *S) TestEndpointNotFound(c *C) {
+	client := &Client{KeystoneClient: &keystone.Client{}}
+	_, err := client.endpoint()
+	c.Assert(err, ErrorMatches, "^Image endpoint not found in the service catalog.$")
+	client = &Client{}
+	_, err = client.endpoint()
+	c.Assert(err, ErrorMatches, "^KeystoneClient is nil.$")
+}
+
+func (s *S) TestRequestSendsToken(c *C) {
+	testServer.PrepareResponse(200, nil, `{"id": "1"}`)
+	client := newTestClient()
+	_, err := client.GetImage("1")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("X-Auth-Token"), Equals, "123token")
+}
+
+func (s *S) TestIsNotFound(c *C) {
+	testServer.PrepareResponse(404, nil, "No image found with ID 1")
+	client := newTestClient()
+	_, err := client.GetImage("1")
+	c.Assert(IsNotFound(err), Equals, true)
+	c.Assert(err, ErrorMatches, "^Failed to get the image 1, status: 404.\nBody: No image found with ID 1.$")
+	c.Assert(IsNotFound(nil), Equals, false)
+}
@@ -0,0 +1,333 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/globocom/go-openstack/pagination"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Statuses of images.
+const (
+	StatusQueued        = "queued"
+	StatusSaving        = "saving"
+	StatusActive        = "active"
+	StatusKilled        = "killed"
+	StatusDeleted       = "deleted"
+	StatusDeactivated   = "deactivated"
+	StatusUploading     = "uploading"
+	StatusImporting     = "importing"
+	StatusPendingDelete = "pending_delete"
+)
+
+// Visibilities of images.
+const (
+	VisibilityPublic    = "public"
+	VisibilityPrivate   = "private"
+	VisibilityShared    = "shared"
+	VisibilityCommunity = "community"
+)
+
+// reservedKeys are the keys of the image representation that are managed by
+// glance, and thus can not be used as custom properties.
+var reservedKeys = map[string]bool{
+	"id": true, "name": true, "status": true, "visibility": true,
+	"checksum": true, "os_hash_algo": true, "os_hash_value": true,
+	"size": true, "virtual_size": true, "disk_format": true,
+	"container_format": true, "min_disk": true, "min_ram": true,
+	"protected": true, "owner": true, "tags": true, "created_at": true,
+	"updated_at": true, "self": true, "file": true, "schema": true,
+	"direct_url": true, "locations": true, "os_hidden": true,
+}
+
+// Image represents an image. Custom properties of the image, like
+// "os_distro", are stored in Properties.
+//
+// Checksum is the MD5 checksum of the image data, and OsHashValue is the
+// checksum computed with the algorithm OsHashAlgo (usually "sha512"). Both
+// are empty until the data is uploaded.
+type Image struct {
+	Id              string    `json:"id"`
+	Name            string    `json:"name"`
+	Status          string    `json:"status"`
+	Visibility      string    `json:"visibility"`
+	Checksum        string    `json:"checksum"`
+	OsHashAlgo      string    `json:"os_hash_algo"`
+	OsHashValue     string    `json:"os_hash_value"`
+	Size            int64     `json:"size"`
+	VirtualSize     int64     `json:"virtual_size"`
+	DiskFormat      string    `json:"disk_format"`
+	ContainerFormat string    `json:"container_format"`
+	MinDisk         int       `json:"min_disk"`
+	MinRAM          int       `json:"min_ram"`
+	Protected       bool      `json:"protected"`
+	Owner           string    `json:"owner"`
+	Tags            []string  `json:"tags"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	Properties map[string]string `json:"-"`
+}
+
+// UnmarshalJSON decodes an image, collecting the custom properties, that
+// glance returns as top-level string attributes, into Properties.
+func (i *Image) UnmarshalJSON(b []byte) error {
+	type image Image
+	if err := json.Unmarshal(b, (*image)(i)); err != nil {
+		return err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	i.Properties = nil
+	for k, v := range raw {
+		if s, ok := v.(string); ok && !reservedKeys[k] {
+			if i.Properties == nil {
+				i.Properties = make(map[string]string)
+			}
+			i.Properties[k] = s
+		}
+	}
+	return nil
+}
+
+// ListImagesOpts contains the filters and pagination parameters for listing
+// images. Empty fields are ignored. Images that have all the given Tags are
+// listed.
+type ListImagesOpts struct {
+	Name       string
+	Status     string
+	Visibility string
+	Owner      string
+	Tags       []string
+
+	// SortKey is the attribute used to sort the images, like "created_at"
+	// or "name", and SortDir is "asc" or "desc".
+	SortKey string
+	SortDir string
+
+	Limit  int
+	Marker string
+}
+
+func (opts *ListImagesOpts) query() url.Values {
+	q := url.Values{}
+	if opts.Name != "" {
+		q.Set("name", opts.Name)
+	}
+	if opts.Status != "" {
+		q.Set("status", opts.Status)
+	}
+	if opts.Visibility != "" {
+		q.Set("visibility", opts.Visibility)
+	}
+	if opts.Owner != "" {
+		q.Set("owner", opts.Owner)
+	}
+	for _, tag := range opts.Tags {
+		q.Add("tag", tag)
+	}
+	if opts.SortKey != "" {
+		q.Set("sort_key", opts.SortKey)
+	}
+	if opts.SortDir != "" {
+		q.Set("sort_dir", opts.SortDir)
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Marker != "" {
+		q.Set("marker", opts.Marker)
+	}
+	return q
+}
+
+func (c *Client) listImages(ctx context.Context, opts ListImagesOpts) (*pagination.Page[Image], error) {
+	var result struct {
+		Images []Image
+		Next   string
+	}
+	path := "/images"
+	if q := opts.query().Encode(); q != "" {
+		path += "?" + q
+	}
+	err := c.requestContext(ctx, "get the list of images", "GET", path, "", nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &pagination.Page[Image]{Items: result.Images, Next: pagination.Marker(result.Next)}, nil
+}
+
+// ListImages returns the list of images that match the given options,
+// following all pages of the listing. The listing starts after opts.Marker,
+// and opts.Limit is the size of each page.
+func (c *Client) ListImages(opts ListImagesOpts) ([]Image, error) {
+	return c.ImagePager(opts).All(context.Background())
+}
+
+// ImagePager returns a pager over the images that match the given options,
+// starting after opts.Marker. opts.Limit is the size of each page.
+func (c *Client) ImagePager(opts ListImagesOpts) *pagination.Pager[Image] {
+	return pagination.New(func(ctx context.Context, marker string) (*pagination.Page[Image], error) {
+		if marker != "" {
+			opts.Marker = marker
+		}
+		return c.listImages(ctx, opts)
+	})
+}
+
+// GetImage returns the image with the given id.
+func (c *Client) GetImage(id string) (*Image, error) {
+	var image Image
+	err := c.request("get the image "+id, "GET", "/images/"+id, "", nil, &image, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// CreateImageOpts contains the attributes of a new image. Empty fields are
+// ignored, letting glance choose the default values.
+type CreateImageOpts struct {
+	// Id is the UUID of the image. When empty, glance generates one.
+	Id string
+
+	Name            string
+	DiskFormat      string
+	ContainerFormat string
+	Visibility      string
+	Tags            []string
+	MinDisk         int
+	MinRAM          int
+	Protected       bool
+
+	// Properties are the custom properties of the image. Their keys can not
+	// be reserved attributes, like "name" or "status".
+	Properties map[string]string
+}
+
+func (opts *CreateImageOpts) toMap() (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	for k, v := range opts.Properties {
+		if reservedKeys[k] {
+			return nil, fmt.Errorf("Invalid image: the property %q is reserved.", k)
+		}
+		m[k] = v
+	}
+	if opts.Id != "" {
+		m["id"] = opts.Id
+	}
+	if opts.Name != "" {
+		m["name"] = opts.Name
+	}
+	if opts.DiskFormat != "" {
+		m["disk_format"] = opts.DiskFormat
+	}
+	if opts.ContainerFormat != "" {
+		m["container_format"] = opts.ContainerFormat
+	}
+	if opts.Visibility != "" {
+		m["visibility"] = opts.Visibility
+	}
+	if len(opts.Tags) > 0 {
+		m["tags"] = opts.Tags
+	}
+	if opts.MinDisk > 0 {
+		m["min_disk"] = opts.MinDisk
+	}
+	if opts.MinRAM > 0 {
+		m["min_ram"] = opts.MinRAM
+	}
+	if opts.Protected {
+		m["protected"] = true
+	}
+	return m, nil
+}
+
+// CreateImage creates a new image, in the "queued" status. The data of the
+// image must be uploaded with UploadImage.
+func (c *Client) CreateImage(opts CreateImageOpts) (*Image, error) {
+	body, err := opts.toMap()
+	if err != nil {
+		return nil, err
+	}
+	var image Image
+	err = c.request("create the image", "POST", "/images", "", body, &image, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// Patch is an operation of a JSON patch (RFC 6902) that changes an attribute
+// or a custom property of an image. Use the functions Replace, Add and Remove
+// to build patches.
+type Patch struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Replace returns a patch that replaces the value of the given attribute or
+// property, like "name" or "min_ram".
+func Replace(name string, value interface{}) Patch {
+	return Patch{Op: "replace", Path: "/" + name, Value: value}
+}
+
+// Add returns a patch that adds the given property, or replaces it if it
+// already exists.
+func Add(name string, value interface{}) Patch {
+	return Patch{Op: "add", Path: "/" + name, Value: value}
+}
+
+// Remove returns a patch that removes the given property.
+func Remove(name string) Patch {
+	return Patch{Op: "remove", Path: "/" + name}
+}
+
+// UpdateImage applies the given patches to the image with the given id,
+// returning the updated image.
+//
+// Example of use:
+//
+//     image, err := client.UpdateImage(id,
+//         glance.Replace("name", "ubuntu-22.04"),
+//         glance.Add("os_distro", "ubuntu"),
+//         glance.Remove("build_id"),
+//     )
+func (c *Client) UpdateImage(id string, patches ...Patch) (*Image, error) {
+	if patches == nil {
+		patches = []Patch{}
+	}
+	var image Image
+	err := c.request("update the image "+id, "PATCH", "/images/"+id, "application/openstack-images-v2.1-json-patch", patches, &image, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// DeleteImage deletes the image with the given id.
+func (c *Client) DeleteImage(id string) error {
+	return c.request("delete the image "+id, "DELETE", "/images/"+id, "", nil, nil, http.StatusNoContent)
+}
+
+// AddImageTag adds the given tag to the image. Adding a tag that the image
+// already has is not an error.
+func (c *Client) AddImageTag(id, tag string) error {
+	return c.request("add the tag "+tag+" to the image "+id, "PUT", "/images/"+id+"/tags/"+url.PathEscape(tag), "", nil, nil, http.StatusNoContent)
+}
+
+// DeleteImageTag removes the given tag from the image.
+func (c *Client) DeleteImageTag(id, tag string) error {
+	return c.request("delete the tag "+tag+" of the image "+id, "DELETE", "/images/"+id+"/tags/"+url.PathEscape(tag), "", nil, nil, http.StatusNoContent)
+}
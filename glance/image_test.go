@@ -0,0 +1,157 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glance
+
+import (
+	"context"
+	"encoding/json"
+	. "launchpad.net/gocheck"
+	"time"
+)
+
+const imageBody = `{"id": "1bea47ed", "name": "cirros", "status": "active", "visibility": "public", "checksum": "ee1eca47dc88f4879d8a229cc70a07c6", "os_hash_algo": "sha512", "os_hash_value": "1b03ca1b", "size": 13287936, "virtual_size": null, "disk_format": "qcow2", "container_format": "bare", "min_disk": 0, "min_ram": 0, "protected": false, "owner": "123tenant", "tags": ["base"], "created_at": "2016-06-29T16:13:07Z", "updated_at": "2016-06-29T16:13:08Z", "os_distro": "cirros", "self": "/v2/images/1bea47ed", "file": "/v2/images/1bea47ed/file", "schema": "/v2/schemas/image", "os_hidden": false}`
+
+func (s *S) TestImageUnmarshal(c *C) {
+	var image Image
+	err := json.Unmarshal([]byte(imageBody), &image)
+	c.Assert(err, IsNil)
+	c.Assert(image.Name, Equals, "cirros")
+	c.Assert(image.Size, Equals, int64(13287936))
+	c.Assert(image.Tags, DeepEquals, []string{"base"})
+	c.Assert(image.CreatedAt, Equals, time.Date(2016, 6, 29, 16, 13, 7, 0, time.UTC))
+	c.Assert(image.Properties, DeepEquals, map[string]string{"os_distro": "cirros"})
+}
+
+func (s *S) TestListImages(c *C) {
+	testServer.PrepareResponse(200, nil, `{"images": [`+imageBody+`], "first": "/v2/images", "schema": "/v2/schemas/images"}`)
+	client := newTestClient()
+	images, err := client.ListImages(ListImagesOpts{Visibility: VisibilityPublic, Tags: []string{"base", "x86"}, SortKey: "name", Limit: 10})
+	c.Assert(err, IsNil)
+	c.Assert(images, HasLen, 1)
+	c.Assert(images[0].Id, Equals, "1bea47ed")
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2/images")
+	c.Assert(req.URL.RawQuery, Equals, "limit=10&sort_key=name&tag=base&tag=x86&visibility=public")
+}
+
+func (s *S) TestImagePager(c *C) {
+	testServer.PrepareResponse(200, nil, `{"images": [{"id": "1"}, {"id": "2"}], "next": "/v2/images?limit=2&marker=2"}`)
+	testServer.PrepareResponse(200, nil, `{"images": [{"id": "3"}]}`)
+	client := newTestClient()
+	images, err := client.ImagePager(ListImagesOpts{Limit: 2}).All(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(images, HasLen, 3)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.RawQuery, Equals, "limit=2&marker=2")
+}
+
+func (s *S) TestListImagesFollowsAllPages(c *C) {
+	testServer.PrepareResponse(200, nil, `{"images": [{"id": "1"}, {"id": "2"}], "next": "/v2/images?limit=2&marker=2"}`)
+	testServer.PrepareResponse(200, nil, `{"images": [{"id": "3"}]}`)
+	client := newTestClient()
+	images, err := client.ListImages(ListImagesOpts{Limit: 2})
+	c.Assert(err, IsNil)
+	c.Assert(images, HasLen, 3)
+	c.Assert(images[2].Id, Equals, "3")
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.RawQuery, Equals, "limit=2&marker=2")
+}
+
+func (s *S) TestImagePagerCancelsHangingRequest(c *C) {
+	client := newTestClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.ImagePager(ListImagesOpts{}).All(ctx)
+	c.Assert(err, NotNil)
+	c.Assert(ctx.Err(), Equals, context.DeadlineExceeded)
+	c.Assert(time.Since(start) < 900*time.Millisecond, Equals, true)
+	// Releases the request that is still waiting for a response.
+	testServer.PrepareResponse(200, nil, `{"images": []}`)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+}
+
+func (s *S) TestCreateImage(c *C) {
+	testServer.PrepareResponse(201, nil, `{"id": "1bea47ed", "name": "cirros", "status": "queued", "os_distro": "cirros"}`)
+	client := newTestClient()
+	image, err := client.CreateImage(CreateImageOpts{
+		Name:            "cirros",
+		DiskFormat:      "qcow2",
+		ContainerFormat: "bare",
+		Tags:            []string{"base"},
+		Properties:      map[string]string{"os_distro": "cirros"},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(image.Status, Equals, StatusQueued)
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "POST")
+	c.Assert(req.URL.Path, Equals, "/v2/images")
+	c.Assert(req.Header.Get("Content-Type"), Equals, "application/json")
+	c.Assert(string(b), Equals, `{"container_format":"bare","disk_format":"qcow2","name":"cirros","os_distro":"cirros","tags":["base"]}`)
+}
+
+func (s *S) TestCreateImageReservedProperty(c *C) {
+	client := newTestClient()
+	_, err := client.CreateImage(CreateImageOpts{Name: "cirros", Properties: map[string]string{"status": "active"}})
+	c.Assert(err, ErrorMatches, `^Invalid image: the property "status" is reserved.$`)
+}
+
+func (s *S) TestUpdateImage(c *C) {
+	testServer.PrepareResponse(200, nil, imageBody)
+	client := newTestClient()
+	image, err := client.UpdateImage("1bea47ed", Replace("name", "cirros"), Add("os_distro", "cirros"), Remove("build_id"))
+	c.Assert(err, IsNil)
+	c.Assert(image.Name, Equals, "cirros")
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "PATCH")
+	c.Assert(req.URL.Path, Equals, "/v2/images/1bea47ed")
+	c.Assert(req.Header.Get("Content-Type"), Equals, "application/openstack-images-v2.1-json-patch")
+	c.Assert(string(b), Equals, `[{"op":"replace","path":"/name","value":"cirros"},{"op":"add","path":"/os_distro","value":"cirros"},{"op":"remove","path":"/build_id"}]`)
+}
+
+func (s *S) TestDeleteImage(c *C) {
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	err := client.DeleteImage("1bea47ed")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.Path, Equals, "/v2/images/1bea47ed")
+}
+
+func (s *S) TestDeleteImageProtected(c *C) {
+	testServer.PrepareResponse(403, nil, "Image 1bea47ed is protected and cannot be deleted")
+	client := newTestClient()
+	err := client.DeleteImage("1bea47ed")
+	c.Assert(err, ErrorMatches, "^Failed to delete the image 1bea47ed, status: 403.\nBody: Image 1bea47ed is protected and cannot be deleted.$")
+}
+
+func (s *S) TestImageTags(c *C) {
+	testServer.PrepareResponse(204, nil, "")
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	err := client.AddImageTag("1bea47ed", "base image")
+	c.Assert(err, IsNil)
+	err = client.DeleteImageTag("1bea47ed", "base image")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "PUT")
+	c.Assert(req.URL.EscapedPath(), Equals, "/v2/images/1bea47ed/tags/base%20image")
+	req, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+}
@@ -0,0 +1,77 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glance
+
+import (
+	"net/http"
+	"time"
+)
+
+// Statuses of image members. A member sees a shared image in its list of
+// images only after accepting it.
+const (
+	MemberPending  = "pending"
+	MemberAccepted = "accepted"
+	MemberRejected = "rejected"
+)
+
+// Member represents a project that an image with the "shared" visibility is
+// shared with.
+type Member struct {
+	ImageId   string    `json:"image_id"`
+	MemberId  string    `json:"member_id"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListImageMembers returns the members of the image with the given id.
+func (c *Client) ListImageMembers(id string) ([]Member, error) {
+	var result struct{ Members []Member }
+	err := c.request("get the members of the image "+id, "GET", "/images/"+id+"/members", "", nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return result.Members, nil
+}
+
+// GetImageMember returns the given member of the image.
+func (c *Client) GetImageMember(id, memberId string) (*Member, error) {
+	var member Member
+	err := c.request("get the member "+memberId+" of the image "+id, "GET", "/images/"+id+"/members/"+memberId, "", nil, &member, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// AddImageMember shares the image with the given project. The new member
+// starts in the "pending" status.
+func (c *Client) AddImageMember(id, memberId string) (*Member, error) {
+	var member Member
+	body := map[string]string{"member": memberId}
+	err := c.request("add the member "+memberId+" to the image "+id, "POST", "/images/"+id+"/members", "", body, &member, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// UpdateImageMember changes the status of the given member of the image. It
+// must be called by the member, usually to accept or reject the image.
+func (c *Client) UpdateImageMember(id, memberId, status string) (*Member, error) {
+	var member Member
+	body := map[string]string{"status": status}
+	err := c.request("update the member "+memberId+" of the image "+id, "PUT", "/images/"+id+"/members/"+memberId, "", body, &member, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// RemoveImageMember stops sharing the image with the given project.
+func (c *Client) RemoveImageMember(id, memberId string) error {
+	return c.request("remove the member "+memberId+" of the image "+id, "DELETE", "/images/"+id+"/members/"+memberId, "", nil, nil, http.StatusNoContent)
+}
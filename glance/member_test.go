@@ -0,0 +1,70 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glance
+
+import (
+	. "launchpad.net/gocheck"
+)
+
+const memberBody = `{"image_id": "1bea47ed", "member_id": "456tenant", "status": "pending", "created_at": "2016-06-29T16:13:07Z", "updated_at": "2016-06-29T16:13:07Z", "schema": "/v2/schemas/member"}`
+
+func (s *S) TestListImageMembers(c *C) {
+	testServer.PrepareResponse(200, nil, `{"members": [`+memberBody+`], "schema": "/v2/schemas/members"}`)
+	client := newTestClient()
+	members, err := client.ListImageMembers("1bea47ed")
+	c.Assert(err, IsNil)
+	c.Assert(members, HasLen, 1)
+	c.Assert(members[0].MemberId, Equals, "456tenant")
+	c.Assert(members[0].Status, Equals, MemberPending)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2/images/1bea47ed/members")
+}
+
+func (s *S) TestGetImageMember(c *C) {
+	testServer.PrepareResponse(200, nil, memberBody)
+	client := newTestClient()
+	member, err := client.GetImageMember("1bea47ed", "456tenant")
+	c.Assert(err, IsNil)
+	c.Assert(member.ImageId, Equals, "1bea47ed")
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2/images/1bea47ed/members/456tenant")
+}
+
+func (s *S) TestAddImageMember(c *C) {
+	testServer.PrepareResponse(200, nil, memberBody)
+	client := newTestClient()
+	member, err := client.AddImageMember("1bea47ed", "456tenant")
+	c.Assert(err, IsNil)
+	c.Assert(member.MemberId, Equals, "456tenant")
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "POST")
+	c.Assert(string(b), Equals, `{"member":"456tenant"}`)
+}
+
+func (s *S) TestUpdateImageMember(c *C) {
+	testServer.PrepareResponse(200, nil, `{"image_id": "1bea47ed", "member_id": "456tenant", "status": "accepted"}`)
+	client := newTestClient()
+	member, err := client.UpdateImageMember("1bea47ed", "456tenant", MemberAccepted)
+	c.Assert(err, IsNil)
+	c.Assert(member.Status, Equals, MemberAccepted)
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "PUT")
+	c.Assert(req.URL.Path, Equals, "/v2/images/1bea47ed/members/456tenant")
+	c.Assert(string(b), Equals, `{"status":"accepted"}`)
+}
+
+func (s *S) TestRemoveImageMember(c *C) {
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	err := client.RemoveImageMember("1bea47ed", "456tenant")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+}
@@ -0,0 +1,41 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package checksum verifies the checksum of content downloaded from OpenStack
+// services. It is shared by the client packages and is not part of their API.
+package checksum
+
+import (
+	"encoding/hex"
+	"hash"
+	"io"
+)
+
+// Reader computes the checksum of the content read from the underlying reader,
+// comparing it with the expected checksum at EOF. When they differ, the read
+// that reaches EOF returns the error built by mismatch instead of io.EOF.
+type Reader struct {
+	io.ReadCloser
+	hash     hash.Hash
+	expected string
+	mismatch func(expected, actual string) error
+}
+
+// NewReader returns a Reader of r that verifies its content with the given
+// hash. The content is not verified when expected is empty, as services do
+// not always know the checksum of the content.
+func NewReader(r io.ReadCloser, h hash.Hash, expected string, mismatch func(expected, actual string) error) *Reader {
+	return &Reader{ReadCloser: r, hash: h, expected: expected, mismatch: mismatch}
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF && r.expected != "" {
+		if actual := hex.EncodeToString(r.hash.Sum(nil)); actual != r.expected {
+			return n, r.mismatch(r.expected, actual)
+		}
+	}
+	return n, err
+}
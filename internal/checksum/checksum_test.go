@@ -0,0 +1,49 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package checksum
+
+import (
+	"crypto/md5"
+	"errors"
+	"io/ioutil"
+	. "launchpad.net/gocheck"
+	"strings"
+	"testing"
+)
+
+type S struct{}
+
+var _ = Suite(&S{})
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+// dataMD5 is the checksum of the string "data".
+const dataMD5 = "8d777f385d3dfec8815d20f7496026dc"
+
+func mismatch(expected, actual string) error {
+	return errors.New("expected " + expected + ", got " + actual)
+}
+
+func (s *S) TestReader(c *C) {
+	r := NewReader(ioutil.NopCloser(strings.NewReader("data")), md5.New(), dataMD5, mismatch)
+	b, err := ioutil.ReadAll(r)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, "data")
+}
+
+func (s *S) TestReaderMismatch(c *C) {
+	r := NewReader(ioutil.NopCloser(strings.NewReader("corrupted data")), md5.New(), dataMD5, mismatch)
+	_, err := ioutil.ReadAll(r)
+	c.Assert(err, ErrorMatches, "^expected "+dataMD5+", got .*")
+}
+
+func (s *S) TestReaderWithoutExpectedChecksum(c *C) {
+	r := NewReader(ioutil.NopCloser(strings.NewReader("data")), md5.New(), "", mismatch)
+	b, err := ioutil.ReadAll(r)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, "data")
+}
@@ -0,0 +1,28 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package timeutil parses the timestamps returned by OpenStack services. It is
+// shared by the client packages and is not part of their API.
+package timeutil
+
+import (
+	"time"
+)
+
+// layouts are the layouts of the timestamps returned by OpenStack services.
+// Depending on the service and its version, timestamps may or may not include
+// fractional seconds and time zone.
+var layouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02T15:04:05"}
+
+// Parse parses the given timestamp, trying all known layouts. Timestamps
+// without time zone are in UTC. It returns the zero time when none of the
+// layouts matches.
+func Parse(value string) time.Time {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
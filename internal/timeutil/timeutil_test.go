@@ -0,0 +1,29 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package timeutil
+
+import (
+	. "launchpad.net/gocheck"
+	"testing"
+	"time"
+)
+
+type S struct{}
+
+var _ = Suite(&S{})
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+func (s *S) TestParse(c *C) {
+	expected := time.Date(2012, 8, 30, 16, 45, 22, 0, time.UTC)
+	c.Assert(Parse("2012-08-30T16:45:22Z").Equal(expected), Equals, true)
+	c.Assert(Parse("2012-08-30T16:45:22.000000Z").Equal(expected), Equals, true)
+	c.Assert(Parse("2012-08-30T16:45:22").Equal(expected), Equals, true)
+	c.Assert(Parse("2012-08-30T16:45:22.123456").Equal(expected.Add(123456*time.Microsecond)), Equals, true)
+	c.Assert(Parse("2012-08-30T13:45:22-03:00").Equal(expected), Equals, true)
+	c.Assert(Parse("yesterday").IsZero(), Equals, true)
+}
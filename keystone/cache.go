@@ -0,0 +1,186 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keystone
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultCacheMargin is the margin used by TokenCache when none is provided.
+const DefaultCacheMargin = 5 * time.Minute
+
+// TokenCache is an on-disk cache of authentication tokens. It allows
+// different processes (for example, several invocations of a command line
+// tool) to share the same token, instead of authenticating in keystone every
+// time.
+//
+// Each entry in the cache is keyed by the auth URL, the user, the password
+// and the tenant, so a wrong password never matches a cached token. Entries
+// store the token, its expiration and the service catalog. Cache files
+// are readable only by their owner (0600), and are locked while being read or
+// written, so concurrent processes never see a partially written entry.
+type TokenCache struct {
+	// Dir is the directory where cached tokens are stored. It is created on
+	// demand. When empty, the directory "go-openstack" in the user's cache
+	// directory (see os.UserCacheDir) is used.
+	Dir string
+
+	// Margin is how long before its expiration a cached token stops being
+	// reused. When zero, DefaultCacheMargin is used.
+	Margin time.Duration
+}
+
+// cacheVersion is the version of the format of cache entries. Entries in any
+// other version are ignored.
+const cacheVersion = 1
+
+type cacheEntry struct {
+	Version  int
+	AuthUrl  string
+	Token    string
+	Expires  time.Time
+	Catalogs []ServiceCatalog
+}
+
+// NewCachedClient works like NewClient, but first looks for a valid token in
+// the given cache. If there is no cached token, or the cached token is about
+// to expire, it authenticates in keystone and stores the new token in the
+// cache.
+//
+// Failures in reading or writing the cache are not fatal: NewCachedClient
+// falls back to authenticating in keystone. A nil cache makes it work exactly
+// like NewClient.
+func NewCachedClient(username, password, tenantName, authUrl string, cache *TokenCache) (*Client, error) {
+	if cache == nil {
+		return NewClient(username, password, tenantName, authUrl)
+	}
+	if client := cache.get(username, password, tenantName, authUrl); client != nil {
+		return client, nil
+	}
+	client, err := NewClient(username, password, tenantName, authUrl)
+	if err != nil {
+		return nil, err
+	}
+	cache.put(username, password, tenantName, authUrl, client)
+	return client, nil
+}
+
+// Remove removes the token of the given user and tenant from the cache. It is
+// useful when the cached token has been revoked before its expiration.
+func (tc *TokenCache) Remove(username, password, tenantName, authUrl string) error {
+	path, err := tc.path(username, password, tenantName, authUrl)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (tc *TokenCache) dir() (string, error) {
+	if tc.Dir != "" {
+		return tc.Dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-openstack"), nil
+}
+
+// path returns the file of the entry for the given credentials. The password
+// is hashed separately and then mixed into the key, which is itself a digest,
+// so the file name reveals nothing about it.
+func (tc *TokenCache) path(username, password, tenantName, authUrl string) (string, error) {
+	dir, err := tc.dir()
+	if err != nil {
+		return "", err
+	}
+	secret := sha1.Sum([]byte(password))
+	key := fmt.Sprintf("%x", sha1.Sum([]byte(fmt.Sprintf("%s\n%s\n%s\n%x", authUrl, username, tenantName, secret))))
+	return filepath.Join(dir, key+".json"), nil
+}
+
+func (tc *TokenCache) margin() time.Duration {
+	if tc.Margin == 0 {
+		return DefaultCacheMargin
+	}
+	return tc.Margin
+}
+
+func (tc *TokenCache) get(username, password, tenantName, authUrl string) *Client {
+	path, err := tc.path(username, password, tenantName, authUrl)
+	if err != nil {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	if err = lockFile(f, false); err != nil {
+		return nil
+	}
+	defer unlockFile(f)
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err = json.Unmarshal(b, &entry); err != nil || entry.Version != cacheVersion || entry.Token == "" {
+		return nil
+	}
+	if time.Now().Add(tc.margin()).After(entry.Expires) {
+		return nil
+	}
+	return &Client{
+		Token:    entry.Token,
+		Catalogs: entry.Catalogs,
+		Expires:  entry.Expires,
+		authUrl:  entry.AuthUrl,
+	}
+}
+
+func (tc *TokenCache) put(username, password, tenantName, authUrl string, client *Client) error {
+	if client.Expires.IsZero() {
+		return nil
+	}
+	b, err := json.Marshal(cacheEntry{Version: cacheVersion, AuthUrl: client.authUrl, Token: client.Token, Expires: client.Expires, Catalogs: client.Catalogs})
+	if err != nil {
+		return err
+	}
+	path, err := tc.path(username, password, tenantName, authUrl)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = lockFile(f, true); err != nil {
+		return err
+	}
+	defer unlockFile(f)
+	if err = f.Chmod(0600); err != nil {
+		return err
+	}
+	if err = f.Truncate(0); err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	return err
+}
@@ -0,0 +1,153 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keystone
+
+import (
+	"io/ioutil"
+	. "launchpad.net/gocheck"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func (s *S) responseExpiringIn(d time.Duration) string {
+	expires := time.Now().Add(d).UTC().Format(time.RFC3339)
+	return strings.Replace(s.response, "2012-08-30T16:45:22Z", expires, 1)
+}
+
+func (s *S) TestAuthStoresTokenExpiration(c *C) {
+	testServer.PrepareResponse(200, nil, s.response)
+	client, err := NewClient("username", "pass", "tenantname", testServer.URL+"/v2.0")
+	c.Assert(err, IsNil)
+	c.Assert(client.Expires.Equal(time.Date(2012, 8, 30, 16, 45, 22, 0, time.UTC)), Equals, true)
+}
+
+func (s *S) TestNewCachedClientStoresToken(c *C) {
+	cache := &TokenCache{Dir: filepath.Join(c.MkDir(), "tokens")}
+	testServer.PrepareResponse(200, nil, s.responseExpiringIn(time.Hour))
+	client, err := NewCachedClient("username", "pass", "tenantname", testServer.URL+"/v2.0", cache)
+	c.Assert(err, IsNil)
+	c.Assert(client.Token, Equals, "secret")
+	path, err := cache.path("username", "pass", "tenantname", testServer.URL+"/v2.0")
+	c.Assert(err, IsNil)
+	info, err := os.Stat(path)
+	c.Assert(err, IsNil)
+	c.Assert(info.Mode().Perm(), Equals, os.FileMode(0600))
+}
+
+func (s *S) TestTokenCacheDefaultDir(c *C) {
+	dir := c.MkDir()
+	for _, env := range []string{"XDG_CACHE_HOME", "HOME"} {
+		defer os.Setenv(env, os.Getenv(env))
+		os.Setenv(env, dir)
+	}
+	userDir, err := os.UserCacheDir()
+	c.Assert(err, IsNil)
+	cache := &TokenCache{}
+	testServer.PrepareResponse(200, nil, s.responseExpiringIn(time.Hour))
+	_, err = NewCachedClient("username", "pass", "tenantname", testServer.URL+"/v2.0", cache)
+	c.Assert(err, IsNil)
+	path, err := cache.path("username", "pass", "tenantname", testServer.URL+"/v2.0")
+	c.Assert(err, IsNil)
+	c.Assert(filepath.Dir(path), Equals, filepath.Join(userDir, "go-openstack"))
+	_, err = os.Stat(path)
+	c.Assert(err, IsNil)
+}
+
+func (s *S) TestNewCachedClientWithoutCache(c *C) {
+	testServer.PrepareResponse(200, nil, s.responseExpiringIn(time.Hour))
+	client, err := NewCachedClient("username", "pass", "tenantname", testServer.URL+"/v2.0", nil)
+	c.Assert(err, IsNil)
+	c.Assert(client.Token, Equals, "secret")
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+}
+
+func (s *S) TestNewCachedClientReusesToken(c *C) {
+	cache := &TokenCache{Dir: c.MkDir()}
+	testServer.PrepareResponse(200, nil, s.responseExpiringIn(time.Hour))
+	_, err := NewCachedClient("username", "pass", "tenantname", testServer.URL+"/v2.0", cache)
+	c.Assert(err, IsNil)
+	testServer.FlushRequests()
+	client, err := NewCachedClient("username", "pass", "tenantname", testServer.URL+"/v2.0", cache)
+	c.Assert(err, IsNil)
+	c.Assert(client.Token, Equals, "secret")
+	c.Assert(client.authUrl, Equals, testServer.URL+"/v2.0")
+	c.Assert(client.Catalogs, HasLen, 7)
+	c.Assert(client.Endpoint("compute", "admin"), Equals, "http://nova.mycloud.com:8774/v2/xpto")
+	_, _, err = testServer.WaitRequest(1e8)
+	c.Assert(err, NotNil)
+}
+
+func (s *S) TestNewCachedClientIsKeyedByTenant(c *C) {
+	cache := &TokenCache{Dir: c.MkDir()}
+	testServer.PrepareResponse(200, nil, s.responseExpiringIn(time.Hour))
+	_, err := NewCachedClient("username", "pass", "tenantname", testServer.URL+"/v2.0", cache)
+	c.Assert(err, IsNil)
+	testServer.FlushRequests()
+	testServer.PrepareResponse(200, nil, s.responseExpiringIn(time.Hour))
+	_, err = NewCachedClient("username", "pass", "othertenant", testServer.URL+"/v2.0", cache)
+	c.Assert(err, IsNil)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+}
+
+func (s *S) TestNewCachedClientIsKeyedByPassword(c *C) {
+	cache := &TokenCache{Dir: c.MkDir()}
+	testServer.PrepareResponse(200, nil, s.responseExpiringIn(time.Hour))
+	_, err := NewCachedClient("username", "pass", "tenantname", testServer.URL+"/v2.0", cache)
+	c.Assert(err, IsNil)
+	testServer.FlushRequests()
+	testServer.PrepareResponse(401, nil, `{"error": {"message": "The request you have made requires authentication.", "code": 401, "title": "Not Authorized"}}`)
+	client, err := NewCachedClient("username", "wrong", "tenantname", testServer.URL+"/v2.0", cache)
+	c.Assert(client, IsNil)
+	c.Assert(err, ErrorMatches, "^Not Authorized$")
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+}
+
+func (s *S) TestNewCachedClientAuthenticatesWhenTokenIsAboutToExpire(c *C) {
+	cache := &TokenCache{Dir: c.MkDir(), Margin: 10 * time.Minute}
+	testServer.PrepareResponse(200, nil, s.responseExpiringIn(5*time.Minute))
+	_, err := NewCachedClient("username", "pass", "tenantname", testServer.URL+"/v2.0", cache)
+	c.Assert(err, IsNil)
+	testServer.FlushRequests()
+	testServer.PrepareResponse(200, nil, s.responseExpiringIn(time.Hour))
+	_, err = NewCachedClient("username", "pass", "tenantname", testServer.URL+"/v2.0", cache)
+	c.Assert(err, IsNil)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+}
+
+func (s *S) TestNewCachedClientIgnoresBrokenCacheFile(c *C) {
+	cache := &TokenCache{Dir: c.MkDir()}
+	path, err := cache.path("username", "pass", "tenantname", testServer.URL+"/v2.0")
+	c.Assert(err, IsNil)
+	err = ioutil.WriteFile(path, []byte("{not json"), 0600)
+	c.Assert(err, IsNil)
+	testServer.PrepareResponse(200, nil, s.responseExpiringIn(time.Hour))
+	client, err := NewCachedClient("username", "pass", "tenantname", testServer.URL+"/v2.0", cache)
+	c.Assert(err, IsNil)
+	c.Assert(client.Token, Equals, "secret")
+	b, err := ioutil.ReadFile(path)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(b), `"Token":"secret"`), Equals, true)
+}
+
+func (s *S) TestTokenCacheRemove(c *C) {
+	cache := &TokenCache{Dir: c.MkDir()}
+	testServer.PrepareResponse(200, nil, s.responseExpiringIn(time.Hour))
+	_, err := NewCachedClient("username", "pass", "tenantname", testServer.URL+"/v2.0", cache)
+	c.Assert(err, IsNil)
+	err = cache.Remove("username", "pass", "tenantname", testServer.URL+"/v2.0")
+	c.Assert(err, IsNil)
+	path, err := cache.path("username", "pass", "tenantname", testServer.URL+"/v2.0")
+	c.Assert(err, IsNil)
+	_, err = os.Stat(path)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	err = cache.Remove("username", "pass", "tenantname", testServer.URL+"/v2.0")
+	c.Assert(err, IsNil)
+}
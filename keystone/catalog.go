@@ -0,0 +1,214 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keystone
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrEndpointNotFound is returned by FindEndpoint when no endpoint in the
+// service catalog matches the query.
+var ErrEndpointNotFound = errors.New("Endpoint not found: no endpoint in the service catalog matches the query.")
+
+// Endpoint represents an endpoint of a service. Each endpoint belongs to a
+// region, and is exposed in one interface: "public", "internal" or "admin".
+type Endpoint struct {
+	Id        string
+	Region    string
+	RegionId  string
+	Interface string
+	URL       string
+}
+
+// ServiceCatalog represents a service catalog. Each service has a name and a
+// type, and a collection of endpoints (one per region and interface).
+//
+// Example of ServiceCatalog instance (for nova):
+//
+//     ServiceCatalog{
+//         Name:      "Compute Service",
+//         Type:      "compute",
+//         Endpoints: []Endpoint{
+//             {Region: "RegionOne", Interface: "admin", URL: "http://mynova.com:8774/v2/tenant-id"},
+//             {Region: "RegionOne", Interface: "public", URL: "http://mynova.com:8774/v2/tenant-id"},
+//             {Region: "RegionOne", Interface: "internal", URL: "http://mynova.com:8774/v2/tenant-id"},
+//         },
+//     }
+type ServiceCatalog struct {
+	Endpoints []Endpoint
+	Type      string
+	Name      string
+}
+
+// EndpointQuery is used to find endpoints in the service catalog. Empty fields
+// match any value.
+//
+// Region matches both the name and the id of the region. Interface accepts
+// both the short form ("admin") and the keystone v2.0 form ("adminURL").
+type EndpointQuery struct {
+	Type      string
+	Name      string
+	Region    string
+	Interface string
+}
+
+func (q *EndpointQuery) matchService(catalog *ServiceCatalog) bool {
+	return (q.Type == "" || q.Type == catalog.Type) && (q.Name == "" || q.Name == catalog.Name)
+}
+
+func (q *EndpointQuery) matchEndpoint(endpoint *Endpoint) bool {
+	if q.Region != "" && q.Region != endpoint.Region && q.Region != endpoint.RegionId {
+		return false
+	}
+	return q.Interface == "" || strings.TrimSuffix(q.Interface, "URL") == endpoint.Interface
+}
+
+// v2Interfaces maps the keys used by keystone v2.0 in each endpoint to the
+// interface names.
+var v2Interfaces = []struct{ key, name string }{
+	{"publicURL", "public"},
+	{"internalURL", "internal"},
+	{"adminURL", "admin"},
+}
+
+// parseServiceCatalog parses a service from the catalog returned by keystone.
+//
+// Keystone v2.0 puts the URLs of all interfaces in the same endpoint, so they
+// are split into one Endpoint per interface. Endpoints already in the v3 form
+// (with "interface" and "url" keys) are parsed as is.
+func parseServiceCatalog(data map[string]interface{}) ServiceCatalog {
+	catalog := ServiceCatalog{}
+	catalog.Name, _ = data["name"].(string)
+	catalog.Type, _ = data["type"].(string)
+	endpoints, _ := data["endpoints"].([]interface{})
+	for _, e := range endpoints {
+		raw, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		str := func(key string) string {
+			v, _ := raw[key].(string)
+			return v
+		}
+		base := Endpoint{Id: str("id"), Region: str("region"), RegionId: str("region_id")}
+		if base.RegionId == "" {
+			base.RegionId = base.Region
+		}
+		if url := str("url"); url != "" {
+			base.Interface = str("interface")
+			base.URL = url
+			catalog.Endpoints = append(catalog.Endpoints, base)
+			continue
+		}
+		for _, iface := range v2Interfaces {
+			if url := str(iface.key); url != "" {
+				endpoint := base
+				endpoint.Interface = iface.name
+				endpoint.URL = url
+				catalog.Endpoints = append(catalog.Endpoints, endpoint)
+			}
+		}
+	}
+	return catalog
+}
+
+// ServiceByType returns the first service in the catalog with the given type,
+// or nil if there is no such service.
+func (c *Client) ServiceByType(serviceType string) *ServiceCatalog {
+	for i := range c.Catalogs {
+		if c.Catalogs[i].Type == serviceType {
+			return &c.Catalogs[i]
+		}
+	}
+	return nil
+}
+
+// ServiceByName returns the first service in the catalog with the given name,
+// or nil if there is no such service.
+func (c *Client) ServiceByName(name string) *ServiceCatalog {
+	for i := range c.Catalogs {
+		if c.Catalogs[i].Name == name {
+			return &c.Catalogs[i]
+		}
+	}
+	return nil
+}
+
+// FindEndpoints returns all endpoints in the service catalog that match the
+// given query, in the order they appear in the catalog.
+//
+// If there is an override for the type of the service (see
+// SetEndpointOverride), the URL of the returned endpoints is replaced by the
+// override.
+func (c *Client) FindEndpoints(query EndpointQuery) []Endpoint {
+	var endpoints []Endpoint
+	for i := range c.Catalogs {
+		catalog := &c.Catalogs[i]
+		if !query.matchService(catalog) {
+			continue
+		}
+		for _, endpoint := range catalog.Endpoints {
+			if query.matchEndpoint(&endpoint) {
+				if override, ok := c.Overrides[catalog.Type]; ok {
+					endpoint.URL = override
+				}
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+	}
+	return endpoints
+}
+
+// FindEndpoint returns the first endpoint that matches the given query, or
+// ErrEndpointNotFound if no endpoint matches it.
+//
+// Example of use:
+//
+//     endpoint, err := client.FindEndpoint(keystone.EndpointQuery{
+//         Type:      "compute",
+//         Region:    "RegionTwo",
+//         Interface: "internal",
+//     })
+func (c *Client) FindEndpoint(query EndpointQuery) (Endpoint, error) {
+	endpoints := c.FindEndpoints(query)
+	if len(endpoints) == 0 {
+		return Endpoint{}, ErrEndpointNotFound
+	}
+	return endpoints[0], nil
+}
+
+// Regions returns the names of all regions that have at least one endpoint in
+// the service catalog.
+func (c *Client) Regions() []string {
+	var regions []string
+	seen := make(map[string]bool)
+	for _, catalog := range c.Catalogs {
+		for _, endpoint := range catalog.Endpoints {
+			if endpoint.Region != "" && !seen[endpoint.Region] {
+				seen[endpoint.Region] = true
+				regions = append(regions, endpoint.Region)
+			}
+		}
+	}
+	return regions
+}
+
+// SetEndpointOverride makes the client use the given URL for all endpoints of
+// the given service type, instead of the URLs advertised in the catalog. It is
+// useful when the catalog contains hostnames that are not reachable by the
+// client.
+//
+// An empty URL removes the override.
+func (c *Client) SetEndpointOverride(serviceType, url string) {
+	if url == "" {
+		delete(c.Overrides, serviceType)
+		return
+	}
+	if c.Overrides == nil {
+		c.Overrides = make(map[string]string)
+	}
+	c.Overrides[serviceType] = url
+}
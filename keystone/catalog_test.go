@@ -0,0 +1,100 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keystone
+
+import (
+	. "launchpad.net/gocheck"
+)
+
+func catalogClient() *Client {
+	return &Client{
+		Catalogs: []ServiceCatalog{
+			{
+				Name: "Compute Service",
+				Type: "compute",
+				Endpoints: []Endpoint{
+					{Id: "1", Region: "RegionOne", RegionId: "r1", Interface: "public", URL: "http://nova1.mycloud.com:8774/v2/xpto"},
+					{Id: "2", Region: "RegionOne", RegionId: "r1", Interface: "internal", URL: "http://nova1.internal:8774/v2/xpto"},
+					{Id: "3", Region: "RegionTwo", RegionId: "r2", Interface: "public", URL: "http://nova2.mycloud.com:8774/v2/xpto"},
+				},
+			},
+			{
+				Name: "Image Service",
+				Type: "image",
+				Endpoints: []Endpoint{
+					{Id: "4", Region: "RegionTwo", RegionId: "r2", Interface: "public", URL: "http://glance2.mycloud.com:9292"},
+				},
+			},
+		},
+	}
+}
+
+func (s *S) TestParseServiceCatalogV3Endpoints(c *C) {
+	data := map[string]interface{}{
+		"name": "nova",
+		"type": "compute",
+		"endpoints": []interface{}{
+			map[string]interface{}{"id": "e1", "region": "RegionOne", "region_id": "r1", "interface": "public", "url": "http://nova.mycloud.com:8774/v2.1"},
+		},
+	}
+	catalog := parseServiceCatalog(data)
+	c.Assert(catalog, DeepEquals, ServiceCatalog{
+		Name: "nova",
+		Type: "compute",
+		Endpoints: []Endpoint{
+			{Id: "e1", Region: "RegionOne", RegionId: "r1", Interface: "public", URL: "http://nova.mycloud.com:8774/v2.1"},
+		},
+	})
+}
+
+func (s *S) TestServiceByTypeAndName(c *C) {
+	client := catalogClient()
+	c.Assert(client.ServiceByType("image").Name, Equals, "Image Service")
+	c.Assert(client.ServiceByType("volume"), IsNil)
+	c.Assert(client.ServiceByName("Compute Service").Type, Equals, "compute")
+	c.Assert(client.ServiceByName("Volume Service"), IsNil)
+}
+
+func (s *S) TestFindEndpoints(c *C) {
+	client := catalogClient()
+	endpoints := client.FindEndpoints(EndpointQuery{Type: "compute", Interface: "public"})
+	c.Assert(endpoints, HasLen, 2)
+	c.Assert(endpoints[0].Id, Equals, "1")
+	c.Assert(endpoints[1].Id, Equals, "3")
+	endpoints = client.FindEndpoints(EndpointQuery{Region: "RegionTwo"})
+	c.Assert(endpoints, HasLen, 2)
+	c.Assert(endpoints[0].Id, Equals, "3")
+	c.Assert(endpoints[1].Id, Equals, "4")
+	endpoints = client.FindEndpoints(EndpointQuery{Name: "Compute Service", Region: "r1", Interface: "internalURL"})
+	c.Assert(endpoints, HasLen, 1)
+	c.Assert(endpoints[0].Id, Equals, "2")
+	c.Assert(client.FindEndpoints(EndpointQuery{Type: "volume"}), HasLen, 0)
+}
+
+func (s *S) TestFindEndpoint(c *C) {
+	client := catalogClient()
+	endpoint, err := client.FindEndpoint(EndpointQuery{Type: "image"})
+	c.Assert(err, IsNil)
+	c.Assert(endpoint.URL, Equals, "http://glance2.mycloud.com:9292")
+	_, err = client.FindEndpoint(EndpointQuery{Type: "image", Region: "RegionOne"})
+	c.Assert(err, Equals, ErrEndpointNotFound)
+}
+
+func (s *S) TestRegions(c *C) {
+	client := catalogClient()
+	c.Assert(client.Regions(), DeepEquals, []string{"RegionOne", "RegionTwo"})
+}
+
+func (s *S) TestSetEndpointOverride(c *C) {
+	client := catalogClient()
+	client.SetEndpointOverride("compute", "http://localhost:8774/v2/xpto")
+	c.Assert(client.Endpoint("compute", "internal"), Equals, "http://localhost:8774/v2/xpto")
+	endpoint, err := client.FindEndpoint(EndpointQuery{Type: "compute", Region: "RegionTwo"})
+	c.Assert(err, IsNil)
+	c.Assert(endpoint.URL, Equals, "http://localhost:8774/v2/xpto")
+	c.Assert(client.Endpoint("image", "public"), Equals, "http://glance2.mycloud.com:9292")
+	client.SetEndpointOverride("compute", "")
+	c.Assert(client.Endpoint("compute", "internal"), Equals, "http://nova1.internal:8774/v2/xpto")
+}
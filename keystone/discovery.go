@@ -0,0 +1,297 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keystone
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrVersionNotFound is returned by ChooseVersion when the service does not
+// provide the requested major version.
+var ErrVersionNotFound = errors.New("Version not found: the service does not provide the requested version.")
+
+// Link represents a link in a version document.
+type Link struct {
+	Href string
+	Rel  string
+}
+
+// Version represents a version of an OpenStack API, as advertised in the
+// root version document of the service.
+//
+// Id is the version of the API (for example, "v2.1"). For services that
+// support microversions, Version and MinVersion are the maximum and minimum
+// microversions supported by the server (for example, "2.53" and "2.1").
+type Version struct {
+	Id         string
+	Status     string
+	Version    string
+	MinVersion string `json:"min_version"`
+	Links      []Link
+}
+
+// Number returns the major and minor numbers of the version, parsed from its
+// Id.
+func (v *Version) Number() (major, minor int, err error) {
+	m := versionId.FindStringSubmatch(v.Id)
+	if m == nil {
+		return 0, 0, fmt.Errorf("Invalid version id: %q.", v.Id)
+	}
+	major, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		minor, _ = strconv.Atoi(m[2])
+	}
+	return major, minor, nil
+}
+
+// segment returns the path segment that identifies the version in the URLs of
+// the service. It comes from the self link when there is one, because some
+// services use ids that do not match their paths (like "v3.14" in "/v3").
+func (v *Version) segment() string {
+	for _, link := range v.Links {
+		if link.Rel != "self" {
+			continue
+		}
+		if u, err := url.Parse(link.Href); err == nil {
+			segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+			if last := segments[len(segments)-1]; versionPath.MatchString(last) {
+				return last
+			}
+		}
+	}
+	return v.Id
+}
+
+var (
+	versionId   = regexp.MustCompile(`^v(\d+)(?:\.(\d+))?$`)
+	versionPath = regexp.MustCompile(`^v\d+(\.\d+)?$`)
+)
+
+// statusRank ranks the status of versions, so ChooseVersion can prefer
+// current versions over deprecated ones.
+var statusRank = map[string]int{
+	"CURRENT":    3,
+	"STABLE":     3,
+	"SUPPORTED":  2,
+	"DEPRECATED": 0,
+}
+
+// splitVersion splits the path of the given URL in the parts before and after
+// the version segment. When the path has no version segment, all of it goes
+// to prefix.
+func splitVersion(u *url.URL) (prefix, suffix []string) {
+	path := strings.Trim(u.Path, "/")
+	if path == "" {
+		return nil, nil
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if versionPath.MatchString(segment) {
+			return segments[:i], segments[i+1:]
+		}
+	}
+	return segments, nil
+}
+
+// hasVersion reports whether the path of the given endpoint includes a
+// version segment, like "/v2.0".
+func hasVersion(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	for _, segment := range strings.Split(strings.Trim(u.Path, "/"), "/") {
+		if versionPath.MatchString(segment) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinPath(segments ...[]string) string {
+	var all []string
+	for _, s := range segments {
+		all = append(all, s...)
+	}
+	return "/" + strings.Join(all, "/")
+}
+
+// RootURL returns the URL of the root version document of the service in the
+// given endpoint, stripping the version (and anything after it, like the
+// tenant id) from its path.
+//
+// Example:
+//
+//     RootURL("http://mynova.com:8774/v2/tenant-id") // returns "http://mynova.com:8774/"
+func RootURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	prefix, _ := splitVersion(u)
+	u.Path = joinPath(prefix)
+	if len(prefix) > 0 {
+		u.Path += "/"
+	}
+	u.RawQuery = ""
+	return u.String(), nil
+}
+
+// DiscoverVersions fetches the root version document of the service in the
+// given endpoint, returning all versions advertised by the service.
+//
+// The endpoint may or may not include a version suffix: the version document
+// is always requested from the root of the service (see RootURL).
+func DiscoverVersions(endpoint string) ([]Version, error) {
+	return DiscoverVersionsContext(context.Background(), endpoint)
+}
+
+// DiscoverVersionsContext works like DiscoverVersions, but aborts the request
+// when the given context is done.
+func DiscoverVersionsContext(ctx context.Context, endpoint string) ([]Version, error) {
+	root, err := RootURL(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, "GET", root, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, errors.New("Failed to get the version document: " + err.Error())
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, errors.New("Failed to get the version document: " + err.Error())
+	}
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusMultipleChoices {
+		return nil, fmt.Errorf("Failed to get the version document, status: %d.\nBody: %s.", response.StatusCode, body)
+	}
+	return parseVersions(body)
+}
+
+// parseVersions parses a version document. Most services return a list of
+// versions in the "versions" key, but keystone wraps the list in a "values"
+// key, and a versioned URL returns a single "version".
+func parseVersions(body []byte) ([]Version, error) {
+	var doc struct {
+		Versions json.RawMessage
+		Version  *Version
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, errors.New("Failed to get the version document, the server did not respond a valid JSON.")
+	}
+	if doc.Version != nil {
+		return []Version{*doc.Version}, nil
+	}
+	var versions []Version
+	if err := json.Unmarshal(doc.Versions, &versions); err == nil {
+		return versions, nil
+	}
+	var wrapped struct{ Values []Version }
+	if err := json.Unmarshal(doc.Versions, &wrapped); err != nil {
+		return nil, errors.New("Failed to get the version document, the server did not respond a valid JSON.")
+	}
+	return wrapped.Values, nil
+}
+
+// ChooseVersion returns the best version with the given major number. Current
+// and supported versions are preferred over deprecated ones and, among
+// versions with the same status, the highest minor number wins.
+//
+// It returns ErrVersionNotFound if no version matches the major number.
+func ChooseVersion(versions []Version, major int) (*Version, error) {
+	var (
+		best                *Version
+		bestRank, bestMinor int
+	)
+	for i := range versions {
+		vmajor, vminor, err := versions[i].Number()
+		if err != nil || vmajor != major {
+			continue
+		}
+		rank, ok := statusRank[strings.ToUpper(versions[i].Status)]
+		if !ok {
+			rank = 1
+		}
+		if best == nil || rank > bestRank || (rank == bestRank && vminor > bestMinor) {
+			best, bestRank, bestMinor = &versions[i], rank, vminor
+		}
+	}
+	if best == nil {
+		return nil, ErrVersionNotFound
+	}
+	return best, nil
+}
+
+// VersionedURL rewrites the given endpoint so it points to the given version
+// of the service, keeping everything that comes after the version in the path
+// (like the tenant id).
+//
+// Examples:
+//
+//     VersionedURL("http://mynova.com:8774/v2/tenant-id", v21) // returns "http://mynova.com:8774/v2.1/tenant-id"
+//     VersionedURL("http://mynova.com:8774", v21)              // returns "http://mynova.com:8774/v2.1"
+func VersionedURL(endpoint string, version *Version) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	prefix, suffix := splitVersion(u)
+	u.Path = joinPath(prefix, []string{version.segment()}, suffix)
+	return u.String(), nil
+}
+
+// DiscoverURL discovers the versions of the service in the given endpoint,
+// chooses the best one with the given major number and returns the endpoint
+// rewritten to use it, along with the chosen version.
+func DiscoverURL(endpoint string, major int) (string, *Version, error) {
+	return DiscoverURLContext(context.Background(), endpoint, major)
+}
+
+// DiscoverURLContext works like DiscoverURL, but aborts the discovery when the
+// given context is done.
+func DiscoverURLContext(ctx context.Context, endpoint string, major int) (string, *Version, error) {
+	versions, err := DiscoverVersionsContext(ctx, endpoint)
+	if err != nil {
+		return "", nil, err
+	}
+	version, err := ChooseVersion(versions, major)
+	if err != nil {
+		return "", nil, err
+	}
+	versioned, err := VersionedURL(endpoint, version)
+	if err != nil {
+		return "", nil, err
+	}
+	return versioned, version, nil
+}
+
+// ServiceURL returns the endpoint of the given service and type of URL (see
+// Endpoint), rewritten to point to the best available version of the service
+// with the given major number.
+//
+// Example of use:
+//
+//     url, err := client.ServiceURL("compute", "public", 2) // "http://mynova.com:8774/v2.1/tenant-id"
+func (c *Client) ServiceURL(service, which string, major int) (string, error) {
+	endpoint := c.Endpoint(service, which)
+	if endpoint == "" {
+		return "", ErrEndpointNotFound
+	}
+	versioned, _, err := DiscoverURL(endpoint, major)
+	return versioned, err
+}
@@ -0,0 +1,124 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keystone
+
+import (
+	. "launchpad.net/gocheck"
+)
+
+const computeVersions = `{"versions": [{"status": "SUPPORTED", "links": [{"href": "http://nova.internal:8774/v2/", "rel": "self"}], "min_version": "", "version": "", "id": "v2.0"}, {"status": "CURRENT", "links": [{"href": "http://nova.internal:8774/v2.1/", "rel": "self"}], "min_version": "2.1", "version": "2.53", "id": "v2.1"}]}`
+
+const identityVersions = `{"versions": {"values": [{"status": "stable", "id": "v3.14", "links": [{"href": "http://localhost:4444/v3/", "rel": "self"}]}, {"status": "deprecated", "id": "v2.0", "links": [{"href": "http://localhost:4444/v2.0/", "rel": "self"}]}]}}`
+
+func (s *S) TestRootURL(c *C) {
+	root, err := RootURL("http://nova.mycloud.com:8774/v2/tenant-id")
+	c.Assert(err, IsNil)
+	c.Assert(root, Equals, "http://nova.mycloud.com:8774/")
+	root, err = RootURL("https://mycloud.com/compute/v2.1")
+	c.Assert(err, IsNil)
+	c.Assert(root, Equals, "https://mycloud.com/compute/")
+	root, err = RootURL("http://nova.mycloud.com:8774")
+	c.Assert(err, IsNil)
+	c.Assert(root, Equals, "http://nova.mycloud.com:8774/")
+}
+
+func (s *S) TestVersionNumber(c *C) {
+	v := Version{Id: "v2.1"}
+	major, minor, err := v.Number()
+	c.Assert(err, IsNil)
+	c.Assert(major, Equals, 2)
+	c.Assert(minor, Equals, 1)
+	v = Version{Id: "v3"}
+	major, minor, err = v.Number()
+	c.Assert(err, IsNil)
+	c.Assert(major, Equals, 3)
+	c.Assert(minor, Equals, 0)
+	v = Version{Id: "latest"}
+	_, _, err = v.Number()
+	c.Assert(err, NotNil)
+}
+
+func (s *S) TestParseVersions(c *C) {
+	versions, err := parseVersions([]byte(computeVersions))
+	c.Assert(err, IsNil)
+	c.Assert(versions, HasLen, 2)
+	c.Assert(versions[1].MinVersion, Equals, "2.1")
+	versions, err = parseVersions([]byte(identityVersions))
+	c.Assert(err, IsNil)
+	c.Assert(versions, HasLen, 2)
+	c.Assert(versions[0].Id, Equals, "v3.14")
+	versions, err = parseVersions([]byte(`{"version": {"id": "v2.1", "status": "CURRENT", "version": "2.53", "min_version": "2.1"}}`))
+	c.Assert(err, IsNil)
+	c.Assert(versions, DeepEquals, []Version{{Id: "v2.1", Status: "CURRENT", Version: "2.53", MinVersion: "2.1"}})
+	_, err = parseVersions([]byte("not json"))
+	c.Assert(err, NotNil)
+}
+
+func (s *S) TestChooseVersion(c *C) {
+	versions := []Version{
+		{Id: "v2.0", Status: "DEPRECATED"},
+		{Id: "v2.1", Status: "CURRENT"},
+		{Id: "v2.2", Status: "EXPERIMENTAL"},
+		{Id: "v3.0", Status: "CURRENT"},
+	}
+	v, err := ChooseVersion(versions, 2)
+	c.Assert(err, IsNil)
+	c.Assert(v.Id, Equals, "v2.1")
+	v, err = ChooseVersion(versions, 3)
+	c.Assert(err, IsNil)
+	c.Assert(v.Id, Equals, "v3.0")
+	_, err = ChooseVersion(versions, 4)
+	c.Assert(err, Equals, ErrVersionNotFound)
+}
+
+func (s *S) TestVersionedURL(c *C) {
+	v := &Version{Id: "v2.1"}
+	u, err := VersionedURL("http://nova.mycloud.com:8774/v2/tenant-id", v)
+	c.Assert(err, IsNil)
+	c.Assert(u, Equals, "http://nova.mycloud.com:8774/v2.1/tenant-id")
+	u, err = VersionedURL("http://nova.mycloud.com:8774", v)
+	c.Assert(err, IsNil)
+	c.Assert(u, Equals, "http://nova.mycloud.com:8774/v2.1")
+	v = &Version{Id: "v3.14", Links: []Link{{Href: "http://keystone.internal:5000/v3/", Rel: "self"}}}
+	u, err = VersionedURL("https://mycloud.com/identity", v)
+	c.Assert(err, IsNil)
+	c.Assert(u, Equals, "https://mycloud.com/identity/v3")
+}
+
+func (s *S) TestDiscoverURL(c *C) {
+	testServer.PrepareResponse(300, nil, identityVersions)
+	u, version, err := DiscoverURL(testServer.URL+"/v2.0", 3)
+	c.Assert(err, IsNil)
+	c.Assert(u, Equals, "http://localhost:4444/v3")
+	c.Assert(version.Id, Equals, "v3.14")
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/")
+}
+
+func (s *S) TestDiscoverURLFailure(c *C) {
+	testServer.PrepareResponse(500, nil, "Internal error")
+	_, _, err := DiscoverURL(testServer.URL, 3)
+	c.Assert(err, ErrorMatches, "^Failed to get the version document, status: 500.\nBody: Internal error.$")
+}
+
+func (s *S) TestServiceURL(c *C) {
+	client := Client{
+		Catalogs: []ServiceCatalog{
+			{
+				Type: "compute",
+				Endpoints: []Endpoint{
+					{Interface: "public", URL: testServer.URL + "/v2/xpto"},
+				},
+			},
+		},
+	}
+	testServer.PrepareResponse(300, nil, computeVersions)
+	u, err := client.ServiceURL("compute", "public", 2)
+	c.Assert(err, IsNil)
+	c.Assert(u, Equals, "http://localhost:4444/v2.1/xpto")
+	_, err = client.ServiceURL("image", "public", 2)
+	c.Assert(err, Equals, ErrEndpointNotFound)
+}
@@ -14,35 +14,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/globocom/go-openstack/internal/timeutil"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 )
 
-// ServiceCatalog represents a service catalog. Each service has a name and a
-// type, and a collection of endpoints (one per region).
-//
-// Example of ServiceCatalog instance (for nova):
-//
-//     ServiceCatalog{
-//         Name:      "Compute Service",
-//         Type:      "compute",
-//         Endpoints: []map[string]string{
-//             map[string]string{
-//                 "region":      "RegionOne",
-//                 "adminURL":    "http://mynova.com:8774/v2/tenant-id",
-//                 "publicURL":   "http://mynova.com:8774/v2/tenant-id",
-//                 "internalURL": "http://mynova.com:8774/v2/tenant-id",
-//             },
-//         },
-//     }
-type ServiceCatalog struct {
-	Endpoints []map[string]string
-	Type      string
-	Name      string
-}
-
 // Client represents a keystone connection client. It stores the authenticatin
 // token and a lista of service catalogs (see ServiceCatalog type).
 type Client struct {
@@ -55,6 +34,18 @@ type Client struct {
 	// details).
 	Catalogs []ServiceCatalog
 
+	// Region is the region used when looking for endpoints. When empty, the
+	// first endpoint of the service is used, regardless of its region.
+	Region string
+
+	// Overrides maps service types to base URLs that should be used instead
+	// of the URLs advertised in the service catalog. See SetEndpointOverride.
+	Overrides map[string]string
+
+	// Expires is the time when Token stops being valid. It is zero when
+	// keystone does not inform the expiration of the token.
+	Expires time.Time
+
 	authUrl string
 }
 
@@ -86,7 +77,19 @@ type Ec2 struct {
 // For authentication, it uses the parameters username, password and tenantName
 // to issue a request to the authUrl. The new generated token is stored in the
 // Client instance, as is the service catalog.
+//
+// The authUrl may or may not include the version of the API: when it does not
+// (for example, "http://example.com:35357"), NewClient discovers the endpoint
+// of the version 2 of the API in the version document of the service (see
+// DiscoverURL).
 func NewClient(username, password, tenantName, authUrl string) (*Client, error) {
+	if !hasVersion(authUrl) {
+		versioned, _, err := DiscoverURL(authUrl, 2)
+		if err != nil {
+			return nil, err
+		}
+		authUrl = versioned
+	}
 	b := bytes.NewBufferString(fmt.Sprintf(`{"auth": {"passwordCredentials": {"username": "%s", "password":"%s"}, "tenantName": "%s"}}`, username, password, tenantName))
 	response, err := http.Post(authUrl+"/tokens", "application/json", b)
 	if err != nil {
@@ -102,34 +105,31 @@ func NewClient(username, password, tenantName, authUrl string) (*Client, error)
 	if response.StatusCode > 399 {
 		return nil, errors.New(data["error"]["title"].(string))
 	}
-	token := data["access"]["token"].(map[string]interface{})["id"].(string)
-	client := Client{Token: token, authUrl: authUrl}
+	token := data["access"]["token"].(map[string]interface{})
+	client := Client{Token: token["id"].(string), authUrl: authUrl}
+	if expires, ok := token["expires"].(string); ok {
+		client.Expires = timeutil.Parse(expires)
+	}
 	catalogs, ok := data["access"]["serviceCatalog"].([]interface{})
 	if !ok {
 		return nil, errors.New("Error while accessing serviceCatalog key in returned json")
 	}
 	for _, c := range catalogs {
-		catalog := c.(map[string]interface{})
-		serviceCatalog := ServiceCatalog{
-			Name: catalog["name"].(string),
-			Type: catalog["type"].(string),
-		}
-		for _, e := range catalog["endpoints"].([]interface{}) {
-			endpoint := map[string]string{}
-			for k, v := range e.(map[string]interface{}) {
-				endpoint[k] = v.(string)
-			}
-			serviceCatalog.Endpoints = append(serviceCatalog.Endpoints, endpoint)
+		catalog, ok := c.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("Error while accessing serviceCatalog key in returned json")
 		}
-		client.Catalogs = append(client.Catalogs, serviceCatalog)
+		client.Catalogs = append(client.Catalogs, parseServiceCatalog(catalog))
 	}
 	return &client, nil
 }
 
 // Endpoint returns the endpoint string for the given service and type of URL.
 //
-// The endpoint is get from the service catalog. If the given service or URL
-// type is not present in the catalog, Endpoint returns an empty string.
+// The endpoint is get from the service catalog, in the region defined by the
+// Region field of the client (or in any region, if Region is empty). If the
+// given service or URL type is not present in the catalog, Endpoint returns an
+// empty string.
 //
 // Examples of use:
 //
@@ -138,23 +138,26 @@ func NewClient(username, password, tenantName, authUrl string) (*Client, error)
 //     endpoint = client.Endpoint("compute", "admin") // note that you can omit "URL" and it still works
 //     endpoint = client.Endpoint("unknownservice", "adminURL") // returns ""
 //     endpoint = client.Endpoint("compute", "unknownURL") // returns ""
+//     endpoint = client.Endpoint("compute", "") // returns ""
+//
+// For more elaborated queries, see the FindEndpoint method.
 func (c *Client) Endpoint(service, which string) string {
-	var (
-		endpoint string
-		catalog  ServiceCatalog
-	)
-	for _, catalog = range c.Catalogs {
-		if catalog.Type == service {
-			break
-		}
+	if which == "" {
+		return ""
 	}
-	if catalog.Type == service {
-		if !strings.Contains(which, "URL") {
-			which += "URL"
-		}
-		endpoint = catalog.Endpoints[0][which] // TODO(fsouza): choose region
+	if !strings.Contains(which, "URL") {
+		which += "URL"
+	}
+	query := EndpointQuery{
+		Type:      service,
+		Interface: which,
+		Region:    c.Region,
+	}
+	endpoint, err := c.FindEndpoint(query)
+	if err != nil {
+		return ""
 	}
-	return endpoint
+	return endpoint.URL
 }
 
 func (c *Client) do(method, urlStr string, body io.Reader) (*http.Response, error) {
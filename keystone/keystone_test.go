@@ -11,7 +11,7 @@ import (
 
 func (s *S) TestAuthFailure(c *C) {
 	testServer.PrepareResponse(401, nil, `{"error": {"message": "Invalid user / password", "code": 401, "title": "Not Authorized"}}`)
-	client, err := NewClient("username", "bad_pass", "tenantname", "http://localhost:4444")
+	client, err := NewClient("username", "bad_pass", "tenantname", "http://localhost:4444/v2.0")
 	c.Assert(client, IsNil)
 	c.Assert(err, NotNil)
 	c.Assert(err, ErrorMatches, "Not Authorized")
@@ -19,35 +19,51 @@ func (s *S) TestAuthFailure(c *C) {
 
 func (s *S) TestAuth(c *C) {
 	testServer.PrepareResponse(200, nil, s.response)
-	client, err := NewClient("username", "pass", "tenantname", testServer.URL)
+	client, err := NewClient("username", "pass", "tenantname", testServer.URL+"/v2.0")
 	c.Assert(err, IsNil)
 	c.Assert(client, NotNil)
 	c.Assert(client.Token, Equals, "secret")
-	c.Assert(client.authUrl, Equals, "http://localhost:4444")
+	c.Assert(client.authUrl, Equals, "http://localhost:4444/v2.0")
 	c.Assert(client.Catalogs, HasLen, 7)
 	c.Assert(client.Catalogs[0].Name, Equals, "Compute Service")
 	c.Assert(client.Catalogs[0].Type, Equals, "compute")
 }
 
+func (s *S) TestAuthDiscoversTheVersionOfUnversionedURL(c *C) {
+	testServer.PrepareResponse(300, nil, identityVersions)
+	testServer.PrepareResponse(200, nil, s.response)
+	client, err := NewClient("username", "pass", "tenantname", testServer.URL)
+	c.Assert(err, IsNil)
+	c.Assert(client.authUrl, Equals, "http://localhost:4444/v2.0")
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/")
+	req, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.0/tokens")
+}
+
 func (s *S) TestAuthFailureInServiceCatalog(c *C) {
 	testServer.PrepareResponse(200, nil, s.brokenResponse)
-	_, err := NewClient("username", "pass", "tenantname", testServer.URL)
+	_, err := NewClient("username", "pass", "tenantname", testServer.URL+"/v2.0")
 	c.Assert(err, NotNil)
 	c.Assert(err, ErrorMatches, "^Error while accessing serviceCatalog key in returned json$")
 }
 
 func (s *S) TestEndpoint(c *C) {
 	testServer.PrepareResponse(200, nil, s.response)
-	client, err := NewClient("username", "pass", "tenantname", testServer.URL)
+	client, err := NewClient("username", "pass", "tenantname", testServer.URL+"/v2.0")
 	c.Assert(err, IsNil)
 	c.Assert(client.Endpoint("compute", "admin"), Equals, "http://nova.mycloud.com:8774/v2/xpto")
 	c.Assert(client.Endpoint("compute", "adminURL"), Equals, "http://nova.mycloud.com:8774/v2/xpto")
 	c.Assert(client.Endpoint("sempute", "admin"), Equals, "")
+	c.Assert(client.Endpoint("compute", ""), Equals, "")
+	c.Assert(client.Endpoint("compute", "unknownURL"), Equals, "")
 }
 
 func (s *S) TestNewTenant(c *C) {
 	testServer.PrepareResponse(200, nil, s.response)
-	client, err := NewClient("username", "pass", "admin", "http://localhost:4444")
+	client, err := NewClient("username", "pass", "admin", "http://localhost:4444/v2.0")
 	c.Assert(err, IsNil)
 	c.Assert(client, NotNil)
 	testServer.PrepareResponse(200, nil, `{"tenant": {"id": "xpto", "enabled": "true", "name": "name", "description": "desc"}}`)
@@ -59,7 +75,7 @@ func (s *S) TestNewTenant(c *C) {
 
 func (s *S) TestNewTenantReturning500(c *C) {
 	testServer.PrepareResponse(200, nil, s.response)
-	client, err := NewClient("username", "pass", "admin", "http://localhost:4444")
+	client, err := NewClient("username", "pass", "admin", "http://localhost:4444/v2.0")
 	c.Assert(err, IsNil)
 	c.Assert(client, NotNil)
 	testServer.FlushRequests()
@@ -71,7 +87,7 @@ func (s *S) TestNewTenantReturning500(c *C) {
 
 func (s *S) TestNewUser(c *C) {
 	testServer.PrepareResponse(200, nil, s.response)
-	client, err := NewClient("username", "pass", "admin", "http://localhost:4444")
+	client, err := NewClient("username", "pass", "admin", "http://localhost:4444/v2.0")
 	c.Assert(err, IsNil)
 	c.Assert(client, NotNil)
 	testServer.PrepareResponse(200, nil, `{"user": {"id": "userId", "enabled": "true", "name": "Stark", "email": "stark@stark.com"}}`)
@@ -84,7 +100,7 @@ func (s *S) TestNewUser(c *C) {
 
 func (s *S) TestNewEc2(c *C) {
 	testServer.PrepareResponse(200, nil, s.response)
-	client, err := NewClient("username", "pass", "admin", "http://localhost:4444")
+	client, err := NewClient("username", "pass", "admin", "http://localhost:4444/v2.0")
 	c.Assert(err, IsNil)
 	c.Assert(client, NotNil)
 	testServer.PrepareResponse(200, nil, `{"credential": {"access": "access", "secret": "secret"}}`)
@@ -96,7 +112,7 @@ func (s *S) TestNewEc2(c *C) {
 
 func (s *S) TestAddRoleToUser(c *C) {
 	testServer.PrepareResponse(200, nil, s.response)
-	client, err := NewClient("username", "pass", "admin", "http://localhost:4444")
+	client, err := NewClient("username", "pass", "admin", "http://localhost:4444/v2.0")
 	c.Assert(err, IsNil)
 	c.Assert(client, NotNil)
 	testServer.FlushRequests()
@@ -105,13 +121,13 @@ func (s *S) TestAddRoleToUser(c *C) {
 	c.Assert(err, IsNil)
 	var request *http.Request
 	request = <-testServer.Request
-	expectedUrl := "/tenants/tenant-uuid-567/users/user-uuid4321/roles/OS-KSADM/role-uuid-1234"
+	expectedUrl := "/v2.0/tenants/tenant-uuid-567/users/user-uuid4321/roles/OS-KSADM/role-uuid-1234"
 	c.Assert(request.URL.Path, Equals, expectedUrl)
 }
 
 func (s *S) TestAddRoleToUserFailure(c *C) {
 	testServer.PrepareResponse(200, nil, s.response)
-	client, err := NewClient("username", "pass", "admin", "http://localhost:4444")
+	client, err := NewClient("username", "pass", "admin", "http://localhost:4444/v2.0")
 	c.Assert(err, IsNil)
 	c.Assert(client, NotNil)
 	testServer.PrepareResponse(500, nil, "")
@@ -122,7 +138,7 @@ func (s *S) TestAddRoleToUserFailure(c *C) {
 
 func (s *S) TestRemoveEc2(c *C) {
 	testServer.PrepareResponse(200, nil, s.response)
-	client, err := NewClient("username", "pass", "admin", "http://localhost:4444")
+	client, err := NewClient("username", "pass", "admin", "http://localhost:4444/v2.0")
 	c.Assert(err, IsNil)
 	c.Assert(client, NotNil)
 	testServer.PrepareResponse(200, nil, "")
@@ -132,7 +148,7 @@ func (s *S) TestRemoveEc2(c *C) {
 
 func (s *S) TestRemoveEc2ReturnErrorIfItFailsToRemoveCredentials(c *C) {
 	testServer.PrepareResponse(200, nil, s.response)
-	client, err := NewClient("username", "pass", "admin", "http://localhost:4444")
+	client, err := NewClient("username", "pass", "admin", "http://localhost:4444/v2.0")
 	c.Assert(err, IsNil)
 	testServer.PrepareResponse(500, nil, "Failed to remove credential.")
 	err = client.RemoveEc2("stark123", "access-key")
@@ -142,7 +158,7 @@ func (s *S) TestRemoveEc2ReturnErrorIfItFailsToRemoveCredentials(c *C) {
 
 func (s *S) TestRemoveRoleFromUser(c *C) {
 	testServer.PrepareResponse(200, nil, s.response)
-	client, err := NewClient("username", "pass", "admin", "http://localhost:4444")
+	client, err := NewClient("username", "pass", "admin", "http://localhost:4444/v2.0")
 	c.Assert(err, IsNil)
 	testServer.FlushRequests()
 	testServer.PrepareResponse(200, nil, "")
@@ -150,14 +166,14 @@ func (s *S) TestRemoveRoleFromUser(c *C) {
 	c.Assert(err, IsNil)
 	var request *http.Request
 	request = <-testServer.Request
-	expectedUrl := "/tenants/tenant-uuid/users/user-uuid/roles/OS-KSADM/role-uuid"
+	expectedUrl := "/v2.0/tenants/tenant-uuid/users/user-uuid/roles/OS-KSADM/role-uuid"
 	c.Assert(request.URL.Path, Equals, expectedUrl)
 	c.Assert(request.Method, Equals, "DELETE")
 }
 
 func (s *S) TestRemoveRoleFromUserReturning500(c *C) {
 	testServer.PrepareResponse(200, nil, s.response)
-	client, err := NewClient("username", "pass", "admin", "http://localhost:4444")
+	client, err := NewClient("username", "pass", "admin", "http://localhost:4444/v2.0")
 	c.Assert(err, IsNil)
 	testServer.FlushRequests()
 	testServer.PrepareResponse(500, nil, "")
@@ -168,7 +184,7 @@ func (s *S) TestRemoveRoleFromUserReturning500(c *C) {
 
 func (s *S) TestRemoveUser(c *C) {
 	testServer.PrepareResponse(200, nil, s.response)
-	client, err := NewClient("username", "pass", "admin", "http://localhost:4444")
+	client, err := NewClient("username", "pass", "admin", "http://localhost:4444/v2.0")
 	c.Assert(err, IsNil)
 	c.Assert(client, NotNil)
 	testServer.PrepareResponse(200, nil, "")
@@ -178,7 +194,7 @@ func (s *S) TestRemoveUser(c *C) {
 
 func (s *S) TestRemoveUserReturnErrorIfItFailsToRemoveUser(c *C) {
 	testServer.PrepareResponse(200, nil, s.response)
-	client, err := NewClient("username", "pass", "admin", "http://localhost:4444")
+	client, err := NewClient("username", "pass", "admin", "http://localhost:4444/v2.0")
 	c.Assert(err, IsNil)
 	testServer.PrepareResponse(500, nil, "Failed to remove user.")
 	err = client.RemoveUser("start123")
@@ -191,7 +207,7 @@ func (s *S) TestRemoveUserReturnErrorIfItFailsToRemoveTheRole(c *C) {
 	// HTTP server (see the FIXME note in the RemoveUser function).
 	c.SucceedNow()
 	testServer.PrepareResponse(200, nil, s.response)
-	client, _ := NewClient("username", "pass", "admin", "http://localhost:4444")
+	client, _ := NewClient("username", "pass", "admin", "http://localhost:4444/v2.0")
 	testServer.PrepareResponse(500, nil, "Failed to remove the role.")
 	err := client.RemoveUser("start123")
 	c.Assert(err, NotNil)
@@ -200,7 +216,7 @@ func (s *S) TestRemoveUserReturnErrorIfItFailsToRemoveTheRole(c *C) {
 
 func (s *S) TestRemoveTenant(c *C) {
 	testServer.PrepareResponse(200, nil, s.response)
-	client, err := NewClient("username", "pass", "admin", "http://localhost:4444")
+	client, err := NewClient("username", "pass", "admin", "http://localhost:4444/v2.0")
 	c.Assert(err, IsNil)
 	c.Assert(client, NotNil)
 	body := `{"tenant": {"id": "xpto", "enabled": "true", "name": "name", "description": "desc"}}`
@@ -218,7 +234,7 @@ func (s *S) TestRemoveTenantReturnErrorIfItFailsToRemoveATenant(c *C) {
 	// HTTP server (see the FIXME note in the RemoveTenant function).
 	c.SucceedNow()
 	testServer.PrepareResponse(200, nil, s.response)
-	client, err := NewClient("username", "pass", "admin", testServer.URL)
+	client, err := NewClient("username", "pass", "admin", testServer.URL+"/v2.0")
 	c.Assert(err, IsNil)
 	c.Assert(client, NotNil)
 	testServer.PrepareResponse(500, nil, "Failed to delete tenant.")
@@ -226,3 +242,33 @@ func (s *S) TestRemoveTenantReturnErrorIfItFailsToRemoveATenant(c *C) {
 	c.Assert(err, NotNil)
 	c.Assert(err, ErrorMatches, "^Failed to delete tenant.$")
 }
+
+func (s *S) TestAuthParsesEndpoints(c *C) {
+	testServer.PrepareResponse(200, nil, s.response)
+	client, err := NewClient("username", "pass", "tenantname", testServer.URL+"/v2.0")
+	c.Assert(err, IsNil)
+	expected := []Endpoint{
+		{Region: "RegionOne", RegionId: "RegionOne", Interface: "public", URL: "http://glance.mycloud.com:9292/v1"},
+		{Region: "RegionOne", RegionId: "RegionOne", Interface: "internal", URL: "http://glance.mycloud.com:9292"},
+		{Region: "RegionOne", RegionId: "RegionOne", Interface: "admin", URL: "http://glance.mycloud.com:9292/v1"},
+	}
+	c.Assert(client.Catalogs[2].Endpoints, DeepEquals, expected)
+}
+
+func (s *S) TestEndpointInRegion(c *C) {
+	client := Client{
+		Region: "RegionTwo",
+		Catalogs: []ServiceCatalog{
+			{
+				Type: "compute",
+				Endpoints: []Endpoint{
+					{Region: "RegionOne", Interface: "admin", URL: "http://nova1.mycloud.com:8774/v2/xpto"},
+					{Region: "RegionTwo", Interface: "admin", URL: "http://nova2.mycloud.com:8774/v2/xpto"},
+				},
+			},
+		},
+	}
+	c.Assert(client.Endpoint("compute", "admin"), Equals, "http://nova2.mycloud.com:8774/v2/xpto")
+	client.Region = "RegionThree"
+	c.Assert(client.Endpoint("compute", "admin"), Equals, "")
+}
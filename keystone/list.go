@@ -0,0 +1,83 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keystone
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/globocom/go-openstack/pagination"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func (c *Client) list(ctx context.Context, path string, limit int, marker string, out interface{}) error {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if marker != "" {
+		query.Set("marker", marker)
+	}
+	urlStr := c.authUrl + path
+	if len(query) > 0 {
+		urlStr += "?" + query.Encode()
+	}
+	request, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("X-Auth-Token", c.Token)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return errorFromResponse(response)
+	}
+	defer response.Body.Close()
+	return json.NewDecoder(response.Body).Decode(out)
+}
+
+// TenantPager returns a pager over the tenants, fetching at most limit
+// tenants per request. A limit of 0 lets keystone decide the size of the
+// pages.
+func (c *Client) TenantPager(limit int) *pagination.Pager[Tenant] {
+	return pagination.New(func(ctx context.Context, marker string) (*pagination.Page[Tenant], error) {
+		var result struct {
+			Tenants []Tenant
+			Links   []pagination.Link `json:"tenants_links"`
+		}
+		if err := c.list(ctx, "/tenants", limit, marker, &result); err != nil {
+			return nil, err
+		}
+		return &pagination.Page[Tenant]{Items: result.Tenants, Next: pagination.NextMarker(result.Links)}, nil
+	})
+}
+
+// ListTenants returns all tenants, following the pages of the listing.
+func (c *Client) ListTenants() ([]Tenant, error) {
+	return c.TenantPager(0).All(context.Background())
+}
+
+// UserPager returns a pager over the users, fetching at most limit users per
+// request. A limit of 0 lets keystone decide the size of the pages.
+func (c *Client) UserPager(limit int) *pagination.Pager[User] {
+	return pagination.New(func(ctx context.Context, marker string) (*pagination.Page[User], error) {
+		var result struct {
+			Users []User
+			Links []pagination.Link `json:"users_links"`
+		}
+		if err := c.list(ctx, "/users", limit, marker, &result); err != nil {
+			return nil, err
+		}
+		return &pagination.Page[User]{Items: result.Users, Next: pagination.NextMarker(result.Links)}, nil
+	})
+}
+
+// ListUsers returns all users, following the pages of the listing.
+func (c *Client) ListUsers() ([]User, error) {
+	return c.UserPager(0).All(context.Background())
+}
@@ -0,0 +1,60 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keystone
+
+import (
+	"context"
+	. "launchpad.net/gocheck"
+)
+
+func (s *S) TestListTenants(c *C) {
+	testServer.PrepareResponse(200, nil, s.response)
+	client, err := NewClient("username", "pass", "admin", "http://localhost:4444/v2.0")
+	c.Assert(err, IsNil)
+	testServer.PrepareResponse(200, nil, `{"tenants": [{"id": "t1", "name": "admin", "description": "Admin tenant", "enabled": true}], "tenants_links": [{"href": "http://localhost:4444/tenants?marker=t1", "rel": "next"}]}`)
+	testServer.PrepareResponse(200, nil, `{"tenants": [{"id": "t2", "name": "demo", "description": "", "enabled": true}], "tenants_links": []}`)
+	tenants, err := client.ListTenants()
+	c.Assert(err, IsNil)
+	c.Assert(tenants, DeepEquals, []Tenant{
+		{Id: "t1", Name: "admin", Description: "Admin tenant"},
+		{Id: "t2", Name: "demo"},
+	})
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.0/tenants")
+	c.Assert(req.URL.RawQuery, Equals, "")
+	c.Assert(req.Header.Get("X-Auth-Token"), Equals, client.Token)
+	req, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.RawQuery, Equals, "marker=t1")
+}
+
+func (s *S) TestListTenantsFailure(c *C) {
+	testServer.PrepareResponse(200, nil, s.response)
+	client, err := NewClient("username", "pass", "admin", "http://localhost:4444/v2.0")
+	c.Assert(err, IsNil)
+	testServer.PrepareResponse(403, nil, "Forbidden")
+	_, err = client.ListTenants()
+	c.Assert(err, ErrorMatches, "^Error while performing request: 403 - Forbidden$")
+}
+
+func (s *S) TestUserPager(c *C) {
+	testServer.PrepareResponse(200, nil, s.response)
+	client, err := NewClient("username", "pass", "admin", "http://localhost:4444/v2.0")
+	c.Assert(err, IsNil)
+	testServer.PrepareResponse(200, nil, `{"users": [{"id": "u1", "name": "Stark", "email": "stark@stark.com"}], "users_links": [{"href": "http://localhost:4444/users?limit=1&marker=u1", "rel": "next"}]}`)
+	pager := client.UserPager(1)
+	users, err := pager.NextPage(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(users, DeepEquals, []User{{Id: "u1", Name: "Stark", Email: "stark@stark.com"}})
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.0/users")
+	c.Assert(req.URL.RawQuery, Equals, "limit=1")
+}
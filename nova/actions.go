@@ -0,0 +1,272 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+)
+
+// StartServer starts a stopped server.
+func (c *Client) StartServer(id string) error {
+	return c.action("start the server "+id, id, map[string]interface{}{"os-start": nil}, nil)
+}
+
+// StopServer stops a running server.
+func (c *Client) StopServer(id string) error {
+	return c.action("stop the server "+id, id, map[string]interface{}{"os-stop": nil}, nil)
+}
+
+// PauseServer pauses a server, keeping its state in memory.
+func (c *Client) PauseServer(id string) error {
+	return c.action("pause the server "+id, id, map[string]interface{}{"pause": nil}, nil)
+}
+
+// UnpauseServer unpauses a paused server.
+func (c *Client) UnpauseServer(id string) error {
+	return c.action("unpause the server "+id, id, map[string]interface{}{"unpause": nil}, nil)
+}
+
+// SuspendServer suspends a server, saving its state to disk.
+func (c *Client) SuspendServer(id string) error {
+	return c.action("suspend the server "+id, id, map[string]interface{}{"suspend": nil}, nil)
+}
+
+// ResumeServer resumes a suspended server.
+func (c *Client) ResumeServer(id string) error {
+	return c.action("resume the server "+id, id, map[string]interface{}{"resume": nil}, nil)
+}
+
+// LockServer locks a server, so only administrators can perform actions on
+// it.
+func (c *Client) LockServer(id string) error {
+	return c.action("lock the server "+id, id, map[string]interface{}{"lock": nil}, nil)
+}
+
+// UnlockServer unlocks a locked server.
+func (c *Client) UnlockServer(id string) error {
+	return c.action("unlock the server "+id, id, map[string]interface{}{"unlock": nil}, nil)
+}
+
+// RescueOpts contains the options for rescuing a server. All fields are
+// optional.
+type RescueOpts struct {
+	// AdminPass is the password of the rescue server. When empty, nova
+	// generates one.
+	AdminPass string
+
+	// RescueImageRef is the image used to boot the rescue server. When
+	// empty, nova uses the image of the server.
+	RescueImageRef string
+}
+
+// RescueServer puts a server in rescue mode, booting it from a rescue image
+// with the original disk attached. It returns the administrative password of
+// the rescue server.
+func (c *Client) RescueServer(id string, opts RescueOpts) (string, error) {
+	rescue := map[string]string{}
+	if opts.AdminPass != "" {
+		rescue["adminPass"] = opts.AdminPass
+	}
+	if opts.RescueImageRef != "" {
+		rescue["rescue_image_ref"] = opts.RescueImageRef
+	}
+	var result struct {
+		AdminPass string `json:"adminPass"`
+	}
+	err := c.action("rescue the server "+id, id, map[string]interface{}{"rescue": rescue}, &result)
+	if err != nil {
+		return "", err
+	}
+	return result.AdminPass, nil
+}
+
+// UnrescueServer brings a server back from rescue mode.
+func (c *Client) UnrescueServer(id string) error {
+	return c.action("unrescue the server "+id, id, map[string]interface{}{"unrescue": nil}, nil)
+}
+
+// ShelveServer shelves a server, shutting it down and creating a snapshot of
+// its disk.
+func (c *Client) ShelveServer(id string) error {
+	return c.action("shelve the server "+id, id, map[string]interface{}{"shelve": nil}, nil)
+}
+
+// ShelveOffloadServer removes a shelved server from its host, releasing its
+// resources.
+func (c *Client) ShelveOffloadServer(id string) error {
+	return c.action("shelve-offload the server "+id, id, map[string]interface{}{"shelveOffload": nil}, nil)
+}
+
+// UnshelveServer brings a shelved server back.
+func (c *Client) UnshelveServer(id string) error {
+	return c.action("unshelve the server "+id, id, map[string]interface{}{"unshelve": nil}, nil)
+}
+
+// CreateImage creates a snapshot of the server, using the given name and
+// metadata for the image. It returns the id of the new image.
+//
+// Nova creates the image asynchronously, so it may not be ready for use
+// immediately.
+func (c *Client) CreateImage(id, name string, metadata map[string]string) (string, error) {
+	createImage := map[string]interface{}{"name": name}
+	if len(metadata) > 0 {
+		createImage["metadata"] = metadata
+	}
+	var result struct {
+		ImageId string `json:"image_id"`
+	}
+	op := "create an image of the server " + id
+	body := map[string]interface{}{"createImage": createImage}
+	header, err := c.requestHeader(op, "POST", "/servers/"+id+"/action", body, &result, http.StatusAccepted)
+	if err != nil {
+		return "", err
+	}
+	if result.ImageId != "" {
+		return result.ImageId, nil
+	}
+	// Before the microversion 2.45, the id is only available in the location
+	// of the image.
+	if location := header.Get("Location"); location != "" {
+		return path.Base(location), nil
+	}
+	return "", errors.New("Failed to " + op + ", the server did not return the id of the image.")
+}
+
+// MigrateServer cold migrates a server to another host. The host parameter
+// is optional (and requires the microversion 2.56): when empty, the scheduler
+// chooses the host.
+func (c *Client) MigrateServer(id, host string) error {
+	var migrate interface{}
+	if host != "" {
+		migrate = map[string]string{"host": host}
+	}
+	return c.action("migrate the server "+id, id, map[string]interface{}{"migrate": migrate}, nil)
+}
+
+// LiveMigrateOpts contains the options for live migrating a server. All
+// fields are optional.
+type LiveMigrateOpts struct {
+	// Host is the destination host. When empty, the scheduler chooses the
+	// host.
+	Host string
+
+	// BlockMigration makes nova migrate the disks of the server along with
+	// its memory, for servers that do not use shared storage.
+	BlockMigration bool
+
+	// AutoBlockMigration lets nova decide whether to use block migration,
+	// and overrides BlockMigration. It requires the microversion 2.25.
+	AutoBlockMigration bool
+
+	// DiskOverCommit allows disk over commit in the destination host. It is
+	// ignored starting with the microversion 2.25.
+	DiskOverCommit bool
+}
+
+// LiveMigrateServer migrates a running server to another host, without
+// stopping it.
+func (c *Client) LiveMigrateServer(id string, opts LiveMigrateOpts) error {
+	migrate := map[string]interface{}{"host": nil, "block_migration": opts.BlockMigration}
+	if opts.Host != "" {
+		migrate["host"] = opts.Host
+	}
+	if opts.AutoBlockMigration {
+		migrate["block_migration"] = "auto"
+	}
+	ok, err := c.SupportsMicroversion("2.25")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		migrate["disk_over_commit"] = opts.DiskOverCommit
+	}
+	return c.action("live migrate the server "+id, id, map[string]interface{}{"os-migrateLive": migrate}, nil)
+}
+
+// EvacuateOpts contains the options for evacuating a server. All fields are
+// optional.
+type EvacuateOpts struct {
+	// Host is the destination host. When empty, the scheduler chooses the
+	// host.
+	Host string
+
+	// AdminPass is the administrative password of the rebuilt server. When
+	// empty, nova generates a password.
+	AdminPass string
+
+	// OnSharedStorage tells nova that the disks of the server are in shared
+	// storage, and must be kept. It is ignored starting with the microversion
+	// 2.14, when nova detects shared storage by itself.
+	OnSharedStorage bool
+}
+
+// EvacuateServer rebuilds a server of a failed host in another host. The
+// compute service of the failed host must be down (see ForceDownService).
+//
+// Before the microversion 2.14, EvacuateServer returns the administrative
+// password of the rebuilt server. Starting with 2.14, the password is empty.
+func (c *Client) EvacuateServer(id string, opts EvacuateOpts) (string, error) {
+	evacuate := map[string]interface{}{}
+	if opts.Host != "" {
+		evacuate["host"] = opts.Host
+	}
+	if opts.AdminPass != "" {
+		evacuate["adminPass"] = opts.AdminPass
+	}
+	ok, err := c.SupportsMicroversion("2.14")
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		evacuate["onSharedStorage"] = opts.OnSharedStorage
+	}
+	var result struct {
+		AdminPass string `json:"adminPass"`
+	}
+	err = c.action("evacuate the server "+id, id, map[string]interface{}{"evacuate": evacuate}, &result)
+	if err != nil {
+		return "", err
+	}
+	return result.AdminPass, nil
+}
+
+// Evacuation is the result of evacuating a server in EvacuateHost. Err is
+// not nil when nova refused to evacuate the server.
+type Evacuation struct {
+	ServerId  string
+	AdminPass string
+	Err       error
+}
+
+// EvacuateHost evacuates all servers of a failed host, from all tenants,
+// using the given options for each server. A failure to evacuate a server
+// does not stop the evacuation of the others; it is reported in the Err field
+// of the server's Evacuation. All pages of servers of the host are listed
+// before the first server is evacuated.
+//
+// Example of use:
+//
+//     evacuations, err := client.EvacuateHost("compute1", nova.EvacuateOpts{})
+//     // handle err
+//     for _, e := range evacuations {
+//         if e.Err != nil {
+//             fmt.Printf("failed to evacuate %s: %s\n", e.ServerId, e.Err)
+//         }
+//     }
+func (c *Client) EvacuateHost(host string, opts EvacuateOpts) ([]Evacuation, error) {
+	servers, err := c.ServerPager(ListServersOpts{Host: host, AllTenants: true}).All(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	evacuations := make([]Evacuation, len(servers))
+	for i, server := range servers {
+		evacuations[i].ServerId = server.Id
+		evacuations[i].AdminPass, evacuations[i].Err = c.EvacuateServer(server.Id, opts)
+	}
+	return evacuations, nil
+}
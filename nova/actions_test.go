@@ -0,0 +1,193 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	. "launchpad.net/gocheck"
+)
+
+func (s *S) TestSimpleActions(c *C) {
+	client := newTestClient()
+	var tests = []struct {
+		action func(string) error
+		body   string
+	}{
+		{client.StartServer, `{"os-start":null}`},
+		{client.StopServer, `{"os-stop":null}`},
+		{client.PauseServer, `{"pause":null}`},
+		{client.UnpauseServer, `{"unpause":null}`},
+		{client.SuspendServer, `{"suspend":null}`},
+		{client.ResumeServer, `{"resume":null}`},
+		{client.LockServer, `{"lock":null}`},
+		{client.UnlockServer, `{"unlock":null}`},
+		{client.UnrescueServer, `{"unrescue":null}`},
+		{client.ShelveServer, `{"shelve":null}`},
+		{client.ShelveOffloadServer, `{"shelveOffload":null}`},
+		{client.UnshelveServer, `{"unshelve":null}`},
+	}
+	for _, t := range tests {
+		testServer.PrepareResponse(202, nil, "")
+		err := t.action("f5dc173b")
+		c.Assert(err, IsNil)
+		req, b, err := testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+		c.Assert(req.Method, Equals, "POST")
+		c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/action")
+		c.Assert(string(b), Equals, t.body)
+	}
+}
+
+func (s *S) TestActionFailure(c *C) {
+	testServer.PrepareResponse(409, nil, "Cannot 'os-start' instance while it is in vm_state active.")
+	client := newTestClient()
+	err := client.StartServer("f5dc173b")
+	c.Assert(err, ErrorMatches, "^Failed to start the server f5dc173b, status: 409.\nBody: .*")
+}
+
+func (s *S) TestRescueServer(c *C) {
+	testServer.PrepareResponse(200, nil, `{"adminPass": "MySecretPass"}`)
+	client := newTestClient()
+	pass, err := client.RescueServer("f5dc173b", RescueOpts{RescueImageRef: "70a599e0"})
+	c.Assert(err, IsNil)
+	c.Assert(pass, Equals, "MySecretPass")
+	_, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `{"rescue":{"rescue_image_ref":"70a599e0"}}`)
+}
+
+func (s *S) TestCreateImage(c *C) {
+	testServer.PrepareResponse(202, nil, `{"image_id": "0e7761dd-ee98-41f0-ba35-05994e446431"}`)
+	client := newTestClient()
+	imageId, err := client.CreateImage("f5dc173b", "snapshot", map[string]string{"owner": "tsuru"})
+	c.Assert(err, IsNil)
+	c.Assert(imageId, Equals, "0e7761dd-ee98-41f0-ba35-05994e446431")
+	_, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `{"createImage":{"metadata":{"owner":"tsuru"},"name":"snapshot"}}`)
+}
+
+func (s *S) TestCreateImageWithLocation(c *C) {
+	headers := map[string]string{"Location": "http://glance.mycloud.com:9292/images/0e7761dd-ee98-41f0-ba35-05994e446431"}
+	testServer.PrepareResponse(202, headers, "")
+	client := newTestClient()
+	imageId, err := client.CreateImage("f5dc173b", "snapshot", nil)
+	c.Assert(err, IsNil)
+	c.Assert(imageId, Equals, "0e7761dd-ee98-41f0-ba35-05994e446431")
+}
+
+func (s *S) TestCreateImageWithoutId(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	_, err := client.CreateImage("f5dc173b", "snapshot", nil)
+	c.Assert(err, ErrorMatches, "^Failed to create an image of the server f5dc173b, the server did not return the id of the image.$")
+}
+
+func (s *S) TestMigrateServer(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.MigrateServer("f5dc173b", "")
+	c.Assert(err, IsNil)
+	err = client.MigrateServer("f5dc173b", "compute2")
+	c.Assert(err, IsNil)
+	_, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `{"migrate":null}`)
+	_, b, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `{"migrate":{"host":"compute2"}}`)
+}
+
+func (s *S) TestLiveMigrateServer(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.LiveMigrateServer("f5dc173b", LiveMigrateOpts{BlockMigration: true})
+	c.Assert(err, IsNil)
+	_, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `{"os-migrateLive":{"block_migration":true,"disk_over_commit":false,"host":null}}`)
+}
+
+func (s *S) TestLiveMigrateServerWithMicroversion(c *C) {
+	testServer.PrepareResponse(200, nil, versionsBody)
+	testServer.PrepareResponse(202, nil, "")
+	client := newUndiscoveredTestClient()
+	client.Microversion = LatestMicroversion
+	err := client.LiveMigrateServer("f5dc173b", LiveMigrateOpts{Host: "compute2", AutoBlockMigration: true, DiskOverCommit: true})
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/")
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("X-OpenStack-Nova-API-Version"), Equals, "2.53")
+	c.Assert(string(b), Equals, `{"os-migrateLive":{"block_migration":"auto","host":"compute2"}}`)
+}
+
+func (s *S) TestEvacuateServer(c *C) {
+	testServer.PrepareResponse(200, nil, `{"adminPass": "MySecretPass"}`)
+	client := newTestClient()
+	pass, err := client.EvacuateServer("f5dc173b", EvacuateOpts{Host: "compute2", OnSharedStorage: true})
+	c.Assert(err, IsNil)
+	c.Assert(pass, Equals, "MySecretPass")
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/action")
+	c.Assert(string(b), Equals, `{"evacuate":{"host":"compute2","onSharedStorage":true}}`)
+}
+
+func (s *S) TestEvacuateServerWithMicroversion(c *C) {
+	testServer.PrepareResponse(200, nil, versionsBody)
+	testServer.PrepareResponse(200, nil, "")
+	client := newUndiscoveredTestClient()
+	client.Microversion = "2.14"
+	pass, err := client.EvacuateServer("f5dc173b", EvacuateOpts{OnSharedStorage: true})
+	c.Assert(err, IsNil)
+	c.Assert(pass, Equals, "")
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/")
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("X-OpenStack-Nova-API-Version"), Equals, "2.14")
+	c.Assert(string(b), Equals, `{"evacuate":{}}`)
+}
+
+func (s *S) TestEvacuateHost(c *C) {
+	testServer.PrepareResponse(200, nil, `{"servers": [{"id": "f5dc173b", "status": "ACTIVE"}, {"id": "a26887c6", "status": "ACTIVE"}]}`)
+	testServer.PrepareResponse(200, nil, `{"adminPass": "pass1"}`)
+	testServer.PrepareResponse(409, nil, "Cannot evacuate a server in task_state deleting")
+	client := newTestClient()
+	evacuations, err := client.EvacuateHost("compute1", EvacuateOpts{})
+	c.Assert(err, IsNil)
+	c.Assert(evacuations, HasLen, 2)
+	c.Assert(evacuations[0], DeepEquals, Evacuation{ServerId: "f5dc173b", AdminPass: "pass1"})
+	c.Assert(evacuations[1].ServerId, Equals, "a26887c6")
+	c.Assert(evacuations[1].Err, ErrorMatches, "^Failed to evacuate the server a26887c6, status: 409.\nBody: Cannot evacuate a server in task_state deleting.$")
+	req, _, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/detail")
+	c.Assert(req.URL.Query().Get("host"), Equals, "compute1")
+	c.Assert(req.URL.Query().Get("all_tenants"), Equals, "1")
+}
+
+func (s *S) TestEvacuateHostWithManyPages(c *C) {
+	testServer.PrepareResponse(200, nil, `{"servers": [{"id": "f5dc173b", "status": "ACTIVE"}], "servers_links": [{"rel": "next", "href": "http://localhost:5555/v2.1/123tenant/servers/detail?all_tenants=1&host=compute1&marker=f5dc173b"}]}`)
+	testServer.PrepareResponse(200, nil, `{"servers": [{"id": "a26887c6", "status": "ACTIVE"}]}`)
+	testServer.PrepareResponse(200, nil, `{"adminPass": "pass1"}`)
+	testServer.PrepareResponse(200, nil, `{"adminPass": "pass2"}`)
+	client := newTestClient()
+	evacuations, err := client.EvacuateHost("compute1", EvacuateOpts{})
+	c.Assert(err, IsNil)
+	c.Assert(evacuations, DeepEquals, []Evacuation{{ServerId: "f5dc173b", AdminPass: "pass1"}, {ServerId: "a26887c6", AdminPass: "pass2"}})
+	testServer.WaitRequest(1e9)
+	req, _, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/detail")
+	c.Assert(req.URL.Query().Get("host"), Equals, "compute1")
+	c.Assert(req.URL.Query().Get("marker"), Equals, "f5dc173b")
+	req, _, _ = testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/action")
+	req, _, _ = testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/a26887c6/action")
+}
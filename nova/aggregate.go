@@ -0,0 +1,230 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"encoding/json"
+	"github.com/globocom/go-openstack/internal/timeutil"
+	"net/http"
+	"time"
+)
+
+// Aggregate represents a host aggregate: a group of hosts with metadata that
+// the scheduler uses to place servers. An aggregate may also be exposed to
+// users as an availability zone.
+type Aggregate struct {
+	Id               string
+	UUID             string
+	Name             string
+	AvailabilityZone string
+	Hosts            []string
+	Metadata         map[string]string
+	Created          time.Time
+	Updated          time.Time
+}
+
+// UnmarshalJSON decodes an aggregate, accepting both numeric and string ids
+// and parsing the timestamps in nova's format.
+func (a *Aggregate) UnmarshalJSON(b []byte) error {
+	var aggregate struct {
+		Id               interface{}
+		UUID             string
+		Name             string
+		AvailabilityZone string `json:"availability_zone"`
+		Hosts            []string
+		Metadata         map[string]string
+		CreatedAt        string `json:"created_at"`
+		UpdatedAt        string `json:"updated_at"`
+	}
+	if err := json.Unmarshal(b, &aggregate); err != nil {
+		return err
+	}
+	*a = Aggregate{
+		Id:               idString(aggregate.Id),
+		UUID:             aggregate.UUID,
+		Name:             aggregate.Name,
+		AvailabilityZone: aggregate.AvailabilityZone,
+		Hosts:            aggregate.Hosts,
+		Metadata:         aggregate.Metadata,
+		Created:          timeutil.Parse(aggregate.CreatedAt),
+		Updated:          timeutil.Parse(aggregate.UpdatedAt),
+	}
+	return nil
+}
+
+// ListAggregates returns all host aggregates.
+func (c *Client) ListAggregates() ([]Aggregate, error) {
+	var result struct{ Aggregates []Aggregate }
+	err := c.request("get the list of aggregates", "GET", "/os-aggregates", nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return result.Aggregates, nil
+}
+
+// GetAggregate returns the host aggregate with the given id.
+func (c *Client) GetAggregate(id string) (*Aggregate, error) {
+	var result struct{ Aggregate Aggregate }
+	err := c.request("get the aggregate "+id, "GET", "/os-aggregates/"+id, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Aggregate, nil
+}
+
+// CreateAggregate creates a new host aggregate, without hosts. When
+// availabilityZone is not empty, the aggregate is exposed to users as an
+// availability zone with that name.
+func (c *Client) CreateAggregate(name, availabilityZone string) (*Aggregate, error) {
+	aggregate := map[string]string{"name": name}
+	if availabilityZone != "" {
+		aggregate["availability_zone"] = availabilityZone
+	}
+	var result struct{ Aggregate Aggregate }
+	body := map[string]interface{}{"aggregate": aggregate}
+	err := c.request("create the aggregate "+name, "POST", "/os-aggregates", body, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Aggregate, nil
+}
+
+// DeleteAggregate deletes a host aggregate. The aggregate must not have any
+// hosts.
+func (c *Client) DeleteAggregate(id string) error {
+	return c.request("delete the aggregate "+id, "DELETE", "/os-aggregates/"+id, nil, nil, http.StatusOK)
+}
+
+func (c *Client) aggregateAction(op, id string, body interface{}) (*Aggregate, error) {
+	var result struct{ Aggregate Aggregate }
+	err := c.request(op, "POST", "/os-aggregates/"+id+"/action", body, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Aggregate, nil
+}
+
+// AddAggregateHost adds a host to a host aggregate, returning the updated
+// aggregate.
+func (c *Client) AddAggregateHost(id, host string) (*Aggregate, error) {
+	body := map[string]interface{}{"add_host": map[string]string{"host": host}}
+	return c.aggregateAction("add the host "+host+" to the aggregate "+id, id, body)
+}
+
+// RemoveAggregateHost removes a host from a host aggregate, returning the
+// updated aggregate.
+func (c *Client) RemoveAggregateHost(id, host string) (*Aggregate, error) {
+	body := map[string]interface{}{"remove_host": map[string]string{"host": host}}
+	return c.aggregateAction("remove the host "+host+" from the aggregate "+id, id, body)
+}
+
+// SetAggregateMetadata sets the given metadata keys in a host aggregate,
+// returning the updated aggregate. Keys that are not in the given map are not
+// changed.
+func (c *Client) SetAggregateMetadata(id string, metadata map[string]string) (*Aggregate, error) {
+	body := map[string]interface{}{"set_metadata": map[string]interface{}{"metadata": metadata}}
+	return c.aggregateAction("set the metadata of the aggregate "+id, id, body)
+}
+
+// DeleteAggregateMetadata removes the given metadata keys from a host
+// aggregate, returning the updated aggregate.
+func (c *Client) DeleteAggregateMetadata(id string, keys ...string) (*Aggregate, error) {
+	metadata := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		metadata[key] = nil
+	}
+	body := map[string]interface{}{"set_metadata": map[string]interface{}{"metadata": metadata}}
+	return c.aggregateAction("delete the metadata of the aggregate "+id, id, body)
+}
+
+// AvailabilityZone represents an availability zone. Hosts is only filled by
+// ListAvailabilityZonesDetail, and maps the name of each host in the zone to
+// the state of its services, by service name.
+type AvailabilityZone struct {
+	Name      string
+	Available bool
+	Hosts     map[string]map[string]ZoneService
+}
+
+// UnmarshalJSON decodes an availability zone from nova's
+// availabilityZoneInfo format.
+func (z *AvailabilityZone) UnmarshalJSON(b []byte) error {
+	var zone struct {
+		ZoneName  string
+		ZoneState struct{ Available bool }
+		Hosts     map[string]map[string]ZoneService
+	}
+	if err := json.Unmarshal(b, &zone); err != nil {
+		return err
+	}
+	*z = AvailabilityZone{
+		Name:      zone.ZoneName,
+		Available: zone.ZoneState.Available,
+		Hosts:     zone.Hosts,
+	}
+	return nil
+}
+
+// ZoneService represents the state of a service (e.g. nova-compute) in a host
+// of an availability zone. Active is false when the service is disabled, and
+// Available is false when the service is down.
+type ZoneService struct {
+	Active    bool
+	Available bool
+	Updated   time.Time
+}
+
+// UnmarshalJSON decodes the state of a service, parsing the timestamp in
+// nova's format.
+func (s *ZoneService) UnmarshalJSON(b []byte) error {
+	var service struct {
+		Active    bool
+		Available bool
+		UpdatedAt string `json:"updated_at"`
+	}
+	if err := json.Unmarshal(b, &service); err != nil {
+		return err
+	}
+	*s = ZoneService{
+		Active:    service.Active,
+		Available: service.Available,
+		Updated:   timeutil.Parse(service.UpdatedAt),
+	}
+	return nil
+}
+
+func (c *Client) listAvailabilityZones(op, path string) ([]AvailabilityZone, error) {
+	var result struct {
+		Zones []AvailabilityZone `json:"availabilityZoneInfo"`
+	}
+	err := c.request(op, "GET", path, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return result.Zones, nil
+}
+
+// ListAvailabilityZones returns the availability zones available to users.
+func (c *Client) ListAvailabilityZones() ([]AvailabilityZone, error) {
+	return c.listAvailabilityZones("get the list of availability zones", "/os-availability-zone")
+}
+
+// ListAvailabilityZonesDetail returns all availability zones, including the
+// internal zone, along with their hosts and the state of their services.
+//
+// Example of use (finding compute services that are down):
+//
+//     zones, err := client.ListAvailabilityZonesDetail()
+//     // handle err
+//     for _, zone := range zones {
+//         for host, services := range zone.Hosts {
+//             if s, ok := services["nova-compute"]; ok && !s.Available {
+//                 fmt.Printf("nova-compute is down in %s (%s)\n", host, zone.Name)
+//             }
+//         }
+//     }
+func (c *Client) ListAvailabilityZonesDetail() ([]AvailabilityZone, error) {
+	return c.listAvailabilityZones("get the details of availability zones", "/os-availability-zone/detail")
+}
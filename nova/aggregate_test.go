@@ -0,0 +1,128 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	. "launchpad.net/gocheck"
+	"time"
+)
+
+const aggregateBody = `{"aggregate": {"availability_zone": "rack1", "created_at": "2012-10-08T20:10:44.587336", "deleted": false, "deleted_at": null, "hosts": ["compute1"], "id": 1, "metadata": {"availability_zone": "rack1", "ssd": "true"}, "name": "fast", "updated_at": null, "uuid": "6ba28ba7-f29b-45cc-a30b-6e3a40c2fb14"}}`
+
+func (s *S) TestGetAggregate(c *C) {
+	testServer.PrepareResponse(200, nil, aggregateBody)
+	client := newTestClient()
+	aggregate, err := client.GetAggregate("1")
+	c.Assert(err, IsNil)
+	expected := &Aggregate{
+		Id:               "1",
+		UUID:             "6ba28ba7-f29b-45cc-a30b-6e3a40c2fb14",
+		Name:             "fast",
+		AvailabilityZone: "rack1",
+		Hosts:            []string{"compute1"},
+		Metadata:         map[string]string{"availability_zone": "rack1", "ssd": "true"},
+		Created:          time.Date(2012, 10, 8, 20, 10, 44, 587336000, time.UTC),
+	}
+	c.Assert(aggregate, DeepEquals, expected)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-aggregates/1")
+}
+
+func (s *S) TestListAggregates(c *C) {
+	testServer.PrepareResponse(200, nil, `{"aggregates": [{"id": 1, "name": "fast", "hosts": [], "metadata": {}}, {"id": 2, "name": "slow", "availability_zone": null, "hosts": ["compute2", "compute3"], "metadata": {}}]}`)
+	client := newTestClient()
+	aggregates, err := client.ListAggregates()
+	c.Assert(err, IsNil)
+	c.Assert(aggregates, HasLen, 2)
+	c.Assert(aggregates[1].Id, Equals, "2")
+	c.Assert(aggregates[1].Hosts, DeepEquals, []string{"compute2", "compute3"})
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-aggregates")
+}
+
+func (s *S) TestCreateAggregate(c *C) {
+	testServer.PrepareResponse(200, nil, aggregateBody)
+	testServer.PrepareResponse(200, nil, `{"aggregate": {"id": 2, "name": "slow"}}`)
+	client := newTestClient()
+	aggregate, err := client.CreateAggregate("fast", "rack1")
+	c.Assert(err, IsNil)
+	c.Assert(aggregate.Id, Equals, "1")
+	_, err = client.CreateAggregate("slow", "")
+	c.Assert(err, IsNil)
+	req, b, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.Method, Equals, "POST")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-aggregates")
+	c.Assert(string(b), Equals, `{"aggregate":{"availability_zone":"rack1","name":"fast"}}`)
+	_, b, _ = testServer.WaitRequest(1e9)
+	c.Assert(string(b), Equals, `{"aggregate":{"name":"slow"}}`)
+}
+
+func (s *S) TestCreateAggregateFailure(c *C) {
+	testServer.PrepareResponse(409, nil, "Aggregate fast already exists")
+	client := newTestClient()
+	_, err := client.CreateAggregate("fast", "")
+	c.Assert(err, ErrorMatches, "^Failed to create the aggregate fast, status: 409.\nBody: Aggregate fast already exists.$")
+}
+
+func (s *S) TestDeleteAggregate(c *C) {
+	testServer.PrepareResponse(200, nil, "")
+	client := newTestClient()
+	err := client.DeleteAggregate("1")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-aggregates/1")
+}
+
+func (s *S) TestAggregateActions(c *C) {
+	client := newTestClient()
+	var tests = []struct {
+		action func() (*Aggregate, error)
+		body   string
+	}{
+		{func() (*Aggregate, error) { return client.AddAggregateHost("1", "compute1") }, `{"add_host":{"host":"compute1"}}`},
+		{func() (*Aggregate, error) { return client.RemoveAggregateHost("1", "compute1") }, `{"remove_host":{"host":"compute1"}}`},
+		{func() (*Aggregate, error) { return client.SetAggregateMetadata("1", map[string]string{"ssd": "true"}) }, `{"set_metadata":{"metadata":{"ssd":"true"}}}`},
+		{func() (*Aggregate, error) { return client.DeleteAggregateMetadata("1", "ssd") }, `{"set_metadata":{"metadata":{"ssd":null}}}`},
+	}
+	for _, t := range tests {
+		testServer.PrepareResponse(200, nil, aggregateBody)
+		aggregate, err := t.action()
+		c.Assert(err, IsNil)
+		c.Assert(aggregate.Name, Equals, "fast")
+		req, b, err := testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+		c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-aggregates/1/action")
+		c.Assert(string(b), Equals, t.body)
+	}
+}
+
+func (s *S) TestListAvailabilityZones(c *C) {
+	testServer.PrepareResponse(200, nil, `{"availabilityZoneInfo": [{"zoneName": "nova", "zoneState": {"available": true}, "hosts": null}]}`)
+	client := newTestClient()
+	zones, err := client.ListAvailabilityZones()
+	c.Assert(err, IsNil)
+	c.Assert(zones, DeepEquals, []AvailabilityZone{{Name: "nova", Available: true}})
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-availability-zone")
+}
+
+func (s *S) TestListAvailabilityZonesDetail(c *C) {
+	testServer.PrepareResponse(200, nil, `{"availabilityZoneInfo": [{"zoneName": "internal", "zoneState": {"available": true}, "hosts": {"controller": {"nova-conductor": {"active": true, "available": true, "updated_at": "2012-10-08T20:10:44.000000"}}}}, {"zoneName": "nova", "zoneState": {"available": false}, "hosts": {"compute1": {"nova-compute": {"active": false, "available": false, "updated_at": null}}}}]}`)
+	client := newTestClient()
+	zones, err := client.ListAvailabilityZonesDetail()
+	c.Assert(err, IsNil)
+	c.Assert(zones, HasLen, 2)
+	c.Assert(zones[0].Hosts["controller"]["nova-conductor"], DeepEquals, ZoneService{Active: true, Available: true, Updated: time.Date(2012, 10, 8, 20, 10, 44, 0, time.UTC)})
+	c.Assert(zones[1].Available, Equals, false)
+	c.Assert(zones[1].Hosts["compute1"]["nova-compute"], DeepEquals, ZoneService{})
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-availability-zone/detail")
+}
@@ -0,0 +1,102 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ConsoleType is the type of a remote console.
+type ConsoleType string
+
+const (
+	// NoVNCConsole is a VNC console accessed through the noVNC web client.
+	NoVNCConsole ConsoleType = "novnc"
+	// SpiceHTML5Console is a SPICE console accessed through the HTML5 web
+	// client.
+	SpiceHTML5Console ConsoleType = "spice-html5"
+	// SerialConsole is a serial console accessed through a websocket.
+	SerialConsole ConsoleType = "serial"
+	// RDPHTML5Console is a RDP console (for Hyper-V servers) accessed
+	// through a HTML5 web client.
+	RDPHTML5Console ConsoleType = "rdp-html5"
+)
+
+// consoleProtocols maps each console type to its protocol and to the legacy
+// server action that returns it.
+var consoleProtocols = map[ConsoleType]struct{ protocol, action string }{
+	NoVNCConsole:      {"vnc", "os-getVNCConsole"},
+	SpiceHTML5Console: {"spice", "os-getSPICEConsole"},
+	SerialConsole:     {"serial", "os-getSerialConsole"},
+	RDPHTML5Console:   {"rdp", "os-getRDPConsole"},
+}
+
+// Console represents a remote console of a server. URL is the address that
+// users open (in a browser, or in a websocket client for serial consoles) to
+// access the console.
+type Console struct {
+	Type     ConsoleType
+	Protocol string
+	URL      string
+}
+
+// GetConsole returns a remote console of the given type for a server.
+//
+// With the microversion 2.6 or newer, the console is created using the
+// remote-consoles API. With older microversions, the client falls back to the
+// legacy server actions (e.g. os-getVNCConsole).
+//
+// Example of use:
+//
+//     console, err := client.GetConsole(server.Id, nova.NoVNCConsole)
+//     // handle err
+//     fmt.Printf("Open %s in your browser\n", console.URL)
+func (c *Client) GetConsole(id string, consoleType ConsoleType) (*Console, error) {
+	p, ok := consoleProtocols[consoleType]
+	if !ok {
+		return nil, fmt.Errorf("Invalid console type: %s.", consoleType)
+	}
+	op := "get the " + string(consoleType) + " console of the server " + id
+	ok, err := c.SupportsMicroversion("2.6")
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		var result struct {
+			Console Console `json:"remote_console"`
+		}
+		body := map[string]interface{}{
+			"remote_console": map[string]string{"protocol": p.protocol, "type": string(consoleType)},
+		}
+		err = c.request(op, "POST", "/servers/"+id+"/remote-consoles", body, &result, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+		return &result.Console, nil
+	}
+	var result struct{ Console Console }
+	body := map[string]interface{}{p.action: map[string]string{"type": string(consoleType)}}
+	if err := c.action(op, id, body, &result); err != nil {
+		return nil, err
+	}
+	result.Console.Protocol = p.protocol
+	return &result.Console, nil
+}
+
+// GetConsoleOutput returns the console log of a server. When lines is greater
+// than zero, only the last lines of the log are returned.
+func (c *Client) GetConsoleOutput(id string, lines int) (string, error) {
+	var length interface{}
+	if lines > 0 {
+		length = lines
+	}
+	var result struct{ Output string }
+	body := map[string]interface{}{"os-getConsoleOutput": map[string]interface{}{"length": length}}
+	if err := c.action("get the console output of the server "+id, id, body, &result); err != nil {
+		return "", err
+	}
+	return result.Output, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	. "launchpad.net/gocheck"
+)
+
+func (s *S) TestGetConsoleLegacy(c *C) {
+	client := newTestClient()
+	var tests = []struct {
+		consoleType ConsoleType
+		protocol    string
+		body        string
+	}{
+		{NoVNCConsole, "vnc", `{"os-getVNCConsole":{"type":"novnc"}}`},
+		{SpiceHTML5Console, "spice", `{"os-getSPICEConsole":{"type":"spice-html5"}}`},
+		{SerialConsole, "serial", `{"os-getSerialConsole":{"type":"serial"}}`},
+		{RDPHTML5Console, "rdp", `{"os-getRDPConsole":{"type":"rdp-html5"}}`},
+	}
+	for _, t := range tests {
+		testServer.PrepareResponse(200, nil, `{"console": {"type": "`+string(t.consoleType)+`", "url": "http://console.example.com/?token=abc"}}`)
+		console, err := client.GetConsole("f5dc173b", t.consoleType)
+		c.Assert(err, IsNil)
+		c.Assert(console, DeepEquals, &Console{Type: t.consoleType, Protocol: t.protocol, URL: "http://console.example.com/?token=abc"})
+		req, b, err := testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+		c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/action")
+		c.Assert(string(b), Equals, t.body)
+	}
+}
+
+func (s *S) TestGetConsoleRemoteConsoles(c *C) {
+	testServer.PrepareResponse(200, nil, versionsBody)
+	testServer.PrepareResponse(200, nil, `{"remote_console": {"protocol": "vnc", "type": "novnc", "url": "http://console.example.com/vnc_auto.html?token=abc"}}`)
+	client := newUndiscoveredTestClient()
+	client.Microversion = LatestMicroversion
+	console, err := client.GetConsole("f5dc173b", NoVNCConsole)
+	c.Assert(err, IsNil)
+	c.Assert(console, DeepEquals, &Console{Type: NoVNCConsole, Protocol: "vnc", URL: "http://console.example.com/vnc_auto.html?token=abc"})
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/")
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("X-OpenStack-Nova-API-Version"), Equals, "2.53")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/remote-consoles")
+	c.Assert(string(b), Equals, `{"remote_console":{"protocol":"vnc","type":"novnc"}}`)
+}
+
+func (s *S) TestGetConsoleInvalidType(c *C) {
+	client := newTestClient()
+	_, err := client.GetConsole("f5dc173b", ConsoleType("xvpvnc"))
+	c.Assert(err, ErrorMatches, "^Invalid console type: xvpvnc.$")
+}
+
+func (s *S) TestGetConsoleFailure(c *C) {
+	testServer.PrepareResponse(409, nil, "Instance not yet ready")
+	client := newTestClient()
+	_, err := client.GetConsole("f5dc173b", NoVNCConsole)
+	c.Assert(err, ErrorMatches, "^Failed to get the novnc console of the server f5dc173b, status: 409.\nBody: Instance not yet ready.$")
+}
+
+func (s *S) TestGetConsoleOutput(c *C) {
+	testServer.PrepareResponse(200, nil, `{"output": "login: \nlogin: "}`)
+	testServer.PrepareResponse(200, nil, `{"output": "login: "}`)
+	client := newTestClient()
+	output, err := client.GetConsoleOutput("f5dc173b", 0)
+	c.Assert(err, IsNil)
+	c.Assert(output, Equals, "login: \nlogin: ")
+	output, err = client.GetConsoleOutput("f5dc173b", 1)
+	c.Assert(err, IsNil)
+	c.Assert(output, Equals, "login: ")
+	_, b, _ := testServer.WaitRequest(1e9)
+	c.Assert(string(b), Equals, `{"os-getConsoleOutput":{"length":null}}`)
+	_, b, _ = testServer.WaitRequest(1e9)
+	c.Assert(string(b), Equals, `{"os-getConsoleOutput":{"length":1}}`)
+}
@@ -0,0 +1,331 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/globocom/go-openstack/keystone"
+	"github.com/globocom/go-openstack/pagination"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ErrFlavorNotFound is returned by FindFlavorByName and FindFlavor when no
+// flavor matches the search.
+var ErrFlavorNotFound = errors.New("Flavor not found: no flavor matches the search.")
+
+// Flavor represents a nova flavor, that defines the resources available to a
+// server.
+//
+// RAM and Swap are in megabytes, Disk and Ephemeral are in gigabytes.
+type Flavor struct {
+	Id          string
+	Name        string
+	RAM         int
+	VCPUs       int
+	Disk        int
+	Ephemeral   int
+	Swap        int
+	RxTxFactor  float64
+	IsPublic    bool
+	Disabled    bool
+	Description string
+	ExtraSpecs  map[string]string
+	Links       []keystone.Link
+}
+
+// UnmarshalJSON decodes a flavor. Nova uses extension prefixes in some of the
+// attributes, and returns an empty string as the swap of flavors without
+// swap.
+func (f *Flavor) UnmarshalJSON(b []byte) error {
+	var flavor struct {
+		Id          string
+		Name        string
+		RAM         int
+		VCPUs       int
+		Disk        int
+		Ephemeral   int `json:"OS-FLV-EXT-DATA:ephemeral"`
+		Swap        interface{}
+		RxTxFactor  float64 `json:"rxtx_factor"`
+		IsPublic    *bool   `json:"os-flavor-access:is_public"`
+		Disabled    bool    `json:"OS-FLV-DISABLED:disabled"`
+		Description string
+		ExtraSpecs  map[string]string `json:"extra_specs"`
+		Links       []keystone.Link
+	}
+	if err := json.Unmarshal(b, &flavor); err != nil {
+		return err
+	}
+	*f = Flavor{
+		Id:          flavor.Id,
+		Name:        flavor.Name,
+		RAM:         flavor.RAM,
+		VCPUs:       flavor.VCPUs,
+		Disk:        flavor.Disk,
+		Ephemeral:   flavor.Ephemeral,
+		RxTxFactor:  flavor.RxTxFactor,
+		IsPublic:    flavor.IsPublic == nil || *flavor.IsPublic,
+		Disabled:    flavor.Disabled,
+		Description: flavor.Description,
+		ExtraSpecs:  flavor.ExtraSpecs,
+		Links:       flavor.Links,
+	}
+	if swap, ok := flavor.Swap.(float64); ok {
+		f.Swap = int(swap)
+	}
+	return nil
+}
+
+// ListFlavorsOpts contains the filters and pagination parameters for listing
+// flavors. Empty fields are ignored.
+//
+// By default, only public flavors are listed. Administrators may set
+// AllFlavors to list private flavors too.
+type ListFlavorsOpts struct {
+	MinRAM     int
+	MinDisk    int
+	AllFlavors bool
+	Limit      int
+	Marker     string
+}
+
+func (opts *ListFlavorsOpts) query() url.Values {
+	q := url.Values{}
+	if opts.MinRAM > 0 {
+		q.Set("minRam", strconv.Itoa(opts.MinRAM))
+	}
+	if opts.MinDisk > 0 {
+		q.Set("minDisk", strconv.Itoa(opts.MinDisk))
+	}
+	if opts.AllFlavors {
+		q.Set("is_public", "None")
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Marker != "" {
+		q.Set("marker", opts.Marker)
+	}
+	return q
+}
+
+func (c *Client) listFlavors(ctx context.Context, opts ListFlavorsOpts) (*pagination.Page[Flavor], error) {
+	var result struct {
+		Flavors []Flavor
+		Links   []pagination.Link `json:"flavors_links"`
+	}
+	path := "/flavors/detail"
+	if q := opts.query().Encode(); q != "" {
+		path += "?" + q
+	}
+	err := c.requestContext(ctx, "get the list of flavors", "GET", path, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &pagination.Page[Flavor]{Items: result.Flavors, Next: pagination.NextMarker(result.Links)}, nil
+}
+
+// ListFlavors returns the detailed list of flavors that match the given
+// options, following all pages of the listing. The listing starts after
+// opts.Marker, and opts.Limit is the size of each page.
+func (c *Client) ListFlavors(opts ListFlavorsOpts) ([]Flavor, error) {
+	return c.FlavorPager(opts).All(context.Background())
+}
+
+// FlavorPager returns a pager over the flavors that match the given options,
+// starting after opts.Marker. opts.Limit is the size of each page.
+func (c *Client) FlavorPager(opts ListFlavorsOpts) *pagination.Pager[Flavor] {
+	return pagination.New(func(ctx context.Context, marker string) (*pagination.Page[Flavor], error) {
+		if marker != "" {
+			opts.Marker = marker
+		}
+		return c.listFlavors(ctx, opts)
+	})
+}
+
+// GetFlavor returns the flavor with the given id.
+func (c *Client) GetFlavor(id string) (*Flavor, error) {
+	var result struct{ Flavor Flavor }
+	err := c.request("get the flavor "+id, "GET", "/flavors/"+id, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Flavor, nil
+}
+
+// FindFlavorByName returns the flavor with the given name, or
+// ErrFlavorNotFound if there is no such flavor.
+func (c *Client) FindFlavorByName(name string) (*Flavor, error) {
+	var found *Flavor
+	err := c.FlavorPager(ListFlavorsOpts{}).Each(context.Background(), func(flavor Flavor) bool {
+		if flavor.Name == name {
+			found = &flavor
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, ErrFlavorNotFound
+	}
+	return found, nil
+}
+
+// FlavorRequirements contains the minimum resources required by a server.
+// Zero fields are ignored.
+type FlavorRequirements struct {
+	MinRAM   int
+	MinVCPUs int
+	MinDisk  int
+}
+
+func (r *FlavorRequirements) fits(f *Flavor) bool {
+	return f.RAM >= r.MinRAM && f.VCPUs >= r.MinVCPUs && f.Disk >= r.MinDisk && !f.Disabled
+}
+
+// FindFlavor returns the smallest enabled flavor that satisfies the given
+// requirements, or ErrFlavorNotFound if no flavor does.
+//
+// Flavors are compared by number of VCPUs, then by RAM, then by disk.
+func (c *Client) FindFlavor(req FlavorRequirements) (*Flavor, error) {
+	flavors, err := c.FlavorPager(ListFlavorsOpts{MinRAM: req.MinRAM, MinDisk: req.MinDisk}).All(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var best *Flavor
+	for i := range flavors {
+		f := &flavors[i]
+		if !req.fits(f) {
+			continue
+		}
+		if best == nil || f.VCPUs < best.VCPUs ||
+			(f.VCPUs == best.VCPUs && (f.RAM < best.RAM || (f.RAM == best.RAM && f.Disk < best.Disk))) {
+			best = f
+		}
+	}
+	if best == nil {
+		return nil, ErrFlavorNotFound
+	}
+	return best, nil
+}
+
+// FlavorOpts contains the attributes of a new flavor. Name, RAM, VCPUs and
+// Disk are required, all other fields are optional.
+//
+// When Id is empty, nova generates an UUID for the flavor. Private flavors are
+// only available to the tenants that have access to it (see
+// AddFlavorAccess).
+type FlavorOpts struct {
+	Id          string
+	Name        string
+	RAM         int
+	VCPUs       int
+	Disk        int
+	Ephemeral   int
+	Swap        int
+	RxTxFactor  float64
+	Private     bool
+	Description string
+}
+
+// CreateFlavor creates a new flavor. This operation is usually restricted to
+// administrators.
+func (c *Client) CreateFlavor(opts FlavorOpts) (*Flavor, error) {
+	flavor := map[string]interface{}{
+		"name":                       opts.Name,
+		"ram":                        opts.RAM,
+		"vcpus":                      opts.VCPUs,
+		"disk":                       opts.Disk,
+		"os-flavor-access:is_public": !opts.Private,
+	}
+	if opts.Id != "" {
+		flavor["id"] = opts.Id
+	}
+	if opts.Ephemeral > 0 {
+		flavor["OS-FLV-EXT-DATA:ephemeral"] = opts.Ephemeral
+	}
+	if opts.Swap > 0 {
+		flavor["swap"] = opts.Swap
+	}
+	if opts.RxTxFactor > 0 {
+		flavor["rxtx_factor"] = opts.RxTxFactor
+	}
+	if opts.Description != "" {
+		flavor["description"] = opts.Description
+	}
+	var result struct{ Flavor Flavor }
+	body := map[string]interface{}{"flavor": flavor}
+	err := c.request("create the flavor "+opts.Name, "POST", "/flavors", body, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Flavor, nil
+}
+
+// DeleteFlavor deletes a flavor.
+func (c *Client) DeleteFlavor(id string) error {
+	return c.request("delete the flavor "+id, "DELETE", "/flavors/"+id, nil, nil, http.StatusAccepted)
+}
+
+// GetFlavorExtraSpecs returns the extra specs of a flavor.
+func (c *Client) GetFlavorExtraSpecs(id string) (map[string]string, error) {
+	var result struct {
+		ExtraSpecs map[string]string `json:"extra_specs"`
+	}
+	err := c.request("get the extra specs of the flavor "+id, "GET", "/flavors/"+id+"/os-extra_specs", nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return result.ExtraSpecs, nil
+}
+
+// SetFlavorExtraSpecs creates or updates the given extra specs of a flavor.
+// Extra specs that are not in specs are not changed.
+func (c *Client) SetFlavorExtraSpecs(id string, specs map[string]string) error {
+	body := map[string]interface{}{"extra_specs": specs}
+	return c.request("set the extra specs of the flavor "+id, "POST", "/flavors/"+id+"/os-extra_specs", body, nil, http.StatusOK)
+}
+
+// DeleteFlavorExtraSpec deletes the extra spec with the given key from a
+// flavor.
+func (c *Client) DeleteFlavorExtraSpec(id, key string) error {
+	path := "/flavors/" + id + "/os-extra_specs/" + url.PathEscape(key)
+	return c.request("delete the extra spec "+key+" of the flavor "+id, "DELETE", path, nil, nil, http.StatusOK)
+}
+
+// FlavorAccess represents the access of a tenant to a private flavor.
+type FlavorAccess struct {
+	FlavorId string `json:"flavor_id"`
+	TenantId string `json:"tenant_id"`
+}
+
+// ListFlavorAccess returns the tenants that have access to a private flavor.
+func (c *Client) ListFlavorAccess(id string) ([]FlavorAccess, error) {
+	var result struct {
+		FlavorAccess []FlavorAccess `json:"flavor_access"`
+	}
+	err := c.request("get the access list of the flavor "+id, "GET", "/flavors/"+id+"/os-flavor-access", nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return result.FlavorAccess, nil
+}
+
+// AddFlavorAccess gives the tenant access to a private flavor.
+func (c *Client) AddFlavorAccess(id, tenantId string) error {
+	body := map[string]interface{}{"addTenantAccess": map[string]string{"tenant": tenantId}}
+	return c.request("add access to the flavor "+id, "POST", "/flavors/"+id+"/action", body, nil, http.StatusOK)
+}
+
+// RemoveFlavorAccess removes the access of the tenant to a private flavor.
+func (c *Client) RemoveFlavorAccess(id, tenantId string) error {
+	body := map[string]interface{}{"removeTenantAccess": map[string]string{"tenant": tenantId}}
+	return c.request("remove access to the flavor "+id, "POST", "/flavors/"+id+"/action", body, nil, http.StatusOK)
+}
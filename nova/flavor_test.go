@@ -0,0 +1,158 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"encoding/json"
+	. "launchpad.net/gocheck"
+)
+
+const flavorsBody = `{"flavors": [
+	{"id": "1", "name": "m1.tiny", "ram": 512, "vcpus": 1, "disk": 1, "OS-FLV-EXT-DATA:ephemeral": 0, "swap": "", "rxtx_factor": 1.0, "os-flavor-access:is_public": true, "OS-FLV-DISABLED:disabled": false, "links": []},
+	{"id": "2", "name": "m1.small", "ram": 2048, "vcpus": 1, "disk": 20, "OS-FLV-EXT-DATA:ephemeral": 0, "swap": 512, "rxtx_factor": 1.0, "os-flavor-access:is_public": true, "OS-FLV-DISABLED:disabled": false, "links": []},
+	{"id": "3", "name": "m1.medium", "ram": 4096, "vcpus": 2, "disk": 40, "OS-FLV-EXT-DATA:ephemeral": 0, "swap": "", "rxtx_factor": 1.0, "os-flavor-access:is_public": true, "OS-FLV-DISABLED:disabled": false, "links": []},
+	{"id": "4", "name": "m1.legacy", "ram": 4096, "vcpus": 1, "disk": 40, "OS-FLV-EXT-DATA:ephemeral": 0, "swap": "", "rxtx_factor": 1.0, "os-flavor-access:is_public": false, "OS-FLV-DISABLED:disabled": true, "links": []}
+]}`
+
+func (s *S) TestFlavorUnmarshal(c *C) {
+	var result struct{ Flavors []Flavor }
+	err := json.Unmarshal([]byte(flavorsBody), &result)
+	c.Assert(err, IsNil)
+	c.Assert(result.Flavors[0], DeepEquals, Flavor{Id: "1", Name: "m1.tiny", RAM: 512, VCPUs: 1, Disk: 1, RxTxFactor: 1.0, IsPublic: true, Links: result.Flavors[0].Links})
+	c.Assert(result.Flavors[1].Swap, Equals, 512)
+	c.Assert(result.Flavors[3].IsPublic, Equals, false)
+	c.Assert(result.Flavors[3].Disabled, Equals, true)
+}
+
+func (s *S) TestListFlavors(c *C) {
+	testServer.PrepareResponse(200, nil, flavorsBody)
+	client := newTestClient()
+	flavors, err := client.ListFlavors(ListFlavorsOpts{MinRAM: 512, AllFlavors: true})
+	c.Assert(err, IsNil)
+	c.Assert(flavors, HasLen, 4)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/flavors/detail")
+	c.Assert(req.URL.RawQuery, Equals, "is_public=None&minRam=512")
+}
+
+func (s *S) TestGetFlavor(c *C) {
+	testServer.PrepareResponse(200, nil, `{"flavor": {"id": "1", "name": "m1.tiny", "ram": 512, "vcpus": 1, "disk": 1}}`)
+	client := newTestClient()
+	flavor, err := client.GetFlavor("1")
+	c.Assert(err, IsNil)
+	c.Assert(flavor.Name, Equals, "m1.tiny")
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/flavors/1")
+}
+
+func (s *S) TestFindFlavorByName(c *C) {
+	testServer.PrepareResponse(200, nil, flavorsBody)
+	testServer.PrepareResponse(200, nil, flavorsBody)
+	client := newTestClient()
+	flavor, err := client.FindFlavorByName("m1.medium")
+	c.Assert(err, IsNil)
+	c.Assert(flavor.Id, Equals, "3")
+	_, err = client.FindFlavorByName("m1.huge")
+	c.Assert(err, Equals, ErrFlavorNotFound)
+}
+
+func (s *S) TestFindFlavorByNameFollowsPages(c *C) {
+	testServer.PrepareResponse(200, nil, `{"flavors": [{"id": "1", "name": "m1.tiny"}], "flavors_links": [{"href": "http://localhost:5555/v2.1/123tenant/flavors/detail?marker=1", "rel": "next"}]}`)
+	testServer.PrepareResponse(200, nil, `{"flavors": [{"id": "2", "name": "m1.small"}], "flavors_links": [{"href": "http://localhost:5555/v2.1/123tenant/flavors/detail?marker=2", "rel": "next"}]}`)
+	client := newTestClient()
+	flavor, err := client.FindFlavorByName("m1.small")
+	c.Assert(err, IsNil)
+	c.Assert(flavor.Id, Equals, "2")
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Query().Get("marker"), Equals, "1")
+	_, _, err = testServer.WaitRequest(1e8)
+	c.Assert(err, NotNil)
+}
+
+func (s *S) TestFindFlavor(c *C) {
+	testServer.PrepareResponse(200, nil, flavorsBody)
+	testServer.PrepareResponse(200, nil, flavorsBody)
+	client := newTestClient()
+	flavor, err := client.FindFlavor(FlavorRequirements{MinRAM: 1024})
+	c.Assert(err, IsNil)
+	c.Assert(flavor.Id, Equals, "2")
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Query().Get("minRam"), Equals, "1024")
+	_, err = client.FindFlavor(FlavorRequirements{MinVCPUs: 4})
+	c.Assert(err, Equals, ErrFlavorNotFound)
+}
+
+func (s *S) TestCreateFlavor(c *C) {
+	testServer.PrepareResponse(200, nil, `{"flavor": {"id": "10", "name": "custom", "ram": 1024, "vcpus": 2, "disk": 10, "os-flavor-access:is_public": false}}`)
+	client := newTestClient()
+	flavor, err := client.CreateFlavor(FlavorOpts{Id: "10", Name: "custom", RAM: 1024, VCPUs: 2, Disk: 10, Private: true})
+	c.Assert(err, IsNil)
+	c.Assert(flavor.IsPublic, Equals, false)
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "POST")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/flavors")
+	c.Assert(string(b), Equals, `{"flavor":{"disk":10,"id":"10","name":"custom","os-flavor-access:is_public":false,"ram":1024,"vcpus":2}}`)
+}
+
+func (s *S) TestDeleteFlavor(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.DeleteFlavor("10")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/flavors/10")
+}
+
+func (s *S) TestFlavorExtraSpecs(c *C) {
+	testServer.PrepareResponse(200, nil, `{"extra_specs": {"hw:cpu_policy": "dedicated"}}`)
+	testServer.PrepareResponse(200, nil, `{"extra_specs": {"hw:numa_nodes": "1"}}`)
+	testServer.PrepareResponse(200, nil, "")
+	client := newTestClient()
+	specs, err := client.GetFlavorExtraSpecs("10")
+	c.Assert(err, IsNil)
+	c.Assert(specs, DeepEquals, map[string]string{"hw:cpu_policy": "dedicated"})
+	err = client.SetFlavorExtraSpecs("10", map[string]string{"hw:numa_nodes": "1"})
+	c.Assert(err, IsNil)
+	err = client.DeleteFlavorExtraSpec("10", "hw:cpu_policy")
+	c.Assert(err, IsNil)
+	req, _, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/flavors/10/os-extra_specs")
+	req, b, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.Method, Equals, "POST")
+	c.Assert(string(b), Equals, `{"extra_specs":{"hw:numa_nodes":"1"}}`)
+	req, _, _ = testServer.WaitRequest(1e9)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/flavors/10/os-extra_specs/hw:cpu_policy")
+}
+
+func (s *S) TestFlavorAccess(c *C) {
+	testServer.PrepareResponse(200, nil, `{"flavor_access": [{"flavor_id": "10", "tenant_id": "123tenant"}]}`)
+	testServer.PrepareResponse(200, nil, `{"flavor_access": []}`)
+	testServer.PrepareResponse(200, nil, `{"flavor_access": []}`)
+	client := newTestClient()
+	access, err := client.ListFlavorAccess("10")
+	c.Assert(err, IsNil)
+	c.Assert(access, DeepEquals, []FlavorAccess{{FlavorId: "10", TenantId: "123tenant"}})
+	err = client.AddFlavorAccess("10", "456tenant")
+	c.Assert(err, IsNil)
+	err = client.RemoveFlavorAccess("10", "456tenant")
+	c.Assert(err, IsNil)
+	req, _, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/flavors/10/os-flavor-access")
+	req, b, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/flavors/10/action")
+	c.Assert(string(b), Equals, `{"addTenantAccess":{"tenant":"456tenant"}}`)
+	_, b, _ = testServer.WaitRequest(1e9)
+	c.Assert(string(b), Equals, `{"removeTenantAccess":{"tenant":"456tenant"}}`)
+}
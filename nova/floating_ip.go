@@ -0,0 +1,127 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// FloatingIP represents a floating IP: a public address that can be
+// associated with a server.
+//
+// FixedIP and InstanceId are empty when the floating IP is not associated
+// with any server.
+type FloatingIP struct {
+	Id         string
+	IP         string
+	FixedIP    string
+	InstanceId string
+	Pool       string
+}
+
+// UnmarshalJSON decodes a floating IP, accepting both numeric and string ids.
+func (f *FloatingIP) UnmarshalJSON(b []byte) error {
+	var ip struct {
+		Id         interface{}
+		IP         string
+		FixedIP    string `json:"fixed_ip"`
+		InstanceId string `json:"instance_id"`
+		Pool       string
+	}
+	if err := json.Unmarshal(b, &ip); err != nil {
+		return err
+	}
+	*f = FloatingIP{
+		Id:         idString(ip.Id),
+		IP:         ip.IP,
+		FixedIP:    ip.FixedIP,
+		InstanceId: ip.InstanceId,
+		Pool:       ip.Pool,
+	}
+	return nil
+}
+
+// ListFloatingIPPools returns the names of the pools of floating IPs.
+func (c *Client) ListFloatingIPPools() ([]string, error) {
+	var result struct {
+		Pools []struct{ Name string } `json:"floating_ip_pools"`
+	}
+	err := c.request("get the list of floating IP pools", "GET", "/os-floating-ip-pools", nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	pools := make([]string, len(result.Pools))
+	for i, pool := range result.Pools {
+		pools[i] = pool.Name
+	}
+	return pools, nil
+}
+
+// ListFloatingIPs returns the floating IPs allocated to the tenant.
+func (c *Client) ListFloatingIPs() ([]FloatingIP, error) {
+	var result struct {
+		FloatingIPs []FloatingIP `json:"floating_ips"`
+	}
+	err := c.request("get the list of floating IPs", "GET", "/os-floating-ips", nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return result.FloatingIPs, nil
+}
+
+// GetFloatingIP returns the floating IP with the given id.
+func (c *Client) GetFloatingIP(id string) (*FloatingIP, error) {
+	var result struct {
+		FloatingIP FloatingIP `json:"floating_ip"`
+	}
+	err := c.request("get the floating IP "+id, "GET", "/os-floating-ips/"+id, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.FloatingIP, nil
+}
+
+// AllocateFloatingIP allocates a floating IP from the given pool to the
+// tenant. When pool is empty, nova uses the default pool.
+func (c *Client) AllocateFloatingIP(pool string) (*FloatingIP, error) {
+	var body interface{}
+	if pool != "" {
+		body = map[string]string{"pool": pool}
+	}
+	var result struct {
+		FloatingIP FloatingIP `json:"floating_ip"`
+	}
+	err := c.request("allocate a floating IP", "POST", "/os-floating-ips", body, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.FloatingIP, nil
+}
+
+// ReleaseFloatingIP releases a floating IP, returning it to its pool.
+func (c *Client) ReleaseFloatingIP(id string) error {
+	return c.request("release the floating IP "+id, "DELETE", "/os-floating-ips/"+id, nil, nil, http.StatusAccepted)
+}
+
+// AssociateFloatingIP associates the floating IP with the given address to a
+// server. The fixedAddress parameter is optional, and selects the fixed IP of
+// the server that will be associated with the floating IP, for servers with
+// more than one fixed IP.
+func (c *Client) AssociateFloatingIP(serverId, address, fixedAddress string) error {
+	addFloatingIp := map[string]string{"address": address}
+	if fixedAddress != "" {
+		addFloatingIp["fixed_address"] = fixedAddress
+	}
+	body := map[string]interface{}{"addFloatingIp": addFloatingIp}
+	return c.action("associate the floating IP "+address+" with the server "+serverId, serverId, body, nil)
+}
+
+// DisassociateFloatingIP disassociates the floating IP with the given address
+// from a server.
+func (c *Client) DisassociateFloatingIP(serverId, address string) error {
+	body := map[string]interface{}{"removeFloatingIp": map[string]string{"address": address}}
+	return c.action("disassociate the floating IP "+address+" from the server "+serverId, serverId, body, nil)
+}
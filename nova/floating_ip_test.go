@@ -0,0 +1,104 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	. "launchpad.net/gocheck"
+)
+
+func (s *S) TestListFloatingIPPools(c *C) {
+	testServer.PrepareResponse(200, nil, `{"floating_ip_pools": [{"name": "public"}, {"name": "internal"}]}`)
+	client := newTestClient()
+	pools, err := client.ListFloatingIPPools()
+	c.Assert(err, IsNil)
+	c.Assert(pools, DeepEquals, []string{"public", "internal"})
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-floating-ip-pools")
+}
+
+func (s *S) TestListFloatingIPs(c *C) {
+	testServer.PrepareResponse(200, nil, `{"floating_ips": [{"fixed_ip": null, "id": 1, "instance_id": null, "ip": "10.10.10.1", "pool": "public"}, {"fixed_ip": "192.168.0.3", "id": 2, "instance_id": "f5dc173b", "ip": "10.10.10.2", "pool": "public"}]}`)
+	client := newTestClient()
+	ips, err := client.ListFloatingIPs()
+	c.Assert(err, IsNil)
+	expected := []FloatingIP{
+		{Id: "1", IP: "10.10.10.1", Pool: "public"},
+		{Id: "2", IP: "10.10.10.2", FixedIP: "192.168.0.3", InstanceId: "f5dc173b", Pool: "public"},
+	}
+	c.Assert(ips, DeepEquals, expected)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-floating-ips")
+}
+
+func (s *S) TestGetFloatingIP(c *C) {
+	testServer.PrepareResponse(200, nil, `{"floating_ip": {"fixed_ip": null, "id": "a1b2", "instance_id": null, "ip": "10.10.10.1", "pool": "public"}}`)
+	client := newTestClient()
+	ip, err := client.GetFloatingIP("a1b2")
+	c.Assert(err, IsNil)
+	c.Assert(ip, DeepEquals, &FloatingIP{Id: "a1b2", IP: "10.10.10.1", Pool: "public"})
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-floating-ips/a1b2")
+}
+
+func (s *S) TestAllocateFloatingIP(c *C) {
+	testServer.PrepareResponse(200, nil, `{"floating_ip": {"fixed_ip": null, "id": 1, "instance_id": null, "ip": "10.10.10.1", "pool": "public"}}`)
+	testServer.PrepareResponse(200, nil, `{"floating_ip": {"fixed_ip": null, "id": 2, "instance_id": null, "ip": "10.10.10.2", "pool": "nova"}}`)
+	client := newTestClient()
+	ip, err := client.AllocateFloatingIP("public")
+	c.Assert(err, IsNil)
+	c.Assert(ip.IP, Equals, "10.10.10.1")
+	ip, err = client.AllocateFloatingIP("")
+	c.Assert(err, IsNil)
+	c.Assert(ip.Pool, Equals, "nova")
+	req, b, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.Method, Equals, "POST")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-floating-ips")
+	c.Assert(string(b), Equals, `{"pool":"public"}`)
+	_, b, _ = testServer.WaitRequest(1e9)
+	c.Assert(string(b), Equals, "")
+}
+
+func (s *S) TestAllocateFloatingIPFailure(c *C) {
+	testServer.PrepareResponse(404, nil, "No more floating IPs in pool public.")
+	client := newTestClient()
+	_, err := client.AllocateFloatingIP("public")
+	c.Assert(err, ErrorMatches, "^Failed to allocate a floating IP, status: 404.\nBody: No more floating IPs in pool public..$")
+}
+
+func (s *S) TestReleaseFloatingIP(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.ReleaseFloatingIP("1")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-floating-ips/1")
+}
+
+func (s *S) TestAssociateFloatingIP(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.AssociateFloatingIP("f5dc173b", "10.10.10.1", "192.168.0.3")
+	c.Assert(err, IsNil)
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/action")
+	c.Assert(string(b), Equals, `{"addFloatingIp":{"address":"10.10.10.1","fixed_address":"192.168.0.3"}}`)
+}
+
+func (s *S) TestDisassociateFloatingIP(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.DisassociateFloatingIP("f5dc173b", "10.10.10.1")
+	c.Assert(err, IsNil)
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/action")
+	c.Assert(string(b), Equals, `{"removeFloatingIp":{"address":"10.10.10.1"}}`)
+}
@@ -0,0 +1,257 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/globocom/go-openstack/pagination"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Hypervisor represents a compute node and its capacity. Memory is in
+// megabytes and disk is in gigabytes.
+//
+// ListHypervisors returns only Id, Hostname, State and Status; the other
+// methods return all fields.
+type Hypervisor struct {
+	Id                 string
+	Hostname           string
+	State              string
+	Status             string
+	Type               string
+	Version            int
+	HostIP             string
+	VCPUs              int
+	VCPUsUsed          int
+	MemoryMB           int
+	MemoryMBUsed       int
+	FreeRAMMB          int
+	LocalGB            int
+	LocalGBUsed        int
+	FreeDiskGB         int
+	DiskAvailableLeast int
+	RunningVMs         int
+	CurrentWorkload    int
+	CPUInfo            map[string]interface{}
+	Service            HypervisorService
+	Servers            []HypervisorServer
+	Uptime             string
+}
+
+// HypervisorServer represents a server running on a hypervisor.
+type HypervisorServer struct {
+	Id   string
+	Name string
+}
+
+// HypervisorService represents the compute service of a hypervisor.
+type HypervisorService struct {
+	Id             string
+	Host           string
+	DisabledReason string
+}
+
+// UnmarshalJSON decodes a hypervisor, accepting both numeric and string ids.
+// Before the microversion 2.28, nova returns the CPU info as a JSON-encoded
+// string, that is also decoded.
+func (h *Hypervisor) UnmarshalJSON(b []byte) error {
+	var hypervisor struct {
+		Id                 interface{}
+		Hostname           string `json:"hypervisor_hostname"`
+		State              string
+		Status             string
+		Type               string `json:"hypervisor_type"`
+		Version            int    `json:"hypervisor_version"`
+		HostIP             string `json:"host_ip"`
+		VCPUs              int
+		VCPUsUsed          int             `json:"vcpus_used"`
+		MemoryMB           int             `json:"memory_mb"`
+		MemoryMBUsed       int             `json:"memory_mb_used"`
+		FreeRAMMB          int             `json:"free_ram_mb"`
+		LocalGB            int             `json:"local_gb"`
+		LocalGBUsed        int             `json:"local_gb_used"`
+		FreeDiskGB         int             `json:"free_disk_gb"`
+		DiskAvailableLeast int             `json:"disk_available_least"`
+		RunningVMs         int             `json:"running_vms"`
+		CurrentWorkload    int             `json:"current_workload"`
+		CPUInfo            json.RawMessage `json:"cpu_info"`
+		Service            struct {
+			Id             interface{}
+			Host           string
+			DisabledReason string `json:"disabled_reason"`
+		}
+		Servers []struct {
+			UUID string
+			Name string
+		}
+		Uptime string
+	}
+	if err := json.Unmarshal(b, &hypervisor); err != nil {
+		return err
+	}
+	*h = Hypervisor{
+		Id:                 idString(hypervisor.Id),
+		Hostname:           hypervisor.Hostname,
+		State:              hypervisor.State,
+		Status:             hypervisor.Status,
+		Type:               hypervisor.Type,
+		Version:            hypervisor.Version,
+		HostIP:             hypervisor.HostIP,
+		VCPUs:              hypervisor.VCPUs,
+		VCPUsUsed:          hypervisor.VCPUsUsed,
+		MemoryMB:           hypervisor.MemoryMB,
+		MemoryMBUsed:       hypervisor.MemoryMBUsed,
+		FreeRAMMB:          hypervisor.FreeRAMMB,
+		LocalGB:            hypervisor.LocalGB,
+		LocalGBUsed:        hypervisor.LocalGBUsed,
+		FreeDiskGB:         hypervisor.FreeDiskGB,
+		DiskAvailableLeast: hypervisor.DiskAvailableLeast,
+		RunningVMs:         hypervisor.RunningVMs,
+		CurrentWorkload:    hypervisor.CurrentWorkload,
+		Service: HypervisorService{
+			Id:             idString(hypervisor.Service.Id),
+			Host:           hypervisor.Service.Host,
+			DisabledReason: hypervisor.Service.DisabledReason,
+		},
+		Uptime: hypervisor.Uptime,
+	}
+	if len(hypervisor.CPUInfo) > 0 {
+		var info string
+		if err := json.Unmarshal(hypervisor.CPUInfo, &info); err == nil {
+			json.Unmarshal([]byte(info), &h.CPUInfo)
+		} else {
+			json.Unmarshal(hypervisor.CPUInfo, &h.CPUInfo)
+		}
+	}
+	for _, server := range hypervisor.Servers {
+		h.Servers = append(h.Servers, HypervisorServer{Id: server.UUID, Name: server.Name})
+	}
+	return nil
+}
+
+// HypervisorStatistics contains the capacity and usage of all hypervisors
+// combined.
+type HypervisorStatistics struct {
+	Count              int
+	VCPUs              int
+	VCPUsUsed          int `json:"vcpus_used"`
+	MemoryMB           int `json:"memory_mb"`
+	MemoryMBUsed       int `json:"memory_mb_used"`
+	FreeRAMMB          int `json:"free_ram_mb"`
+	LocalGB            int `json:"local_gb"`
+	LocalGBUsed        int `json:"local_gb_used"`
+	FreeDiskGB         int `json:"free_disk_gb"`
+	DiskAvailableLeast int `json:"disk_available_least"`
+	RunningVMs         int `json:"running_vms"`
+	CurrentWorkload    int `json:"current_workload"`
+}
+
+func (c *Client) listHypervisors(ctx context.Context, op, path string) (*pagination.Page[Hypervisor], error) {
+	var result struct {
+		Hypervisors []Hypervisor
+		Links       []pagination.Link `json:"hypervisors_links"`
+	}
+	err := c.requestContext(ctx, op, "GET", path, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &pagination.Page[Hypervisor]{Items: result.Hypervisors, Next: pagination.NextMarker(result.Links)}, nil
+}
+
+// ListHypervisors returns the id, hostname, state and status of all
+// hypervisors, following the pages of the listing.
+func (c *Client) ListHypervisors() ([]Hypervisor, error) {
+	return c.HypervisorPager(false, 0).All(context.Background())
+}
+
+// ListHypervisorsDetail returns all hypervisors with their capacity and usage,
+// following the pages of the listing.
+func (c *Client) ListHypervisorsDetail() ([]Hypervisor, error) {
+	return c.HypervisorPager(true, 0).All(context.Background())
+}
+
+// HypervisorPager returns a pager over the hypervisors, with pages of the
+// given size. When detail is true, the hypervisors include their capacity and
+// usage. Pagination requires the microversion 2.33 or newer; with older
+// microversions, all hypervisors are returned in a single page.
+func (c *Client) HypervisorPager(detail bool, limit int) *pagination.Pager[Hypervisor] {
+	path, op := "/os-hypervisors", "get the list of hypervisors"
+	if detail {
+		path, op = "/os-hypervisors/detail", "get the details of hypervisors"
+	}
+	return pagination.New(func(ctx context.Context, marker string) (*pagination.Page[Hypervisor], error) {
+		q := url.Values{}
+		if limit > 0 {
+			q.Set("limit", strconv.Itoa(limit))
+		}
+		if marker != "" {
+			q.Set("marker", marker)
+		}
+		p := path
+		if len(q) > 0 {
+			p += "?" + q.Encode()
+		}
+		return c.listHypervisors(ctx, op, p)
+	})
+}
+
+// GetHypervisor returns the hypervisor with the given id.
+func (c *Client) GetHypervisor(id string) (*Hypervisor, error) {
+	var result struct{ Hypervisor Hypervisor }
+	err := c.request("get the hypervisor "+id, "GET", "/os-hypervisors/"+id, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Hypervisor, nil
+}
+
+// HypervisorStatistics returns the capacity and usage of all hypervisors
+// combined.
+func (c *Client) HypervisorStatistics() (*HypervisorStatistics, error) {
+	var result struct {
+		Statistics HypervisorStatistics `json:"hypervisor_statistics"`
+	}
+	err := c.request("get the hypervisor statistics", "GET", "/os-hypervisors/statistics", nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Statistics, nil
+}
+
+// ListHypervisorServers returns the hypervisors whose hostname matches the
+// given pattern, with the servers running on each of them in the Servers
+// field.
+func (c *Client) ListHypervisorServers(hostnamePattern string) ([]Hypervisor, error) {
+	op := "get the servers of the hypervisors " + hostnamePattern
+	ok, err := c.SupportsMicroversion("2.53")
+	if err != nil {
+		return nil, err
+	}
+	path := "/os-hypervisors/" + url.PathEscape(hostnamePattern) + "/servers"
+	if ok {
+		q := url.Values{"hypervisor_hostname_pattern": {hostnamePattern}, "with_servers": {"true"}}
+		path = "/os-hypervisors?" + q.Encode()
+	}
+	// Nova does not paginate the hypervisors searched by hostname.
+	page, err := c.listHypervisors(context.Background(), op, path)
+	if err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+// HypervisorUptime returns the uptime of the hypervisor with the given id, as
+// reported by the uptime command in the hypervisor.
+func (c *Client) HypervisorUptime(id string) (string, error) {
+	var result struct{ Hypervisor Hypervisor }
+	err := c.request("get the uptime of the hypervisor "+id, "GET", "/os-hypervisors/"+id+"/uptime", nil, &result, http.StatusOK)
+	if err != nil {
+		return "", err
+	}
+	return result.Hypervisor.Uptime, nil
+}
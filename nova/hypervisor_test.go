@@ -0,0 +1,132 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	. "launchpad.net/gocheck"
+)
+
+const hypervisorBody = `{"hypervisor": {"cpu_info": "{\"arch\": \"x86_64\", \"vendor\": \"Intel\"}", "current_workload": 0, "disk_available_least": 0, "free_disk_gb": 1028, "free_ram_mb": 7680, "host_ip": "192.168.1.135", "hypervisor_hostname": "compute1", "hypervisor_type": "QEMU", "hypervisor_version": 1000, "id": 1, "local_gb": 1028, "local_gb_used": 0, "memory_mb": 8192, "memory_mb_used": 512, "running_vms": 0, "service": {"host": "compute1", "id": 2, "disabled_reason": null}, "state": "up", "status": "enabled", "vcpus": 2, "vcpus_used": 0}}`
+
+func (s *S) TestGetHypervisor(c *C) {
+	testServer.PrepareResponse(200, nil, hypervisorBody)
+	client := newTestClient()
+	hypervisor, err := client.GetHypervisor("1")
+	c.Assert(err, IsNil)
+	expected := &Hypervisor{
+		Id:           "1",
+		Hostname:     "compute1",
+		State:        "up",
+		Status:       "enabled",
+		Type:         "QEMU",
+		Version:      1000,
+		HostIP:       "192.168.1.135",
+		VCPUs:        2,
+		MemoryMB:     8192,
+		MemoryMBUsed: 512,
+		FreeRAMMB:    7680,
+		LocalGB:      1028,
+		FreeDiskGB:   1028,
+		CPUInfo:      map[string]interface{}{"arch": "x86_64", "vendor": "Intel"},
+		Service:      HypervisorService{Id: "2", Host: "compute1"},
+	}
+	c.Assert(hypervisor, DeepEquals, expected)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-hypervisors/1")
+}
+
+func (s *S) TestGetHypervisorCPUInfoObject(c *C) {
+	testServer.PrepareResponse(200, nil, `{"hypervisor": {"id": "c48f6247-abe4-4a24-824e-ea39e108874f", "cpu_info": {"arch": "x86_64"}}}`)
+	client := newTestClient()
+	hypervisor, err := client.GetHypervisor("c48f6247-abe4-4a24-824e-ea39e108874f")
+	c.Assert(err, IsNil)
+	c.Assert(hypervisor.CPUInfo, DeepEquals, map[string]interface{}{"arch": "x86_64"})
+}
+
+func (s *S) TestListHypervisors(c *C) {
+	testServer.PrepareResponse(200, nil, `{"hypervisors": [{"hypervisor_hostname": "compute1", "id": 1, "state": "up", "status": "enabled"}]}`)
+	testServer.PrepareResponse(200, nil, `{"hypervisors": [{"hypervisor_hostname": "compute1", "id": 1, "vcpus": 2, "vcpus_used": 1}]}`)
+	client := newTestClient()
+	hypervisors, err := client.ListHypervisors()
+	c.Assert(err, IsNil)
+	c.Assert(hypervisors, DeepEquals, []Hypervisor{{Id: "1", Hostname: "compute1", State: "up", Status: "enabled"}})
+	hypervisors, err = client.ListHypervisorsDetail()
+	c.Assert(err, IsNil)
+	c.Assert(hypervisors[0].VCPUsUsed, Equals, 1)
+	req, _, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-hypervisors")
+	req, _, _ = testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-hypervisors/detail")
+}
+
+func (s *S) TestListHypervisorsFailure(c *C) {
+	testServer.PrepareResponse(403, nil, "Policy doesn't allow it")
+	client := newTestClient()
+	_, err := client.ListHypervisors()
+	c.Assert(err, ErrorMatches, "^Failed to get the list of hypervisors, status: 403.\nBody: Policy doesn't allow it.$")
+}
+
+func (s *S) TestHypervisorStatistics(c *C) {
+	testServer.PrepareResponse(200, nil, `{"hypervisor_statistics": {"count": 2, "current_workload": 0, "disk_available_least": 0, "free_disk_gb": 2056, "free_ram_mb": 15360, "local_gb": 2056, "local_gb_used": 0, "memory_mb": 16384, "memory_mb_used": 1024, "running_vms": 3, "vcpus": 4, "vcpus_used": 3}}`)
+	client := newTestClient()
+	stats, err := client.HypervisorStatistics()
+	c.Assert(err, IsNil)
+	expected := &HypervisorStatistics{
+		Count:        2,
+		VCPUs:        4,
+		VCPUsUsed:    3,
+		MemoryMB:     16384,
+		MemoryMBUsed: 1024,
+		FreeRAMMB:    15360,
+		LocalGB:      2056,
+		FreeDiskGB:   2056,
+		RunningVMs:   3,
+	}
+	c.Assert(stats, DeepEquals, expected)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-hypervisors/statistics")
+}
+
+func (s *S) TestListHypervisorServers(c *C) {
+	testServer.PrepareResponse(200, nil, `{"hypervisors": [{"hypervisor_hostname": "compute1", "id": 1, "servers": [{"name": "web1", "uuid": "f5dc173b"}]}]}`)
+	client := newTestClient()
+	hypervisors, err := client.ListHypervisorServers("compute1")
+	c.Assert(err, IsNil)
+	c.Assert(hypervisors, HasLen, 1)
+	c.Assert(hypervisors[0].Servers, DeepEquals, []HypervisorServer{{Id: "f5dc173b", Name: "web1"}})
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-hypervisors/compute1/servers")
+}
+
+func (s *S) TestListHypervisorServersWithMicroversion(c *C) {
+	testServer.PrepareResponse(200, nil, versionsBody)
+	testServer.PrepareResponse(200, nil, `{"hypervisors": [{"hypervisor_hostname": "compute1", "id": "c48f6247", "servers": []}]}`)
+	client := newUndiscoveredTestClient()
+	client.Microversion = LatestMicroversion
+	hypervisors, err := client.ListHypervisorServers("compute1")
+	c.Assert(err, IsNil)
+	c.Assert(hypervisors[0].Id, Equals, "c48f6247")
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/")
+	req, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-hypervisors")
+	c.Assert(req.URL.RawQuery, Equals, "hypervisor_hostname_pattern=compute1&with_servers=true")
+}
+
+func (s *S) TestHypervisorUptime(c *C) {
+	testServer.PrepareResponse(200, nil, `{"hypervisor": {"hypervisor_hostname": "compute1", "id": 1, "state": "up", "status": "enabled", "uptime": " 08:32:11 up 93 days, 18:25, 12 users,  load average: 0.20, 0.12, 0.14"}}`)
+	client := newTestClient()
+	uptime, err := client.HypervisorUptime("1")
+	c.Assert(err, IsNil)
+	c.Assert(uptime, Equals, " 08:32:11 up 93 days, 18:25, 12 users,  load average: 0.20, 0.12, 0.14")
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-hypervisors/1/uptime")
+}
@@ -0,0 +1,181 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/globocom/go-openstack/internal/timeutil"
+	"github.com/globocom/go-openstack/pagination"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// InstanceAction represents an action performed on a server (e.g. create,
+// reboot or migrate), identified by the id of the request that started it.
+//
+// Events is only filled by GetInstanceAction and ListInstanceActionsWithEvents,
+// and contains the steps of the action in the compute services.
+type InstanceAction struct {
+	Action    string
+	RequestId string
+	ServerId  string
+	TenantId  string
+	UserId    string
+	Message   string
+	Started   time.Time
+	Updated   time.Time
+	Events    []InstanceActionEvent
+}
+
+// UnmarshalJSON decodes an instance action, parsing the timestamps in nova's
+// format.
+func (a *InstanceAction) UnmarshalJSON(b []byte) error {
+	var action struct {
+		Action    string
+		RequestId string `json:"request_id"`
+		ServerId  string `json:"instance_uuid"`
+		TenantId  string `json:"project_id"`
+		UserId    string `json:"user_id"`
+		Message   string
+		StartTime string `json:"start_time"`
+		UpdatedAt string `json:"updated_at"`
+		Events    []InstanceActionEvent
+	}
+	if err := json.Unmarshal(b, &action); err != nil {
+		return err
+	}
+	*a = InstanceAction{
+		Action:    action.Action,
+		RequestId: action.RequestId,
+		ServerId:  action.ServerId,
+		TenantId:  action.TenantId,
+		UserId:    action.UserId,
+		Message:   action.Message,
+		Started:   timeutil.Parse(action.StartTime),
+		Updated:   timeutil.Parse(action.UpdatedAt),
+		Events:    action.Events,
+	}
+	return nil
+}
+
+// InstanceActionEvent represents a step of an instance action. Result is
+// "Success" or "Error"; Traceback is only available to admins.
+type InstanceActionEvent struct {
+	Event     string
+	Host      string
+	Result    string
+	Traceback string
+	Started   time.Time
+	Finished  time.Time
+}
+
+// UnmarshalJSON decodes an instance action event, parsing the timestamps in
+// nova's format.
+func (e *InstanceActionEvent) UnmarshalJSON(b []byte) error {
+	var event struct {
+		Event      string
+		Host       string
+		Result     string
+		Traceback  string
+		StartTime  string `json:"start_time"`
+		FinishTime string `json:"finish_time"`
+	}
+	if err := json.Unmarshal(b, &event); err != nil {
+		return err
+	}
+	*e = InstanceActionEvent{
+		Event:     event.Event,
+		Host:      event.Host,
+		Result:    event.Result,
+		Traceback: event.Traceback,
+		Started:   timeutil.Parse(event.StartTime),
+		Finished:  timeutil.Parse(event.FinishTime),
+	}
+	return nil
+}
+
+func (c *Client) listInstanceActions(ctx context.Context, serverId string, limit int, marker string) (*pagination.Page[InstanceAction], error) {
+	var result struct {
+		Actions []InstanceAction `json:"instanceActions"`
+		Links   []pagination.Link
+	}
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	if marker != "" {
+		q.Set("marker", marker)
+	}
+	path := "/servers/" + serverId + "/os-instance-actions"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	err := c.requestContext(ctx, "get the actions of the server "+serverId, "GET", path, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &pagination.Page[InstanceAction]{Items: result.Actions, Next: pagination.NextMarker(result.Links)}, nil
+}
+
+// ListInstanceActions returns all actions performed on a server, most recent
+// first, following the pages of the listing. nova does not include the events
+// in the listing, so the Events of the actions are empty; use
+// ListInstanceActionsWithEvents to get them.
+func (c *Client) ListInstanceActions(serverId string) ([]InstanceAction, error) {
+	return c.InstanceActionPager(serverId, 0).All(context.Background())
+}
+
+// ListInstanceActionsWithEvents works like ListInstanceActions, but also gets
+// the events of each action, sending one request per action.
+//
+// Example of use (printing the audit trail of a server):
+//
+//     actions, err := client.ListInstanceActionsWithEvents(server.Id)
+//     // handle err
+//     for _, action := range actions {
+//         for _, event := range action.Events {
+//             fmt.Printf("%s %s: %s\n", action.Action, event.Event, event.Result)
+//         }
+//     }
+func (c *Client) ListInstanceActionsWithEvents(serverId string) ([]InstanceAction, error) {
+	actions, err := c.ListInstanceActions(serverId)
+	if err != nil {
+		return nil, err
+	}
+	for i, a := range actions {
+		action, err := c.GetInstanceAction(serverId, a.RequestId)
+		if err != nil {
+			return nil, err
+		}
+		actions[i] = *action
+	}
+	return actions, nil
+}
+
+// InstanceActionPager returns a pager over the actions performed on a server,
+// most recent first, with pages of the given size. Pagination requires the
+// microversion 2.58 or newer.
+func (c *Client) InstanceActionPager(serverId string, limit int) *pagination.Pager[InstanceAction] {
+	return pagination.New(func(ctx context.Context, marker string) (*pagination.Page[InstanceAction], error) {
+		return c.listInstanceActions(ctx, serverId, limit, marker)
+	})
+}
+
+// GetInstanceAction returns the action with the given request id performed on
+// a server, along with its events.
+func (c *Client) GetInstanceAction(serverId, requestId string) (*InstanceAction, error) {
+	var result struct {
+		Action InstanceAction `json:"instanceAction"`
+	}
+	op := "get the action " + requestId + " of the server " + serverId
+	err := c.request(op, "GET", "/servers/"+serverId+"/os-instance-actions/"+requestId, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Action, nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	. "launchpad.net/gocheck"
+	"time"
+)
+
+func (s *S) TestListInstanceActions(c *C) {
+	testServer.PrepareResponse(200, nil, `{"instanceActions": [{"action": "reboot", "instance_uuid": "f5dc173b", "message": null, "project_id": "123tenant", "request_id": "req-3293a3f1", "start_time": "2012-12-05T01:00:00.000000", "user_id": "123user"}, {"action": "create", "instance_uuid": "f5dc173b", "message": null, "project_id": "123tenant", "request_id": "req-c8e4f1a1", "start_time": "2012-12-05T00:00:00.000000", "user_id": "123user"}]}`)
+	client := newTestClient()
+	actions, err := client.ListInstanceActions("f5dc173b")
+	c.Assert(err, IsNil)
+	c.Assert(actions, HasLen, 2)
+	expected := InstanceAction{
+		Action:    "reboot",
+		RequestId: "req-3293a3f1",
+		ServerId:  "f5dc173b",
+		TenantId:  "123tenant",
+		UserId:    "123user",
+		Started:   time.Date(2012, 12, 5, 1, 0, 0, 0, time.UTC),
+	}
+	c.Assert(actions[0], DeepEquals, expected)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/os-instance-actions")
+}
+
+func (s *S) TestListInstanceActionsWithEvents(c *C) {
+	testServer.PrepareResponse(200, nil, `{"instanceActions": [{"action": "reboot", "instance_uuid": "f5dc173b", "request_id": "req-3293a3f1"}, {"action": "create", "instance_uuid": "f5dc173b", "request_id": "req-c8e4f1a1"}]}`)
+	testServer.PrepareResponse(200, nil, `{"instanceAction": {"action": "reboot", "instance_uuid": "f5dc173b", "request_id": "req-3293a3f1", "events": [{"event": "compute_reboot_instance", "result": "Success"}]}}`)
+	testServer.PrepareResponse(200, nil, `{"instanceAction": {"action": "create", "instance_uuid": "f5dc173b", "request_id": "req-c8e4f1a1", "events": [{"event": "compute__do_build_and_run_instance", "result": "Success"}]}}`)
+	client := newTestClient()
+	actions, err := client.ListInstanceActionsWithEvents("f5dc173b")
+	c.Assert(err, IsNil)
+	c.Assert(actions, HasLen, 2)
+	c.Assert(actions[0].Events, HasLen, 1)
+	c.Assert(actions[0].Events[0].Event, Equals, "compute_reboot_instance")
+	c.Assert(actions[1].Action, Equals, "create")
+	c.Assert(actions[1].Events[0].Event, Equals, "compute__do_build_and_run_instance")
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	for _, id := range []string{"req-3293a3f1", "req-c8e4f1a1"} {
+		req, _, err := testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+		c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/os-instance-actions/"+id)
+	}
+}
+
+func (s *S) TestGetInstanceAction(c *C) {
+	testServer.PrepareResponse(200, nil, `{"instanceAction": {"action": "reboot", "instance_uuid": "f5dc173b", "message": "Error", "project_id": "123tenant", "request_id": "req-3293a3f1", "start_time": "2012-12-05T01:00:00.000000", "user_id": "123user", "events": [{"event": "compute_reboot_instance", "host": "compute1", "result": "Error", "traceback": "Traceback...", "start_time": "2012-12-05T01:00:02.000000", "finish_time": "2012-12-05T01:00:28.000000"}]}}`)
+	client := newTestClient()
+	action, err := client.GetInstanceAction("f5dc173b", "req-3293a3f1")
+	c.Assert(err, IsNil)
+	c.Assert(action.Message, Equals, "Error")
+	expected := []InstanceActionEvent{{
+		Event:     "compute_reboot_instance",
+		Host:      "compute1",
+		Result:    "Error",
+		Traceback: "Traceback...",
+		Started:   time.Date(2012, 12, 5, 1, 0, 2, 0, time.UTC),
+		Finished:  time.Date(2012, 12, 5, 1, 0, 28, 0, time.UTC),
+	}}
+	c.Assert(action.Events, DeepEquals, expected)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/os-instance-actions/req-3293a3f1")
+}
+
+func (s *S) TestGetInstanceActionFailure(c *C) {
+	testServer.PrepareResponse(404, nil, "Action req-0 not found")
+	client := newTestClient()
+	_, err := client.GetInstanceAction("f5dc173b", "req-0")
+	c.Assert(err, ErrorMatches, "^Failed to get the action req-0 of the server f5dc173b, status: 404.\nBody: Action req-0 not found.$")
+}
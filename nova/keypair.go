@@ -0,0 +1,163 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/globocom/go-openstack/pagination"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPublicKey is returned by Fingerprint and ImportKeypair when the
+// given public key is not a valid OpenSSH public key.
+var ErrInvalidPublicKey = errors.New("Invalid public key: the key is not in the OpenSSH format.")
+
+// Keypair represents a SSH keypair registered in nova.
+//
+// PrivateKey is available only in the keypair returned by CreateKeypair. Nova
+// does not store private keys, so it is the only chance to get it.
+type Keypair struct {
+	Name        string
+	PublicKey   string `json:"public_key"`
+	PrivateKey  string `json:"private_key"`
+	Fingerprint string
+	Type        string
+	UserId      string `json:"user_id"`
+}
+
+// Fingerprint returns the fingerprint of the given OpenSSH public key (as in
+// ~/.ssh/id_rsa.pub), in the same format used by nova: the MD5 hash of the
+// key, in hexadecimal, with colon-separated bytes.
+func Fingerprint(publicKey string) (string, error) {
+	fields := strings.Fields(publicKey)
+	if len(fields) < 2 {
+		return "", ErrInvalidPublicKey
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil || len(blob) < 4 {
+		return "", ErrInvalidPublicKey
+	}
+	// The key starts with its type, encoded as a SSH string (length
+	// followed by the bytes), and it must match the type in the first
+	// field.
+	size := binary.BigEndian.Uint32(blob)
+	if uint64(size) > uint64(len(blob)-4) || string(blob[4:4+size]) != fields[0] {
+		return "", ErrInvalidPublicKey
+	}
+	sum := md5.Sum(blob)
+	hex := make([]string, len(sum))
+	for i, b := range sum {
+		hex[i] = fmt.Sprintf("%02x", b)
+	}
+	return strings.Join(hex, ":"), nil
+}
+
+func (c *Client) listKeypairs(ctx context.Context, limit int, marker string) (*pagination.Page[Keypair], error) {
+	var result struct {
+		Keypairs []struct{ Keypair Keypair }
+		Links    []pagination.Link `json:"keypairs_links"`
+	}
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	if marker != "" {
+		q.Set("marker", marker)
+	}
+	path := "/os-keypairs"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	err := c.requestContext(ctx, "get the list of keypairs", "GET", path, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	keypairs := make([]Keypair, len(result.Keypairs))
+	for i, k := range result.Keypairs {
+		keypairs[i] = k.Keypair
+	}
+	return &pagination.Page[Keypair]{Items: keypairs, Next: pagination.NextMarker(result.Links)}, nil
+}
+
+// ListKeypairs returns all keypairs of the user, following the pages of the
+// listing.
+func (c *Client) ListKeypairs() ([]Keypair, error) {
+	return c.KeypairPager(0).All(context.Background())
+}
+
+// KeypairPager returns a pager over the keypairs of the user, with pages of
+// the given size. Pagination requires the microversion 2.35 or newer.
+func (c *Client) KeypairPager(limit int) *pagination.Pager[Keypair] {
+	return pagination.New(func(ctx context.Context, marker string) (*pagination.Page[Keypair], error) {
+		return c.listKeypairs(ctx, limit, marker)
+	})
+}
+
+// GetKeypair returns the keypair with the given name.
+func (c *Client) GetKeypair(name string) (*Keypair, error) {
+	var result struct{ Keypair Keypair }
+	err := c.request("get the keypair "+name, "GET", "/os-keypairs/"+url.PathEscape(name), nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Keypair, nil
+}
+
+func (c *Client) createKeypair(op string, keypair map[string]string) (*Keypair, error) {
+	var result struct{ Keypair Keypair }
+	body := map[string]interface{}{"keypair": keypair}
+	err := c.request(op, "POST", "/os-keypairs", body, &result, http.StatusOK, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Keypair, nil
+}
+
+// CreateKeypair creates a new keypair, generated by nova. The returned
+// keypair includes the private key.
+func (c *Client) CreateKeypair(name string) (*Keypair, error) {
+	return c.createKeypair("create the keypair "+name, map[string]string{"name": name})
+}
+
+// ImportKeypair creates a new keypair using the given OpenSSH public key.
+//
+// The key is validated before being sent to nova, and the fingerprint
+// returned by nova is checked against the fingerprint of the key. On a
+// mismatch, the keypair is deleted and an error is returned. If the deletion
+// fails too, the keypair is returned along with the error, so the caller can
+// delete it later.
+func (c *Client) ImportKeypair(name, publicKey string) (*Keypair, error) {
+	fingerprint, err := Fingerprint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	op := "import the keypair " + name
+	keypair, err := c.createKeypair(op, map[string]string{"name": name, "public_key": publicKey})
+	if err != nil {
+		return nil, err
+	}
+	if keypair.Fingerprint != fingerprint {
+		err = fmt.Errorf("Failed to %s, fingerprint mismatch: expected %s, got %s.", op, fingerprint, keypair.Fingerprint)
+		if delErr := c.DeleteKeypair(name); delErr != nil {
+			return keypair, fmt.Errorf("%s The keypair was not deleted: %s", err, delErr)
+		}
+		return nil, err
+	}
+	return keypair, nil
+}
+
+// DeleteKeypair deletes the keypair with the given name.
+func (c *Client) DeleteKeypair(name string) error {
+	path := "/os-keypairs/" + url.PathEscape(name)
+	return c.request("delete the keypair "+name, "DELETE", path, nil, nil, http.StatusAccepted, http.StatusNoContent)
+}
@@ -0,0 +1,183 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/globocom/go-openstack/internal/timeutil"
+	"net/http"
+	"time"
+)
+
+// AbsoluteLimits contains the absolute limits of the tenant and the current
+// usage of them. A limit of -1 means unlimited. RAM is in megabytes.
+type AbsoluteLimits struct {
+	MaxTotalInstances       int
+	MaxTotalCores           int
+	MaxTotalRAMSize         int
+	MaxTotalKeypairs        int
+	MaxServerMeta           int
+	MaxImageMeta            int
+	MaxPersonality          int
+	MaxPersonalitySize      int
+	MaxSecurityGroups       int
+	MaxSecurityGroupRules   int
+	MaxTotalFloatingIps     int
+	MaxServerGroups         int
+	MaxServerGroupMembers   int
+	TotalInstancesUsed      int
+	TotalCoresUsed          int
+	TotalRAMUsed            int
+	TotalFloatingIpsUsed    int
+	TotalSecurityGroupsUsed int
+	TotalServerGroupsUsed   int
+}
+
+// RateLimit represents the rate limits applied to the requests whose URI
+// matches Regex.
+type RateLimit struct {
+	URI    string
+	Regex  string
+	Limits []RateLimitEntry `json:"limit"`
+}
+
+// RateLimitEntry represents the rate limit of a HTTP verb: Value requests per
+// Unit (SECOND, MINUTE, HOUR or DAY), with Remaining requests left until
+// NextAvailable.
+type RateLimitEntry struct {
+	Verb          string
+	Value         int
+	Remaining     int
+	Unit          string
+	NextAvailable time.Time
+}
+
+// UnmarshalJSON decodes a rate limit entry, parsing the timestamp in nova's
+// format, that may not include the time zone.
+func (e *RateLimitEntry) UnmarshalJSON(b []byte) error {
+	var entry struct {
+		Verb          string
+		Value         int
+		Remaining     int
+		Unit          string
+		NextAvailable string `json:"next-available"`
+	}
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return err
+	}
+	*e = RateLimitEntry{
+		Verb:          entry.Verb,
+		Value:         entry.Value,
+		Remaining:     entry.Remaining,
+		Unit:          entry.Unit,
+		NextAvailable: timeutil.Parse(entry.NextAvailable),
+	}
+	return nil
+}
+
+// Limits contains the absolute and rate limits of the tenant.
+type Limits struct {
+	Absolute AbsoluteLimits
+	Rate     []RateLimit
+}
+
+// Limits returns the absolute and rate limits of the tenant.
+func (c *Client) Limits() (*Limits, error) {
+	var result struct{ Limits Limits }
+	err := c.request("get the limits", "GET", "/limits", nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Limits, nil
+}
+
+// QuotaExceededError is returned by CheckQuota and AbsoluteLimits.Fits when a
+// plan of servers does not fit in the remaining quota.
+type QuotaExceededError struct {
+	Resource  string
+	Requested int
+	Available int
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("Quota exceeded: %d %s requested, %d available.", e.Requested, e.Resource, e.Available)
+}
+
+// ErrPlanWithoutFlavor is returned by CheckQuota and AbsoluteLimits.Fits when
+// a ServerPlan has a nil Flavor.
+var ErrPlanWithoutFlavor = errors.New("Invalid server plan: the flavor is required.")
+
+// ServerPlan describes Count servers of the given flavor that are about to be
+// created.
+type ServerPlan struct {
+	Flavor *Flavor
+	Count  int
+}
+
+func available(max, used int) int {
+	if max < 0 {
+		return -1
+	}
+	if max > used {
+		return max - used
+	}
+	return 0
+}
+
+// Fits checks whether the given servers fit in the remaining instances, cores
+// and RAM quotas, returning a *QuotaExceededError for the first quota that
+// would be exceeded. It returns ErrPlanWithoutFlavor if a plan has no flavor.
+func (l *AbsoluteLimits) Fits(plan ...ServerPlan) error {
+	var instances, cores, ram int
+	for _, p := range plan {
+		if p.Flavor == nil {
+			return ErrPlanWithoutFlavor
+		}
+		instances += p.Count
+		cores += p.Count * p.Flavor.VCPUs
+		ram += p.Count * p.Flavor.RAM
+	}
+	var checks = []struct {
+		resource  string
+		requested int
+		available int
+	}{
+		{"instances", instances, available(l.MaxTotalInstances, l.TotalInstancesUsed)},
+		{"cores", cores, available(l.MaxTotalCores, l.TotalCoresUsed)},
+		{"MB of RAM", ram, available(l.MaxTotalRAMSize, l.TotalRAMUsed)},
+	}
+	for _, check := range checks {
+		if check.available >= 0 && check.requested > check.available {
+			return &QuotaExceededError{Resource: check.resource, Requested: check.requested, Available: check.available}
+		}
+	}
+	return nil
+}
+
+// CheckQuota gets the current limits of the tenant and checks whether the
+// given servers fit in them. It is useful as a pre-flight check before
+// creating many servers, but nova may still refuse some of them if other
+// servers are created in the meantime.
+//
+// Example of use:
+//
+//     small, _ := client.FindFlavorByName("m1.small")
+//     large, _ := client.FindFlavorByName("m1.large")
+//     err := client.CheckQuota(
+//         nova.ServerPlan{Flavor: small, Count: 10},
+//         nova.ServerPlan{Flavor: large, Count: 2},
+//     )
+//     if e, ok := err.(*nova.QuotaExceededError); ok {
+//         fmt.Printf("not enough %s\n", e.Resource)
+//     }
+func (c *Client) CheckQuota(plan ...ServerPlan) error {
+	limits, err := c.Limits()
+	if err != nil {
+		return err
+	}
+	return limits.Absolute.Fits(plan...)
+}
@@ -0,0 +1,107 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	. "launchpad.net/gocheck"
+	"strings"
+	"time"
+)
+
+const limitsBody = `{"limits": {"absolute": {"maxImageMeta": 128, "maxPersonality": 5, "maxPersonalitySize": 10240, "maxSecurityGroupRules": 20, "maxSecurityGroups": 10, "maxServerMeta": 128, "maxTotalCores": 20, "maxTotalFloatingIps": 10, "maxTotalInstances": 10, "maxTotalKeypairs": 100, "maxTotalRAMSize": 51200, "maxServerGroups": 10, "maxServerGroupMembers": 10, "totalCoresUsed": 14, "totalInstancesUsed": 7, "totalRAMUsed": 28672, "totalSecurityGroupsUsed": 1, "totalFloatingIpsUsed": 2, "totalServerGroupsUsed": 0}, "rate": [{"regex": ".*", "uri": "*", "limit": [{"next-available": "2012-11-27T17:22:18Z", "remaining": 120, "unit": "MINUTE", "value": 120, "verb": "POST"}]}]}}`
+
+func (s *S) TestLimits(c *C) {
+	testServer.PrepareResponse(200, nil, limitsBody)
+	client := newTestClient()
+	limits, err := client.Limits()
+	c.Assert(err, IsNil)
+	c.Assert(limits.Absolute, DeepEquals, AbsoluteLimits{
+		MaxTotalInstances:       10,
+		MaxTotalCores:           20,
+		MaxTotalRAMSize:         51200,
+		MaxTotalKeypairs:        100,
+		MaxServerMeta:           128,
+		MaxImageMeta:            128,
+		MaxPersonality:          5,
+		MaxPersonalitySize:      10240,
+		MaxSecurityGroups:       10,
+		MaxSecurityGroupRules:   20,
+		MaxTotalFloatingIps:     10,
+		MaxServerGroups:         10,
+		MaxServerGroupMembers:   10,
+		TotalInstancesUsed:      7,
+		TotalCoresUsed:          14,
+		TotalRAMUsed:            28672,
+		TotalFloatingIpsUsed:    2,
+		TotalSecurityGroupsUsed: 1,
+	})
+	expected := []RateLimit{{
+		URI:   "*",
+		Regex: ".*",
+		Limits: []RateLimitEntry{
+			{Verb: "POST", Value: 120, Remaining: 120, Unit: "MINUTE", NextAvailable: time.Date(2012, 11, 27, 17, 22, 18, 0, time.UTC)},
+		},
+	}}
+	c.Assert(limits.Rate, DeepEquals, expected)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/limits")
+}
+
+func (s *S) TestLimitsWithoutTimeZone(c *C) {
+	testServer.PrepareResponse(200, nil, strings.Replace(limitsBody, "2012-11-27T17:22:18Z", "2012-11-27T17:22:18", 1))
+	client := newTestClient()
+	limits, err := client.Limits()
+	c.Assert(err, IsNil)
+	c.Assert(limits.Rate[0].Limits[0].NextAvailable.Equal(time.Date(2012, 11, 27, 17, 22, 18, 0, time.UTC)), Equals, true)
+}
+
+func (s *S) TestLimitsFailure(c *C) {
+	testServer.PrepareResponse(500, nil, "Internal error")
+	client := newTestClient()
+	_, err := client.Limits()
+	c.Assert(err, ErrorMatches, "^Failed to get the limits, status: 500.\nBody: Internal error.$")
+}
+
+func (s *S) TestAbsoluteLimitsFits(c *C) {
+	limits := AbsoluteLimits{
+		MaxTotalInstances:  10,
+		MaxTotalCores:      20,
+		MaxTotalRAMSize:    -1,
+		TotalInstancesUsed: 7,
+		TotalCoresUsed:     14,
+		TotalRAMUsed:       28672,
+	}
+	small := &Flavor{VCPUs: 1, RAM: 2048}
+	large := &Flavor{VCPUs: 4, RAM: 8192}
+	c.Assert(limits.Fits(), IsNil)
+	c.Assert(limits.Fits(ServerPlan{Flavor: small, Count: 2}, ServerPlan{Flavor: large, Count: 1}), IsNil)
+	err := limits.Fits(ServerPlan{Flavor: small, Count: 4})
+	c.Assert(err, DeepEquals, &QuotaExceededError{Resource: "instances", Requested: 4, Available: 3})
+	c.Assert(err, ErrorMatches, "^Quota exceeded: 4 instances requested, 3 available.$")
+	err = limits.Fits(ServerPlan{Flavor: large, Count: 2})
+	c.Assert(err, DeepEquals, &QuotaExceededError{Resource: "cores", Requested: 8, Available: 6})
+	limits.MaxTotalRAMSize = 30720
+	err = limits.Fits(ServerPlan{Flavor: small, Count: 2})
+	c.Assert(err, ErrorMatches, "^Quota exceeded: 4096 MB of RAM requested, 2048 available.$")
+}
+
+func (s *S) TestAbsoluteLimitsFitsWithoutFlavor(c *C) {
+	limits := AbsoluteLimits{MaxTotalInstances: 10, MaxTotalCores: 20, MaxTotalRAMSize: -1}
+	err := limits.Fits(ServerPlan{Flavor: &Flavor{VCPUs: 1}, Count: 1}, ServerPlan{Count: 2})
+	c.Assert(err, Equals, ErrPlanWithoutFlavor)
+}
+
+func (s *S) TestCheckQuota(c *C) {
+	testServer.PrepareResponse(200, nil, limitsBody)
+	testServer.PrepareResponse(200, nil, limitsBody)
+	client := newTestClient()
+	flavor := &Flavor{VCPUs: 2, RAM: 4096}
+	err := client.CheckQuota(ServerPlan{Flavor: flavor, Count: 3})
+	c.Assert(err, IsNil)
+	err = client.CheckQuota(ServerPlan{Flavor: flavor, Count: 4})
+	c.Assert(err, FitsTypeOf, &QuotaExceededError{})
+	c.Assert(err.(*QuotaExceededError).Resource, Equals, "instances")
+}
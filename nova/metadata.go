@@ -0,0 +1,137 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"net/http"
+	"net/url"
+)
+
+func (c *Client) serverMetadata(op, method, id string, metadata map[string]string) (map[string]string, error) {
+	var body interface{}
+	if metadata != nil {
+		body = map[string]interface{}{"metadata": metadata}
+	}
+	var result struct{ Metadata map[string]string }
+	err := c.request(op, method, "/servers/"+id+"/metadata", body, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return result.Metadata, nil
+}
+
+// GetServerMetadata returns all metadata of a server.
+func (c *Client) GetServerMetadata(id string) (map[string]string, error) {
+	return c.serverMetadata("get the metadata of the server "+id, "GET", id, nil)
+}
+
+// SetServerMetadata replaces all metadata of a server with the given
+// metadata, returning the new metadata. Keys that are not in the given map
+// are deleted.
+func (c *Client) SetServerMetadata(id string, metadata map[string]string) (map[string]string, error) {
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	return c.serverMetadata("set the metadata of the server "+id, "PUT", id, metadata)
+}
+
+// UpdateServerMetadata creates or updates the given metadata keys of a
+// server, returning all metadata of the server. Keys that are not in the given
+// map are not changed.
+//
+// Example of use:
+//
+//     metadata, err := client.UpdateServerMetadata(server.Id, map[string]string{
+//         "owner": "platform-team",
+//         "cost-center": "1234",
+//     })
+func (c *Client) UpdateServerMetadata(id string, metadata map[string]string) (map[string]string, error) {
+	return c.serverMetadata("update the metadata of the server "+id, "POST", id, metadata)
+}
+
+// GetServerMetadataItem returns the value of the given metadata key of a
+// server. If the key does not exist, it returns an Error with status 404 (see
+// IsNotFound).
+func (c *Client) GetServerMetadataItem(id, key string) (string, error) {
+	var result struct{ Meta map[string]string }
+	op := "get the metadata " + key + " of the server " + id
+	err := c.request(op, "GET", "/servers/"+id+"/metadata/"+url.PathEscape(key), nil, &result, http.StatusOK)
+	if err != nil {
+		return "", err
+	}
+	return result.Meta[key], nil
+}
+
+// SetServerMetadataItem creates or updates a single metadata key of a server.
+func (c *Client) SetServerMetadataItem(id, key, value string) error {
+	body := map[string]interface{}{"meta": map[string]string{key: value}}
+	op := "set the metadata " + key + " of the server " + id
+	return c.request(op, "PUT", "/servers/"+id+"/metadata/"+url.PathEscape(key), body, nil, http.StatusOK)
+}
+
+// DeleteServerMetadataItem deletes a metadata key of a server.
+func (c *Client) DeleteServerMetadataItem(id, key string) error {
+	op := "delete the metadata " + key + " of the server " + id
+	return c.request(op, "DELETE", "/servers/"+id+"/metadata/"+url.PathEscape(key), nil, nil, http.StatusNoContent)
+}
+
+// ListServerTags returns the tags of a server. Server tags require the
+// microversion 2.26 or newer.
+func (c *Client) ListServerTags(id string) ([]string, error) {
+	var result struct{ Tags []string }
+	err := c.request("get the tags of the server "+id, "GET", "/servers/"+id+"/tags", nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return result.Tags, nil
+}
+
+// SetServerTags replaces all tags of a server with the given tags, returning
+// the new tags.
+func (c *Client) SetServerTags(id string, tags []string) ([]string, error) {
+	if tags == nil {
+		tags = []string{}
+	}
+	var result struct{ Tags []string }
+	body := map[string]interface{}{"tags": tags}
+	err := c.request("set the tags of the server "+id, "PUT", "/servers/"+id+"/tags", body, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return result.Tags, nil
+}
+
+// DeleteServerTags deletes all tags of a server.
+func (c *Client) DeleteServerTags(id string) error {
+	return c.request("delete the tags of the server "+id, "DELETE", "/servers/"+id+"/tags", nil, nil, http.StatusNoContent)
+}
+
+// HasServerTag checks whether a server has the given tag. It returns an error
+// when the server does not exist.
+//
+// Nova responds with 404 both when the server does not have the tag and when
+// the server does not exist, so a 404 costs an additional request to tell
+// these cases apart.
+func (c *Client) HasServerTag(id, tag string) (bool, error) {
+	err := c.request("check the tag "+tag+" of the server "+id, "GET", "/servers/"+id+"/tags/"+url.PathEscape(tag), nil, nil, http.StatusNoContent)
+	if IsNotFound(err) {
+		if _, err = c.GetServer(id); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// AddServerTag adds a tag to a server. Adding a tag that the server already
+// has is not an error.
+func (c *Client) AddServerTag(id, tag string) error {
+	return c.request("add the tag "+tag+" to the server "+id, "PUT", "/servers/"+id+"/tags/"+url.PathEscape(tag), nil, nil, http.StatusCreated, http.StatusNoContent)
+}
+
+// DeleteServerTag removes a tag from a server.
+func (c *Client) DeleteServerTag(id, tag string) error {
+	return c.request("delete the tag "+tag+" of the server "+id, "DELETE", "/servers/"+id+"/tags/"+url.PathEscape(tag), nil, nil, http.StatusNoContent)
+}
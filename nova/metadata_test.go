@@ -0,0 +1,128 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	. "launchpad.net/gocheck"
+)
+
+func (s *S) TestGetServerMetadata(c *C) {
+	testServer.PrepareResponse(200, nil, `{"metadata": {"owner": "platform", "env": "prod"}}`)
+	client := newTestClient()
+	metadata, err := client.GetServerMetadata("f5dc173b")
+	c.Assert(err, IsNil)
+	c.Assert(metadata, DeepEquals, map[string]string{"owner": "platform", "env": "prod"})
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/metadata")
+}
+
+func (s *S) TestSetAndUpdateServerMetadata(c *C) {
+	testServer.PrepareResponse(200, nil, `{"metadata": {"owner": "platform"}}`)
+	testServer.PrepareResponse(200, nil, `{"metadata": {"owner": "platform", "env": "prod"}}`)
+	client := newTestClient()
+	metadata, err := client.SetServerMetadata("f5dc173b", map[string]string{"owner": "platform"})
+	c.Assert(err, IsNil)
+	c.Assert(metadata, DeepEquals, map[string]string{"owner": "platform"})
+	metadata, err = client.UpdateServerMetadata("f5dc173b", map[string]string{"env": "prod"})
+	c.Assert(err, IsNil)
+	c.Assert(metadata, HasLen, 2)
+	req, b, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.Method, Equals, "PUT")
+	c.Assert(string(b), Equals, `{"metadata":{"owner":"platform"}}`)
+	req, b, _ = testServer.WaitRequest(1e9)
+	c.Assert(req.Method, Equals, "POST")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/metadata")
+	c.Assert(string(b), Equals, `{"metadata":{"env":"prod"}}`)
+}
+
+func (s *S) TestServerMetadataItem(c *C) {
+	testServer.PrepareResponse(200, nil, `{"meta": {"owner": "platform"}}`)
+	testServer.PrepareResponse(200, nil, `{"meta": {"owner": "billing"}}`)
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	value, err := client.GetServerMetadataItem("f5dc173b", "owner")
+	c.Assert(err, IsNil)
+	c.Assert(value, Equals, "platform")
+	err = client.SetServerMetadataItem("f5dc173b", "owner", "billing")
+	c.Assert(err, IsNil)
+	err = client.DeleteServerMetadataItem("f5dc173b", "owner")
+	c.Assert(err, IsNil)
+	req, _, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/metadata/owner")
+	req, b, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.Method, Equals, "PUT")
+	c.Assert(string(b), Equals, `{"meta":{"owner":"billing"}}`)
+	req, _, _ = testServer.WaitRequest(1e9)
+	c.Assert(req.Method, Equals, "DELETE")
+}
+
+func (s *S) TestGetServerMetadataItemNotFound(c *C) {
+	testServer.PrepareResponse(404, nil, "Metadata item was not found")
+	client := newTestClient()
+	_, err := client.GetServerMetadataItem("f5dc173b", "owner")
+	c.Assert(IsNotFound(err), Equals, true)
+	c.Assert(err, ErrorMatches, "^Failed to get the metadata owner of the server f5dc173b, status: 404.\nBody: Metadata item was not found.$")
+}
+
+func (s *S) TestServerTags(c *C) {
+	testServer.PrepareResponse(200, nil, `{"tags": ["web", "prod"]}`)
+	testServer.PrepareResponse(200, nil, `{"tags": ["db"]}`)
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	tags, err := client.ListServerTags("f5dc173b")
+	c.Assert(err, IsNil)
+	c.Assert(tags, DeepEquals, []string{"web", "prod"})
+	tags, err = client.SetServerTags("f5dc173b", []string{"db"})
+	c.Assert(err, IsNil)
+	c.Assert(tags, DeepEquals, []string{"db"})
+	err = client.DeleteServerTags("f5dc173b")
+	c.Assert(err, IsNil)
+	req, _, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/tags")
+	req, b, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.Method, Equals, "PUT")
+	c.Assert(string(b), Equals, `{"tags":["db"]}`)
+	req, _, _ = testServer.WaitRequest(1e9)
+	c.Assert(req.Method, Equals, "DELETE")
+}
+
+func (s *S) TestServerTag(c *C) {
+	testServer.PrepareResponse(204, nil, "")
+	testServer.PrepareResponse(404, nil, "")
+	testServer.PrepareResponse(200, nil, `{"server": {"id": "f5dc173b"}}`)
+	testServer.PrepareResponse(201, nil, "")
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	ok, err := client.HasServerTag("f5dc173b", "web")
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	ok, err = client.HasServerTag("f5dc173b", "db")
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, false)
+	err = client.AddServerTag("f5dc173b", "db")
+	c.Assert(err, IsNil)
+	err = client.DeleteServerTag("f5dc173b", "db")
+	c.Assert(err, IsNil)
+	req, _, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/tags/web")
+	testServer.WaitRequest(1e9)
+	req, _, _ = testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b")
+	req, _, _ = testServer.WaitRequest(1e9)
+	c.Assert(req.Method, Equals, "PUT")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/tags/db")
+	req, _, _ = testServer.WaitRequest(1e9)
+	c.Assert(req.Method, Equals, "DELETE")
+}
+
+func (s *S) TestHasServerTagWithMissingServer(c *C) {
+	testServer.PrepareResponse(404, nil, "Instance f5dc173b could not be found.")
+	testServer.PrepareResponse(404, nil, "Instance f5dc173b could not be found.")
+	client := newTestClient()
+	ok, err := client.HasServerTag("f5dc173b", "web")
+	c.Assert(ok, Equals, false)
+	c.Assert(IsNotFound(err), Equals, true)
+}
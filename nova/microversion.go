@@ -0,0 +1,175 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/globocom/go-openstack/keystone"
+	"strconv"
+	"strings"
+)
+
+// LatestMicroversion can be used as the value of Client.Microversion to
+// request the maximum microversion supported by the server.
+const LatestMicroversion = "latest"
+
+// APIVersion represents a microversion of the compute API, like 2.26.
+type APIVersion struct {
+	Major int
+	Minor int
+}
+
+// ParseAPIVersion parses a microversion in the form "major.minor".
+func ParseAPIVersion(version string) (APIVersion, error) {
+	parts := strings.SplitN(version, ".", 2)
+	if len(parts) != 2 {
+		return APIVersion{}, fmt.Errorf("Invalid microversion: %q.", version)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return APIVersion{}, fmt.Errorf("Invalid microversion: %q.", version)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return APIVersion{}, fmt.Errorf("Invalid microversion: %q.", version)
+	}
+	return APIVersion{Major: major, Minor: minor}, nil
+}
+
+// String returns the microversion in the form "major.minor".
+func (v APIVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// IsZero reports whether v is the zero value, that represents the absence of
+// a microversion.
+func (v APIVersion) IsZero() bool {
+	return v.Major == 0 && v.Minor == 0
+}
+
+// LessThan reports whether v is older than other.
+func (v APIVersion) LessThan(other APIVersion) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	return v.Minor < other.Minor
+}
+
+// NegotiateMicroversion discovers the microversions supported by the server
+// and chooses the one that will be sent in all requests, according to the
+// Microversion field of the client.
+//
+// Microversions are only available in the version 2.1 of the API, whose
+// endpoint is discovered from the version document of the service (see
+// keystone.DiscoverURL), regardless of the version in the endpoint of the
+// service catalog. The document is fetched only once per client.
+//
+// It returns an error if the requested microversion is out of the range
+// supported by the server. Callers do not need to call this method directly:
+// the negotiation happens before the first request sent by the client, and
+// again whenever the Microversion field changes.
+func (c *Client) NegotiateMicroversion() (APIVersion, error) {
+	_, version, err := c.negotiate(context.Background())
+	return version, err
+}
+
+// negotiate returns the base URL and the microversion for the current value
+// of the Microversion field, negotiating them when the field has changed since
+// the last negotiation. The lock is not held while the version document is
+// fetched, so a slow server does not block other goroutines, and the given
+// context can cancel the discovery.
+func (c *Client) negotiate(ctx context.Context) (string, APIVersion, error) {
+	if c.KeystoneClient == nil {
+		return "", APIVersion{}, errors.New("KeystoneClient is nil.")
+	}
+	c.mu.Lock()
+	requested := c.Microversion
+	if c.negotiated && c.requested == requested {
+		defer c.mu.Unlock()
+		return c.baseURL, c.version, nil
+	}
+	endpoint, discovered := c.baseURL, c.discovered
+	c.mu.Unlock()
+	if discovered == nil {
+		var err error
+		endpoint, discovered, err = keystone.DiscoverURLContext(ctx, c.KeystoneClient.Endpoint("compute", "admin"), 2)
+		if err != nil {
+			return "", APIVersion{}, err
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.baseURL, c.discovered = endpoint, discovered
+	version, err := chooseMicroversion(discovered, requested)
+	if err != nil {
+		return "", APIVersion{}, err
+	}
+	c.version, c.requested, c.negotiated = version, requested, true
+	return endpoint, version, nil
+}
+
+// chooseMicroversion returns the requested microversion, checked against the
+// range supported by the server, as advertised in the discovered version. It
+// returns the zero APIVersion when no microversion is requested.
+func chooseMicroversion(discovered *keystone.Version, requested string) (APIVersion, error) {
+	if requested == "" {
+		return APIVersion{}, nil
+	}
+	if discovered.Version == "" {
+		return APIVersion{}, fmt.Errorf("Microversion %s is not supported: the server does not support microversions.", requested)
+	}
+	min, err := ParseAPIVersion(discovered.MinVersion)
+	if err != nil {
+		return APIVersion{}, err
+	}
+	max, err := ParseAPIVersion(discovered.Version)
+	if err != nil {
+		return APIVersion{}, err
+	}
+	if requested == LatestMicroversion {
+		return max, nil
+	}
+	version, err := ParseAPIVersion(requested)
+	if err != nil {
+		return APIVersion{}, err
+	}
+	if version.LessThan(min) || max.LessThan(version) {
+		return APIVersion{}, fmt.Errorf("Microversion %s is not supported: the server supports microversions from %s to %s.", version, min, max)
+	}
+	return version, nil
+}
+
+// NegotiatedMicroversion returns the microversion chosen by the last
+// negotiation. It returns the zero APIVersion when the negotiation has not
+// happened yet, or when no microversion was requested.
+func (c *Client) NegotiatedMicroversion() APIVersion {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.version
+}
+
+// SupportsMicroversion reports whether the negotiated microversion is equal
+// to or newer than the given version. It negotiates the microversion first,
+// if needed, so its result is valid even before the first request of the
+// client. It is useful for checking whether a feature is available:
+//
+//     ok, err := client.SupportsMicroversion("2.26")
+//     // handle err
+//     if ok {
+//         // server tags are available
+//     }
+func (c *Client) SupportsMicroversion(version string) (bool, error) {
+	v, err := ParseAPIVersion(version)
+	if err != nil {
+		return false, err
+	}
+	negotiated, err := c.NegotiateMicroversion()
+	if err != nil {
+		return false, err
+	}
+	return !negotiated.IsZero() && !negotiated.LessThan(v), nil
+}
@@ -0,0 +1,203 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	. "launchpad.net/gocheck"
+)
+
+const versionsBody = `{"versions": [{"status": "SUPPORTED", "updated": "2011-01-21T11:33:21Z", "links": [{"href": "http://localhost:5555/v2/", "rel": "self"}], "min_version": "", "version": "", "id": "v2.0"}, {"status": "CURRENT", "updated": "2013-07-23T11:33:21Z", "links": [{"href": "http://localhost:5555/v2.1/", "rel": "self"}], "min_version": "2.1", "version": "2.53", "id": "v2.1"}]}`
+
+func (s *S) TestParseAPIVersion(c *C) {
+	v, err := ParseAPIVersion("2.26")
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, APIVersion{Major: 2, Minor: 26})
+	c.Assert(v.String(), Equals, "2.26")
+	_, err = ParseAPIVersion("2")
+	c.Assert(err, NotNil)
+	_, err = ParseAPIVersion("2.x")
+	c.Assert(err, NotNil)
+}
+
+func (s *S) TestAPIVersionLessThan(c *C) {
+	c.Assert(APIVersion{2, 9}.LessThan(APIVersion{2, 10}), Equals, true)
+	c.Assert(APIVersion{2, 10}.LessThan(APIVersion{2, 9}), Equals, false)
+	c.Assert(APIVersion{2, 10}.LessThan(APIVersion{3, 0}), Equals, true)
+	c.Assert(APIVersion{2, 10}.LessThan(APIVersion{2, 10}), Equals, false)
+}
+
+func (s *S) TestNegotiateLatestMicroversion(c *C) {
+	client := newUndiscoveredTestClient()
+	client.Microversion = LatestMicroversion
+	testServer.PrepareResponse(300, nil, versionsBody)
+	version, err := client.NegotiateMicroversion()
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, APIVersion{2, 53})
+	c.Assert(client.NegotiatedMicroversion(), Equals, APIVersion{2, 53})
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/")
+	ok, err := client.SupportsMicroversion("2.26")
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	ok, err = client.SupportsMicroversion("2.60")
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *S) TestSupportsMicroversionNegotiates(c *C) {
+	client := newUndiscoveredTestClient()
+	client.Microversion = LatestMicroversion
+	testServer.PrepareResponse(300, nil, versionsBody)
+	ok, err := client.SupportsMicroversion("2.26")
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	c.Assert(client.NegotiatedMicroversion(), Equals, APIVersion{2, 53})
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/")
+	ok, err = client.SupportsMicroversion("2.30")
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	_, _, err = testServer.WaitRequest(1e8)
+	c.Assert(err, NotNil)
+}
+
+func (s *S) TestSupportsMicroversionNegotiationFailure(c *C) {
+	client := newUndiscoveredTestClient()
+	client.Microversion = "2.90"
+	testServer.PrepareResponse(200, nil, versionsBody)
+	_, err := client.SupportsMicroversion("2.26")
+	c.Assert(err, ErrorMatches, "^Microversion 2.90 is not supported: .*")
+}
+
+func (s *S) TestSupportsMicroversionInvalidVersion(c *C) {
+	client := newTestClient()
+	_, err := client.SupportsMicroversion("2")
+	c.Assert(err, ErrorMatches, `^Invalid microversion: "2".$`)
+}
+
+func (s *S) TestNegotiatePinnedMicroversion(c *C) {
+	client := newUndiscoveredTestClient()
+	client.Microversion = "2.26"
+	testServer.PrepareResponse(200, nil, versionsBody)
+	version, err := client.NegotiateMicroversion()
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, APIVersion{2, 26})
+}
+
+func (s *S) TestNegotiateAgainWhenMicroversionChanges(c *C) {
+	client := newUndiscoveredTestClient()
+	client.Microversion = "2.26"
+	testServer.PrepareResponse(200, nil, versionsBody)
+	version, err := client.NegotiateMicroversion()
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, APIVersion{2, 26})
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	client.Microversion = LatestMicroversion
+	version, err = client.NegotiateMicroversion()
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, APIVersion{2, 53})
+	c.Assert(client.NegotiatedMicroversion(), Equals, APIVersion{2, 53})
+	// The version document is not fetched again.
+	_, _, err = testServer.WaitRequest(1e8)
+	c.Assert(err, NotNil)
+	client.Microversion = ""
+	version, err = client.NegotiateMicroversion()
+	c.Assert(err, IsNil)
+	c.Assert(version.IsZero(), Equals, true)
+}
+
+func (s *S) TestNegotiateUnsupportedMicroversion(c *C) {
+	client := newUndiscoveredTestClient()
+	client.Microversion = "2.90"
+	testServer.PrepareResponse(200, nil, versionsBody)
+	_, err := client.NegotiateMicroversion()
+	c.Assert(err, ErrorMatches, "^Microversion 2.90 is not supported: the server supports microversions from 2.1 to 2.53.$")
+	c.Assert(client.NegotiatedMicroversion().IsZero(), Equals, true)
+}
+
+func (s *S) TestNegotiateMicroversionWithoutMicroversionsInTheServer(c *C) {
+	client := newUndiscoveredTestClient()
+	client.Microversion = "2.1"
+	testServer.PrepareResponse(200, nil, `{"versions": [{"status": "CURRENT", "min_version": "", "version": "", "id": "v2.0"}]}`)
+	_, err := client.NegotiateMicroversion()
+	c.Assert(err, ErrorMatches, "^Microversion 2.1 is not supported: the server does not support microversions.$")
+}
+
+func (s *S) TestNegotiateWithoutMicroversion(c *C) {
+	client := newTestClient()
+	version, err := client.NegotiateMicroversion()
+	c.Assert(err, IsNil)
+	c.Assert(version.IsZero(), Equals, true)
+	ok, err := client.SupportsMicroversion("2.1")
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, false)
+	_, _, err = testServer.WaitRequest(1e8)
+	c.Assert(err, NotNil)
+}
+
+func (s *S) TestMicroversionHeaderIsSentInRequests(c *C) {
+	client := newUndiscoveredTestClient()
+	client.Microversion = "2.26"
+	testServer.PrepareResponse(200, nil, versionsBody)
+	testServer.PrepareResponse(200, nil, `{"networks": []}`)
+	err := client.DisassociateNetwork("123tenant")
+	c.Assert(err, Equals, ErrNoNetwork)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-networks")
+	c.Assert(req.Header.Get("X-OpenStack-Nova-API-Version"), Equals, "2.26")
+	c.Assert(req.Header.Get("OpenStack-API-Version"), Equals, "compute 2.26")
+}
+
+func (s *S) TestMicroversionHeaderIsNotSentWithoutMicroversion(c *C) {
+	client := newTestClient()
+	testServer.PrepareResponse(200, nil, `{"networks": []}`)
+	err := client.DisassociateNetwork("123tenant")
+	c.Assert(err, Equals, ErrNoNetwork)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("X-OpenStack-Nova-API-Version"), Equals, "")
+}
+
+func (s *S) TestNegotiationRewritesTheEndpoint(c *C) {
+	client := newUndiscoveredTestClient()
+	client.KeystoneClient.Catalogs[0].Endpoints[0].URL = "http://localhost:5555/v2/123tenant"
+	client.Microversion = LatestMicroversion
+	testServer.PrepareResponse(300, nil, versionsBody)
+	testServer.PrepareResponse(200, nil, `{"networks": []}`)
+	err := client.DisassociateNetwork("123tenant")
+	c.Assert(err, Equals, ErrNoNetwork)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-networks")
+}
+
+func (s *S) TestUnversionedEndpointIsDiscoveredWithoutMicroversion(c *C) {
+	client := newUndiscoveredTestClient()
+	client.KeystoneClient.Catalogs[0].Endpoints[0].URL = "http://localhost:5555"
+	testServer.PrepareResponse(300, nil, versionsBody)
+	testServer.PrepareResponse(200, nil, `{"networks": []}`)
+	testServer.PrepareResponse(200, nil, `{"networks": []}`)
+	err := client.DisassociateNetwork("123tenant")
+	c.Assert(err, Equals, ErrNoNetwork)
+	err = client.DisassociateNetwork("123tenant")
+	c.Assert(err, Equals, ErrNoNetwork)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/")
+	for i := 0; i < 2; i++ {
+		req, _, err = testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+		c.Assert(req.URL.Path, Equals, "/v2.1/os-networks")
+		c.Assert(req.Header.Get("X-OpenStack-Nova-API-Version"), Equals, "")
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/globocom/go-openstack/internal/timeutil"
+	"github.com/globocom/go-openstack/pagination"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Migration represents a migration of a server between hosts. Type is
+// "migration" (cold migration), "live-migration", "resize" or "evacuation".
+type Migration struct {
+	Id            string
+	ServerId      string
+	Type          string
+	Status        string
+	SourceCompute string
+	SourceNode    string
+	DestCompute   string
+	DestNode      string
+	DestHost      string
+	OldFlavorId   string
+	NewFlavorId   string
+	Created       time.Time
+	Updated       time.Time
+}
+
+// UnmarshalJSON decodes a migration, accepting both numeric and string ids
+// and parsing the timestamps in nova's format.
+func (m *Migration) UnmarshalJSON(b []byte) error {
+	var migration struct {
+		Id            interface{}
+		ServerId      string `json:"instance_uuid"`
+		ServerUUID    string `json:"server_uuid"`
+		Type          string `json:"migration_type"`
+		Status        string
+		SourceCompute string      `json:"source_compute"`
+		SourceNode    string      `json:"source_node"`
+		DestCompute   string      `json:"dest_compute"`
+		DestNode      string      `json:"dest_node"`
+		DestHost      string      `json:"dest_host"`
+		OldFlavorId   interface{} `json:"old_instance_type_id"`
+		NewFlavorId   interface{} `json:"new_instance_type_id"`
+		CreatedAt     string      `json:"created_at"`
+		UpdatedAt     string      `json:"updated_at"`
+	}
+	if err := json.Unmarshal(b, &migration); err != nil {
+		return err
+	}
+	*m = Migration{
+		Id:            idString(migration.Id),
+		ServerId:      migration.ServerId,
+		Type:          migration.Type,
+		Status:        migration.Status,
+		SourceCompute: migration.SourceCompute,
+		SourceNode:    migration.SourceNode,
+		DestCompute:   migration.DestCompute,
+		DestNode:      migration.DestNode,
+		DestHost:      migration.DestHost,
+		OldFlavorId:   idString(migration.OldFlavorId),
+		NewFlavorId:   idString(migration.NewFlavorId),
+		Created:       timeutil.Parse(migration.CreatedAt),
+		Updated:       timeutil.Parse(migration.UpdatedAt),
+	}
+	if m.ServerId == "" {
+		m.ServerId = migration.ServerUUID
+	}
+	return nil
+}
+
+// ListMigrationsOpts contains the filters and pagination parameters for
+// listing migrations. Empty fields are ignored. Filtering by Type requires the
+// microversion 2.23, and Limit and Marker require the microversion 2.59.
+type ListMigrationsOpts struct {
+	Host     string
+	Status   string
+	Type     string
+	ServerId string
+	Limit    int
+	Marker   string
+}
+
+func (opts *ListMigrationsOpts) query() url.Values {
+	q := url.Values{}
+	params := map[string]string{
+		"host":           opts.Host,
+		"status":         opts.Status,
+		"migration_type": opts.Type,
+		"instance_uuid":  opts.ServerId,
+		"marker":         opts.Marker,
+	}
+	for k, v := range params {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	return q
+}
+
+func (c *Client) listMigrations(ctx context.Context, opts ListMigrationsOpts) (*pagination.Page[Migration], error) {
+	var result struct {
+		Migrations []Migration
+		Links      []pagination.Link `json:"migrations_links"`
+	}
+	path := "/os-migrations"
+	if q := opts.query().Encode(); q != "" {
+		path += "?" + q
+	}
+	err := c.requestContext(ctx, "get the list of migrations", "GET", path, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &pagination.Page[Migration]{Items: result.Migrations, Next: pagination.NextMarker(result.Links)}, nil
+}
+
+// ListMigrations returns the migrations that match the given filters,
+// following all pages of the listing. The listing starts after opts.Marker,
+// and opts.Limit is the size of each page.
+func (c *Client) ListMigrations(opts ListMigrationsOpts) ([]Migration, error) {
+	return c.MigrationPager(opts).All(context.Background())
+}
+
+// MigrationPager returns a pager over the migrations that match the given
+// filters, starting after opts.Marker. opts.Limit is the size of each page.
+func (c *Client) MigrationPager(opts ListMigrationsOpts) *pagination.Pager[Migration] {
+	return pagination.New(func(ctx context.Context, marker string) (*pagination.Page[Migration], error) {
+		if marker != "" {
+			opts.Marker = marker
+		}
+		return c.listMigrations(ctx, opts)
+	})
+}
+
+// ListServerMigrations returns the live migrations in progress of a server.
+// It requires the microversion 2.23 or newer.
+func (c *Client) ListServerMigrations(serverId string) ([]Migration, error) {
+	var result struct{ Migrations []Migration }
+	op := "get the migrations of the server " + serverId
+	err := c.request(op, "GET", "/servers/"+serverId+"/migrations", nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return result.Migrations, nil
+}
+
+// AbortLiveMigration aborts a live migration in progress, leaving the server
+// in its source host. It requires the microversion 2.24 or newer.
+func (c *Client) AbortLiveMigration(serverId, migrationId string) error {
+	op := "abort the migration " + migrationId + " of the server " + serverId
+	return c.request(op, "DELETE", "/servers/"+serverId+"/migrations/"+migrationId, nil, nil, http.StatusAccepted)
+}
+
+// ForceCompleteLiveMigration forces a live migration in progress to complete,
+// by pausing the server until its memory is copied to the destination host.
+// It requires the microversion 2.22 or newer.
+func (c *Client) ForceCompleteLiveMigration(serverId, migrationId string) error {
+	op := "force the completion of the migration " + migrationId + " of the server " + serverId
+	body := map[string]interface{}{"force_complete": nil}
+	return c.request(op, "POST", "/servers/"+serverId+"/migrations/"+migrationId+"/action", body, nil, http.StatusAccepted)
+}
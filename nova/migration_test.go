@@ -0,0 +1,81 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	. "launchpad.net/gocheck"
+	"time"
+)
+
+func (s *S) TestListMigrations(c *C) {
+	testServer.PrepareResponse(200, nil, `{"migrations": [{"created_at": "2012-10-29T13:42:02.000000", "dest_compute": "compute2", "dest_host": "1.2.3.4", "dest_node": "node2", "id": 1234, "instance_uuid": "f5dc173b", "migration_type": "live-migration", "new_instance_type_id": 2, "old_instance_type_id": 1, "source_compute": "compute1", "source_node": "node1", "status": "running", "updated_at": "2012-10-29T13:42:02.000000"}]}`)
+	client := newTestClient()
+	migrations, err := client.ListMigrations(ListMigrationsOpts{Host: "compute1", Status: "running", Type: "live-migration"})
+	c.Assert(err, IsNil)
+	created := time.Date(2012, 10, 29, 13, 42, 2, 0, time.UTC)
+	expected := []Migration{{
+		Id:            "1234",
+		ServerId:      "f5dc173b",
+		Type:          "live-migration",
+		Status:        "running",
+		SourceCompute: "compute1",
+		SourceNode:    "node1",
+		DestCompute:   "compute2",
+		DestNode:      "node2",
+		DestHost:      "1.2.3.4",
+		OldFlavorId:   "1",
+		NewFlavorId:   "2",
+		Created:       created,
+		Updated:       created,
+	}}
+	c.Assert(migrations, DeepEquals, expected)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-migrations")
+	c.Assert(req.URL.RawQuery, Equals, "host=compute1&migration_type=live-migration&status=running")
+}
+
+func (s *S) TestListMigrationsFailure(c *C) {
+	testServer.PrepareResponse(403, nil, "Policy doesn't allow it")
+	client := newTestClient()
+	_, err := client.ListMigrations(ListMigrationsOpts{})
+	c.Assert(err, ErrorMatches, "^Failed to get the list of migrations, status: 403.\nBody: Policy doesn't allow it.$")
+}
+
+func (s *S) TestListServerMigrations(c *C) {
+	testServer.PrepareResponse(200, nil, `{"migrations": [{"id": 4, "server_uuid": "f5dc173b", "source_compute": "compute1", "dest_compute": "compute2", "status": "running"}]}`)
+	client := newTestClient()
+	migrations, err := client.ListServerMigrations("f5dc173b")
+	c.Assert(err, IsNil)
+	c.Assert(migrations, HasLen, 1)
+	c.Assert(migrations[0].Id, Equals, "4")
+	c.Assert(migrations[0].ServerId, Equals, "f5dc173b")
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/migrations")
+}
+
+func (s *S) TestAbortLiveMigration(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.AbortLiveMigration("f5dc173b", "4")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/migrations/4")
+}
+
+func (s *S) TestForceCompleteLiveMigration(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.ForceCompleteLiveMigration("f5dc173b", "4")
+	c.Assert(err, IsNil)
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "POST")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/migrations/4/action")
+	c.Assert(string(b), Equals, `{"force_complete":null}`)
+}
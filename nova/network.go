@@ -0,0 +1,201 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Error returned by DisassociateNetwork when no network is found for the
+// tenant.
+var ErrNoNetwork = errors.New("Network not found: no network was found for this tenant.")
+
+// NetworkDisassociator interface provides the method DisassociateNetwork, that
+// is used to disassociate a network from a tenant.
+type NetworkDisassociator interface {
+	// Disassociates a network from the given tenant.
+	DisassociateNetwork(tenantId string) error
+}
+
+// Network represents a nova-network network.
+//
+// TenantId is the tenant the network is associated with, and Host is the host
+// the network is associated with. Both are empty for networks that are not
+// associated.
+type Network struct {
+	Id                string
+	Label             string
+	CIDR              string `json:"cidr"`
+	CIDRv6            string `json:"cidr_v6"`
+	Netmask           string
+	NetmaskV6         string `json:"netmask_v6"`
+	Gateway           string
+	GatewayV6         string `json:"gateway_v6"`
+	Broadcast         string
+	DHCPStart         string `json:"dhcp_start"`
+	DNS1              string `json:"dns1"`
+	DNS2              string `json:"dns2"`
+	Bridge            string
+	BridgeInterface   string `json:"bridge_interface"`
+	VLAN              int    `json:"vlan"`
+	MultiHost         bool   `json:"multi_host"`
+	Injected          bool
+	VPNPublicAddress  string `json:"vpn_public_address"`
+	VPNPublicPort     int    `json:"vpn_public_port"`
+	VPNPrivateAddress string `json:"vpn_private_address"`
+	Host              string
+	TenantId          string `json:"project_id"`
+}
+
+// NetworkOpts contains the attributes of a new network. Label and CIDR are
+// required, all other fields are optional.
+type NetworkOpts struct {
+	Label           string
+	CIDR            string
+	CIDRv6          string
+	Gateway         string
+	GatewayV6       string
+	DHCPStart       string
+	DNS1            string
+	DNS2            string
+	Bridge          string
+	BridgeInterface string
+	VLAN            int
+	MultiHost       bool
+	TenantId        string
+}
+
+func (opts *NetworkOpts) toMap() map[string]interface{} {
+	network := map[string]interface{}{
+		"label": opts.Label,
+		"cidr":  opts.CIDR,
+	}
+	params := map[string]string{
+		"cidr_v6":          opts.CIDRv6,
+		"gateway":          opts.Gateway,
+		"gateway_v6":       opts.GatewayV6,
+		"dhcp_start":       opts.DHCPStart,
+		"dns1":             opts.DNS1,
+		"dns2":             opts.DNS2,
+		"bridge":           opts.Bridge,
+		"bridge_interface": opts.BridgeInterface,
+		"project_id":       opts.TenantId,
+	}
+	for k, v := range params {
+		if v != "" {
+			network[k] = v
+		}
+	}
+	if opts.VLAN > 0 {
+		network["vlan"] = opts.VLAN
+	}
+	if opts.MultiHost {
+		network["multi_host"] = true
+	}
+	return network
+}
+
+// ListNetworks returns all networks.
+func (c *Client) ListNetworks() ([]Network, error) {
+	var result struct{ Networks []Network }
+	err := c.request("get the list of all networks", "GET", "/os-networks", nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return result.Networks, nil
+}
+
+// GetNetwork returns the network with the given id.
+func (c *Client) GetNetwork(id string) (*Network, error) {
+	var result struct{ Network Network }
+	err := c.request("get the network "+id, "GET", "/os-networks/"+id, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Network, nil
+}
+
+// CreateNetwork creates a new network.
+func (c *Client) CreateNetwork(opts NetworkOpts) (*Network, error) {
+	var result struct{ Network Network }
+	body := map[string]interface{}{"network": opts.toMap()}
+	err := c.request("create the network "+opts.Label, "POST", "/os-networks", body, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Network, nil
+}
+
+// DeleteNetwork deletes a network. The network must not be associated with
+// any tenant.
+func (c *Client) DeleteNetwork(id string) error {
+	return c.request("delete the network "+id, "DELETE", "/os-networks/"+id, nil, nil, http.StatusAccepted)
+}
+
+func (c *Client) networkAction(op, id string, body interface{}) error {
+	return c.request(op, "POST", "/os-networks/"+id+"/action", body, nil, http.StatusAccepted)
+}
+
+// AssociateNetworkHost associates a network with a host.
+func (c *Client) AssociateNetworkHost(id, host string) error {
+	body := map[string]string{"associate_host": host}
+	return c.networkAction("associate the network "+id+" with the host "+host, id, body)
+}
+
+// AssociateNetworkTenant associates a network with the tenant of the client
+// (the tenant of the keystone token). When id is empty, nova associates any
+// available network with the tenant.
+func (c *Client) AssociateNetworkTenant(id string) error {
+	var networkId interface{}
+	if id != "" {
+		networkId = id
+	}
+	body := map[string]interface{}{"id": networkId}
+	return c.request("associate the network "+id+" with the tenant", "POST", "/os-networks/add", body, nil, http.StatusAccepted)
+}
+
+// DisassociateNetworkById disassociates the network with the given id from
+// its tenant and host. It is useful when a tenant has more than one network
+// (see DisassociateNetwork).
+func (c *Client) DisassociateNetworkById(id string) error {
+	return c.networkAction("disassociate the network "+id, id, map[string]interface{}{"disassociate": nil})
+}
+
+// DisassociateNetworkHost disassociates a network from its host, keeping its
+// tenant.
+func (c *Client) DisassociateNetworkHost(id string) error {
+	return c.networkAction("disassociate the network "+id+" from its host", id, map[string]interface{}{"disassociate_host": nil})
+}
+
+// DisassociateNetworkTenant disassociates a network from its tenant, keeping
+// its host.
+func (c *Client) DisassociateNetworkTenant(id string) error {
+	return c.networkAction("disassociate the network "+id+" from its tenant", id, map[string]interface{}{"disassociate_project": nil})
+}
+
+// DisassociateNetwork disassociates a network from the given tenant, returning
+// an error in case of any failure.
+//
+// If the tenant has more than one network, only the first one is
+// disassociated. Use DisassociateNetworkById to disassociate a specific
+// network.
+func (c *Client) DisassociateNetwork(tenantId string) error {
+	networks, err := c.ListNetworks()
+	if err != nil {
+		return err
+	}
+	var netId string
+	for _, net := range networks {
+		if net.TenantId == tenantId {
+			netId = net.Id
+			break
+		}
+	}
+	if netId == "" {
+		return ErrNoNetwork
+	}
+	return c.networkAction("disassociate the network "+netId+" from the tenant "+tenantId, netId, map[string]interface{}{"disassociate": nil})
+}
@@ -0,0 +1,137 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	. "launchpad.net/gocheck"
+)
+
+const networkBody = `{"network": {"bridge": "br1808", "vpn_public_port": 1000, "dhcp_start": "172.25.8.3", "bridge_interface": "eth1", "updated_at": "2012-05-12 02:16:48", "id": "ef0aa0c4-48d8-4d9e-903a-61486cd60805", "cidr_v6": null, "deleted_at": null, "gateway": "172.25.8.1", "label": "private_0", "project_id": "123tenant", "vpn_private_address": "172.25.8.2", "deleted": false, "vlan": 1808, "broadcast": "172.25.8.255", "netmask": "255.255.255.0", "injected": false, "cidr": "172.25.8.0/24", "vpn_public_address": "10.170.0.14", "multi_host": true, "dns1": null, "host": null, "gateway_v6": null, "netmask_v6": null, "created_at": "2012-05-12 02:13:17"}}`
+
+func (s *S) TestGetNetwork(c *C) {
+	testServer.PrepareResponse(200, nil, networkBody)
+	client := newTestClient()
+	network, err := client.GetNetwork("ef0aa0c4-48d8-4d9e-903a-61486cd60805")
+	c.Assert(err, IsNil)
+	expected := &Network{
+		Id:                "ef0aa0c4-48d8-4d9e-903a-61486cd60805",
+		Label:             "private_0",
+		CIDR:              "172.25.8.0/24",
+		Netmask:           "255.255.255.0",
+		Gateway:           "172.25.8.1",
+		Broadcast:         "172.25.8.255",
+		DHCPStart:         "172.25.8.3",
+		Bridge:            "br1808",
+		BridgeInterface:   "eth1",
+		VLAN:              1808,
+		MultiHost:         true,
+		VPNPublicAddress:  "10.170.0.14",
+		VPNPublicPort:     1000,
+		VPNPrivateAddress: "172.25.8.2",
+		TenantId:          "123tenant",
+	}
+	c.Assert(network, DeepEquals, expected)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-networks/ef0aa0c4-48d8-4d9e-903a-61486cd60805")
+}
+
+func (s *S) TestListNetworks(c *C) {
+	testServer.PrepareResponse(200, nil, `{"networks": [{"id": "1", "label": "private_0", "cidr": "172.25.8.0/24", "project_id": "123tenant"}, {"id": "2", "label": "private_1", "cidr": "172.25.9.0/24", "project_id": null}]}`)
+	client := newTestClient()
+	networks, err := client.ListNetworks()
+	c.Assert(err, IsNil)
+	c.Assert(networks, HasLen, 2)
+	c.Assert(networks[0].CIDR, Equals, "172.25.8.0/24")
+	c.Assert(networks[1].TenantId, Equals, "")
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-networks")
+}
+
+func (s *S) TestListNetworksFailure(c *C) {
+	testServer.PrepareResponse(500, nil, "Internal error")
+	client := newTestClient()
+	_, err := client.ListNetworks()
+	c.Assert(err, ErrorMatches, "^Failed to get the list of all networks, status: 500.\nBody: Internal error.$")
+}
+
+func (s *S) TestCreateNetwork(c *C) {
+	testServer.PrepareResponse(200, nil, networkBody)
+	client := newTestClient()
+	network, err := client.CreateNetwork(NetworkOpts{Label: "private_0", CIDR: "172.25.8.0/24", VLAN: 1808, MultiHost: true, TenantId: "123tenant"})
+	c.Assert(err, IsNil)
+	c.Assert(network.Id, Equals, "ef0aa0c4-48d8-4d9e-903a-61486cd60805")
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "POST")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-networks")
+	c.Assert(string(b), Equals, `{"network":{"cidr":"172.25.8.0/24","label":"private_0","multi_host":true,"project_id":"123tenant","vlan":1808}}`)
+}
+
+func (s *S) TestDeleteNetwork(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.DeleteNetwork("ef0aa0c4")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-networks/ef0aa0c4")
+}
+
+func (s *S) TestAssociateNetworkHost(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.AssociateNetworkHost("ef0aa0c4", "compute1")
+	c.Assert(err, IsNil)
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-networks/ef0aa0c4/action")
+	c.Assert(string(b), Equals, `{"associate_host":"compute1"}`)
+}
+
+func (s *S) TestAssociateNetworkTenant(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.AssociateNetworkTenant("ef0aa0c4")
+	c.Assert(err, IsNil)
+	err = client.AssociateNetworkTenant("")
+	c.Assert(err, IsNil)
+	req, b, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-networks/add")
+	c.Assert(string(b), Equals, `{"id":"ef0aa0c4"}`)
+	_, b, _ = testServer.WaitRequest(1e9)
+	c.Assert(string(b), Equals, `{"id":null}`)
+}
+
+func (s *S) TestDisassociateNetworkVariants(c *C) {
+	client := newTestClient()
+	var tests = []struct {
+		disassociate func(string) error
+		body         string
+	}{
+		{client.DisassociateNetworkById, `{"disassociate":null}`},
+		{client.DisassociateNetworkHost, `{"disassociate_host":null}`},
+		{client.DisassociateNetworkTenant, `{"disassociate_project":null}`},
+	}
+	for _, t := range tests {
+		testServer.PrepareResponse(202, nil, "")
+		err := t.disassociate("ef0aa0c5")
+		c.Assert(err, IsNil)
+		req, b, err := testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+		c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-networks/ef0aa0c5/action")
+		c.Assert(string(b), Equals, t.body)
+	}
+}
+
+func (s *S) TestDisassociateNetworkByIdFailure(c *C) {
+	testServer.PrepareResponse(404, nil, "Network could not be found")
+	client := newTestClient()
+	err := client.DisassociateNetworkById("ef0aa0c5")
+	c.Assert(IsNotFound(err), Equals, true)
+}
@@ -7,97 +7,157 @@
 package nova
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/globocom/go-openstack/keystone"
+	"io"
 	"io/ioutil"
 	"net/http"
-	"strings"
+	"strconv"
+	"sync"
 )
 
-// Error returned by DisassociateNetwork when no network is found for the
-// tenant.
-var ErrNoNetwork = errors.New("Network not found: no network was found for this tenant.")
-
-// NetworkDisassociator interface provides the method DisassociateNetwork, that
-// is used to disassociate a network from a tenant.
-type NetworkDisassociator interface {
-	// Disassociates a network from the given tenant.
-	DisassociateNetwork(tenantId string) error
-}
-
-type network struct {
-	Id       string
-	TenantId string `json:"project_id"`
-}
-
 // Client represents a client for the Nova OS API. It encapsulates a
 // keystone.Client instance that provides the token and endpoints used by this
 // client.
+//
+// The client sends its requests to the version 2.1 of the API, discovered from
+// the version document of the service, so the endpoint in the service catalog
+// may or may not include a version. The zero value of the Microversion field
+// makes the client use the base 2.1 semantics of the API. Set it to a specific
+// microversion, or to LatestMicroversion, to make the client negotiate the
+// microversion with the server (see NegotiateMicroversion).
+//
+// The microversion is negotiated before the first request, and negotiated
+// again before the next request whenever the Microversion field changes. The
+// field must not be changed while other goroutines are using the client.
 type Client struct {
 	KeystoneClient *keystone.Client
+
+	// Microversion is the microversion requested by the client, like "2.26"
+	// or "latest".
+	Microversion string
+
+	mu         sync.Mutex
+	negotiated bool
+	requested  string
+	version    APIVersion
+	baseURL    string
+	discovered *keystone.Version
 }
 
-func (c *Client) do(req *http.Request) ([]byte, int, error) {
+// do sends the request with the given microversion, returning the body and
+// the response. The body of the response is already closed.
+func (c *Client) do(req *http.Request, version APIVersion) ([]byte, *http.Response, error) {
+	if !version.IsZero() {
+		req.Header.Set("X-OpenStack-Nova-API-Version", version.String())
+		req.Header.Set("OpenStack-API-Version", "compute "+version.String())
+	}
 	req.Header.Set("X-Auth-Token", c.KeystoneClient.Token)
 	if req.Body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, 0, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 	b, err := ioutil.ReadAll(resp.Body)
-	return b, resp.StatusCode, err
+	return b, resp, err
 }
 
-// DisassociateNetwork disassociates a network from the given tenant, returning
-// an error in case of any failure.
-func (c *Client) DisassociateNetwork(tenantId string) error {
-	if c.KeystoneClient == nil {
-		return errors.New("KeystoneClient is nil.")
-	}
-	endpoint := c.KeystoneClient.Endpoint("compute", "admin")
-	req, err := http.NewRequest("GET", endpoint+"/os-networks", nil)
-	if err != nil {
-		return err
-	}
-	body, status, err := c.do(req)
-	if err != nil {
-		return errors.New("Failed to get the list of all networks: " + err.Error())
-	}
-	if status != http.StatusOK {
-		return fmt.Errorf("Failed to get the list of all networks, status: %d.\nBody: %s.", status, body)
+// Error is returned when the compute API responds a request with an
+// unexpected status.
+type Error struct {
+	// Op describes the operation that failed, like "get the server 123".
+	Op string
+
+	StatusCode int
+	Body       string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("Failed to %s, status: %d.\nBody: %s.", e.Op, e.StatusCode, e.Body)
+}
+
+// IsNotFound reports whether err is an Error with the status 404.
+func IsNotFound(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// idString converts an id decoded from JSON to string. Resources backed by
+// nova-network have numeric ids, while resources backed by neutron have UUIDs,
+// so ids are decoded into interface{} values and converted with idString.
+func idString(id interface{}) string {
+	switch v := id.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
 	}
-	var result map[string][]network
-	err = json.Unmarshal(body, &result)
+	return ""
+}
+
+// request sends a request to the given path of the compute API, returning an
+// Error if the status of the response is not one of the expected statuses.
+//
+// The in parameter, when not nil, is encoded as JSON and sent as the body of
+// the request. The body of the response is decoded into out, when it is not
+// nil. The op parameter describes the operation and is used in error
+// messages.
+func (c *Client) request(op, method, path string, in, out interface{}, expected ...int) error {
+	return c.requestContext(context.Background(), op, method, path, in, out, expected...)
+}
+
+// requestContext works like request, but aborts the request when the given
+// context is done.
+func (c *Client) requestContext(ctx context.Context, op, method, path string, in, out interface{}, expected ...int) error {
+	_, err := c.requestHeaderContext(ctx, op, method, path, in, out, expected...)
+	return err
+}
+
+// requestHeader works like request, but also returns the headers of the
+// response.
+func (c *Client) requestHeader(op, method, path string, in, out interface{}, expected ...int) (http.Header, error) {
+	return c.requestHeaderContext(context.Background(), op, method, path, in, out, expected...)
+}
+
+func (c *Client) requestHeaderContext(ctx context.Context, op, method, path string, in, out interface{}, expected ...int) (http.Header, error) {
+	endpoint, version, err := c.negotiate(ctx)
 	if err != nil {
-		return errors.New("Failed to get the list of all networks, the server did not respond a valid JSON.")
+		return nil, err
 	}
-	var netId string
-	for _, net := range result["networks"] {
-		if net.TenantId == tenantId {
-			netId = net.Id
-			break
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
 		}
+		body = bytes.NewReader(b)
 	}
-	if netId == "" {
-		return ErrNoNetwork
+	req, err := http.NewRequestWithContext(ctx, method, endpoint+path, body)
+	if err != nil {
+		return nil, err
 	}
-	reqBody := strings.NewReader(`{"disassociate":null}`)
-	req, err = http.NewRequest("POST", endpoint+"/os-networks/"+netId+"/action", reqBody)
 	req.Header.Set("Accept", "application/json")
+	result, resp, err := c.do(req, version)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("Failed to %s: %s", op, err)
 	}
-	body, status, err = c.do(req)
-	if err != nil {
-		return fmt.Errorf("Failed to disassociate the network %s from the tenant %s: %s", netId, tenantId, err)
+	ok := false
+	for _, e := range expected {
+		ok = ok || resp.StatusCode == e
 	}
-	if status != http.StatusAccepted {
-		return fmt.Errorf("Failed to disassociate the network %s from the tenant %s, status: %d.\nBody: %s", netId, tenantId, status, body)
+	if !ok {
+		return nil, &Error{Op: op, StatusCode: resp.StatusCode, Body: string(result)}
+	}
+	if out != nil && len(result) > 0 {
+		if err = json.Unmarshal(result, out); err != nil {
+			return nil, fmt.Errorf("Failed to %s, the server did not respond a valid JSON.", op)
+		}
 	}
-	return nil
+	return resp.Header, nil
 }
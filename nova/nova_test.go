@@ -29,6 +29,40 @@ func (s *S) TearDownTest(c *C) {
 	testServer.FlushRequests()
 }
 
+// legacyVersionsBody is the version document of a server that only provides
+// the version 2.0 of the API, without microversions.
+const legacyVersionsBody = `{"versions": [{"status": "CURRENT", "links": [{"href": "http://localhost:5555/v2/", "rel": "self"}], "min_version": "", "version": "", "id": "v2.0"}]}`
+
+// newTestClient returns a client that sends its requests to the test server.
+// The version of the API is already discovered, as if the server had
+// advertised the microversions in versionsBody, so tests only prepare the
+// responses of the requests they check.
+func newTestClient() *Client {
+	kclient := keystone.Client{
+		Token: "123token",
+		Catalogs: []keystone.ServiceCatalog{
+			{
+				Name: "Compute Service",
+				Type: "compute",
+				Endpoints: []keystone.Endpoint{
+					{Interface: "admin", URL: "http://localhost:5555/v2.1/123tenant"},
+				},
+			},
+		},
+	}
+	return &Client{
+		KeystoneClient: &kclient,
+		baseURL:        "http://localhost:5555/v2.1/123tenant",
+		discovered:     &keystone.Version{Id: "v2.1", Status: "CURRENT", Version: "2.53", MinVersion: "2.1"},
+	}
+}
+
+// newUndiscoveredTestClient works like newTestClient, but the returned client
+// discovers the version of the API in its first request.
+func newUndiscoveredTestClient() *Client {
+	return &Client{KeystoneClient: newTestClient().KeystoneClient}
+}
+
 func (s *S) TestDisassociateNetwork(c *C) {
 	kclient := keystone.Client{
 		Token: "123token",
@@ -36,20 +70,21 @@ func (s *S) TestDisassociateNetwork(c *C) {
 			{
 				Name: "Compute Service",
 				Type: "compute",
-				Endpoints: []map[string]string{
-					{
-						"adminURL": "http://localhost:5555/v2/123tenant",
-					},
+				Endpoints: []keystone.Endpoint{
+					{Interface: "admin", URL: "http://localhost:5555/v2/123tenant"},
 				},
 			},
 		},
 	}
 	body := `{"networks": [{"bridge": "br1808", "vpn_public_port": 1000, "dhcp_start": "172.25.8.3", "bridge_interface": "eth1", "updated_at": "2012-05-12 02:16:48", "id": "ef0aa0c4-48d8-4d9e-903a-61486cd60805", "cidr_v6": null, "deleted_at": null, "gateway": "172.25.8.1", "label": "private_0", "project_id": "123tenant", "vpn_private_address": "172.25.8.2", "deleted": false, "vlan": 1808, "broadcast": "172.25.8.255", "netmask": "255.255.255.0", "injected": false, "cidr": "172.25.8.0/24", "vpn_public_address": "10.170.0.14", "multi_host": true, "dns1": null, "host": null, "gateway_v6": null, "netmask_v6": null, "created_at": "2012-05-12 02:13:17"}, {"bridge": "br1808", "vpn_public_port": 1000, "dhcp_start": "172.25.8.3", "bridge_interface": "eth1", "updated_at": "2012-05-12 02:16:48", "id": "ef0aa0c5-48d8-4d9e-903a-61486cd60805", "cidr_v6": null, "deleted_at": null, "gateway": "172.25.8.1", "label": "private_0", "project_id": "1234tenant", "vpn_private_address": "172.25.8.2", "deleted": false, "vlan": 1808, "broadcast": "172.25.8.255", "netmask": "255.255.255.0", "injected": false, "cidr": "172.25.8.0/24", "vpn_public_address": "10.170.0.14", "multi_host": true, "dns1": null, "host": null, "gateway_v6": null, "netmask_v6": null, "created_at": "2012-05-12 02:13:17"}]}`
+	testServer.PrepareResponse(300, nil, legacyVersionsBody)                                     // Version document
 	testServer.PrepareResponse(200, map[string]string{"Content-Type": "application/json"}, body) // List networks
 	testServer.PrepareResponse(202, nil, "")                                                     // Disassociate network
 	client := Client{KeystoneClient: &kclient}
 	err := client.DisassociateNetwork("123tenant")
 	c.Assert(err, IsNil)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
 	listreq, _, err := testServer.WaitRequest(1e9)
 	if err != nil {
 		c.Error("Did not send the request to retrieve the list of networks after 1 second.")
@@ -77,15 +112,14 @@ func (s *S) TestDisassociateNetworkForTenantWithoutNetwork(c *C) {
 			{
 				Name: "Compute Service",
 				Type: "compute",
-				Endpoints: []map[string]string{
-					{
-						"adminURL": "http://localhost:5555/v2/123tenant",
-					},
+				Endpoints: []keystone.Endpoint{
+					{Interface: "admin", URL: "http://localhost:5555/v2/123tenant"},
 				},
 			},
 		},
 	}
 	body := `{"networks": [{"bridge": "br1808", "vpn_public_port": 1000, "dhcp_start": "172.25.8.3", "bridge_interface": "eth1", "updated_at": "2012-05-12 02:16:48", "id": "ef0aa0c4-48d8-4d9e-903a-61486cd60805", "cidr_v6": null, "deleted_at": null, "gateway": "172.25.8.1", "label": "private_0", "project_id": "123tenant", "vpn_private_address": "172.25.8.2", "deleted": false, "vlan": 1808, "broadcast": "172.25.8.255", "netmask": "255.255.255.0", "injected": false, "cidr": "172.25.8.0/24", "vpn_public_address": "10.170.0.14", "multi_host": true, "dns1": null, "host": null, "gateway_v6": null, "netmask_v6": null, "created_at": "2012-05-12 02:13:17"}, {"bridge": "br1808", "vpn_public_port": 1000, "dhcp_start": "172.25.8.3", "bridge_interface": "eth1", "updated_at": "2012-05-12 02:16:48", "id": "ef0aa0c5-48d8-4d9e-903a-61486cd60805", "cidr_v6": null, "deleted_at": null, "gateway": "172.25.8.1", "label": "private_0", "project_id": "1234tenant", "vpn_private_address": "172.25.8.2", "deleted": false, "vlan": 1808, "broadcast": "172.25.8.255", "netmask": "255.255.255.0", "injected": false, "cidr": "172.25.8.0/24", "vpn_public_address": "10.170.0.14", "multi_host": true, "dns1": null, "host": null, "gateway_v6": null, "netmask_v6": null, "created_at": "2012-05-12 02:13:17"}]}`
+	testServer.PrepareResponse(300, nil, legacyVersionsBody)                                     // Version document
 	testServer.PrepareResponse(200, map[string]string{"Content-Type": "application/json"}, body) // List networks
 	client := Client{KeystoneClient: &kclient}
 	err := client.DisassociateNetwork("123tenantsojfdkw")
@@ -0,0 +1,214 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"net/http"
+)
+
+// QuotaSet represents the compute quotas of a tenant. A value of -1 means
+// unlimited.
+//
+// FixedIPs, FloatingIPs, SecurityGroups, SecurityGroupRules and Networks are
+// proxy quotas that nova removed in the microversion 2.36, and InjectedFiles,
+// InjectedFileContentBytes and InjectedFilePathBytes were removed along with
+// personality files in the microversion 2.57.
+type QuotaSet struct {
+	TenantId                 string `json:"id"`
+	Cores                    int
+	Instances                int
+	RAM                      int `json:"ram"`
+	KeyPairs                 int `json:"key_pairs"`
+	MetadataItems            int `json:"metadata_items"`
+	InjectedFiles            int `json:"injected_files"`
+	InjectedFileContentBytes int `json:"injected_file_content_bytes"`
+	InjectedFilePathBytes    int `json:"injected_file_path_bytes"`
+	ServerGroups             int `json:"server_groups"`
+	ServerGroupMembers       int `json:"server_group_members"`
+	FixedIPs                 int `json:"fixed_ips"`
+	FloatingIPs              int `json:"floating_ips"`
+	SecurityGroups           int `json:"security_groups"`
+	SecurityGroupRules       int `json:"security_group_rules"`
+	Networks                 int
+}
+
+// UpdateQuotasOpts contains the quotas changed by UpdateQuotas. Only the
+// fields that are not nil are sent, so the other quotas of the tenant keep
+// their values. Use the Quota function to fill the fields.
+//
+// UpdateQuotas does not send the proxy quotas when the negotiated
+// microversion is 2.36 or newer, nor the injected file quotas when it is 2.57
+// or newer (see QuotaSet).
+type UpdateQuotasOpts struct {
+	Cores                    *int
+	Instances                *int
+	RAM                      *int
+	KeyPairs                 *int
+	MetadataItems            *int
+	InjectedFiles            *int
+	InjectedFileContentBytes *int
+	InjectedFilePathBytes    *int
+	ServerGroups             *int
+	ServerGroupMembers       *int
+	FixedIPs                 *int
+	FloatingIPs              *int
+	SecurityGroups           *int
+	SecurityGroupRules       *int
+	Networks                 *int
+}
+
+// Quota returns a pointer to the given value, for use in UpdateQuotasOpts.
+func Quota(value int) *int {
+	return &value
+}
+
+func (opts *UpdateQuotasOpts) toMap(proxies, files bool) map[string]int {
+	params := map[string]*int{
+		"cores":                opts.Cores,
+		"instances":            opts.Instances,
+		"ram":                  opts.RAM,
+		"key_pairs":            opts.KeyPairs,
+		"metadata_items":       opts.MetadataItems,
+		"server_groups":        opts.ServerGroups,
+		"server_group_members": opts.ServerGroupMembers,
+	}
+	if proxies {
+		params["fixed_ips"] = opts.FixedIPs
+		params["floating_ips"] = opts.FloatingIPs
+		params["security_groups"] = opts.SecurityGroups
+		params["security_group_rules"] = opts.SecurityGroupRules
+		params["networks"] = opts.Networks
+	}
+	if files {
+		params["injected_files"] = opts.InjectedFiles
+		params["injected_file_content_bytes"] = opts.InjectedFileContentBytes
+		params["injected_file_path_bytes"] = opts.InjectedFilePathBytes
+	}
+	quotas := map[string]int{}
+	for k, v := range params {
+		if v != nil {
+			quotas[k] = *v
+		}
+	}
+	return quotas
+}
+
+// QuotaUsage represents the consumption of a quota: the limit, the amount in
+// use and the amount reserved by operations in progress.
+type QuotaUsage struct {
+	Limit    int
+	InUse    int `json:"in_use"`
+	Reserved int
+}
+
+// Available returns how much of the quota is still available, or -1 when the
+// quota is unlimited.
+func (u QuotaUsage) Available() int {
+	if u.Limit < 0 {
+		return -1
+	}
+	if available := u.Limit - u.InUse - u.Reserved; available > 0 {
+		return available
+	}
+	return 0
+}
+
+// QuotaDetail represents the quotas of a tenant along with their usage.
+type QuotaDetail struct {
+	TenantId                 string `json:"id"`
+	Cores                    QuotaUsage
+	Instances                QuotaUsage
+	RAM                      QuotaUsage `json:"ram"`
+	KeyPairs                 QuotaUsage `json:"key_pairs"`
+	MetadataItems            QuotaUsage `json:"metadata_items"`
+	InjectedFiles            QuotaUsage `json:"injected_files"`
+	InjectedFileContentBytes QuotaUsage `json:"injected_file_content_bytes"`
+	InjectedFilePathBytes    QuotaUsage `json:"injected_file_path_bytes"`
+	ServerGroups             QuotaUsage `json:"server_groups"`
+	ServerGroupMembers       QuotaUsage `json:"server_group_members"`
+	FixedIPs                 QuotaUsage `json:"fixed_ips"`
+	FloatingIPs              QuotaUsage `json:"floating_ips"`
+	SecurityGroups           QuotaUsage `json:"security_groups"`
+	SecurityGroupRules       QuotaUsage `json:"security_group_rules"`
+	Networks                 QuotaUsage
+}
+
+func (c *Client) getQuotas(op, path string, out interface{}) error {
+	var result struct {
+		QuotaSet interface{} `json:"quota_set"`
+	}
+	result.QuotaSet = out
+	return c.request(op, "GET", path, nil, &result, http.StatusOK)
+}
+
+// GetQuotas returns the compute quotas of the given tenant.
+func (c *Client) GetQuotas(tenantId string) (*QuotaSet, error) {
+	var quotas QuotaSet
+	err := c.getQuotas("get the quotas of the tenant "+tenantId, "/os-quota-sets/"+tenantId, &quotas)
+	if err != nil {
+		return nil, err
+	}
+	return &quotas, nil
+}
+
+// GetDefaultQuotas returns the default compute quotas, that apply to tenants
+// without custom quotas.
+func (c *Client) GetDefaultQuotas(tenantId string) (*QuotaSet, error) {
+	var quotas QuotaSet
+	err := c.getQuotas("get the default quotas", "/os-quota-sets/"+tenantId+"/defaults", &quotas)
+	if err != nil {
+		return nil, err
+	}
+	return &quotas, nil
+}
+
+// GetQuotaDetail returns the compute quotas of the given tenant, along with
+// how much of each quota is in use.
+func (c *Client) GetQuotaDetail(tenantId string) (*QuotaDetail, error) {
+	var detail QuotaDetail
+	err := c.getQuotas("get the quota usage of the tenant "+tenantId, "/os-quota-sets/"+tenantId+"/detail", &detail)
+	if err != nil {
+		return nil, err
+	}
+	return &detail, nil
+}
+
+// UpdateQuotas changes the compute quotas of the given tenant, returning the
+// updated quotas. Only the quotas set in opts are changed.
+//
+// Example of use (right after creating a tenant with keystone):
+//
+//     tenant, err := keystoneClient.NewTenant("gopher", "Gopher's tenant", true)
+//     // handle err
+//     quotas, err := novaClient.UpdateQuotas(tenant.Id, nova.UpdateQuotasOpts{
+//         Instances: nova.Quota(20),
+//         Cores:     nova.Quota(40),
+//     })
+func (c *Client) UpdateQuotas(tenantId string, opts UpdateQuotasOpts) (*QuotaSet, error) {
+	withoutProxies, err := c.SupportsMicroversion("2.36")
+	if err != nil {
+		return nil, err
+	}
+	withoutFiles, err := c.SupportsMicroversion("2.57")
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{"quota_set": opts.toMap(!withoutProxies, !withoutFiles)}
+	var result struct {
+		QuotaSet QuotaSet `json:"quota_set"`
+	}
+	err = c.request("update the quotas of the tenant "+tenantId, "PUT", "/os-quota-sets/"+tenantId, body, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	result.QuotaSet.TenantId = tenantId
+	return &result.QuotaSet, nil
+}
+
+// DeleteQuotas reverts the compute quotas of the given tenant to the default
+// quotas.
+func (c *Client) DeleteQuotas(tenantId string) error {
+	return c.request("delete the quotas of the tenant "+tenantId, "DELETE", "/os-quota-sets/"+tenantId, nil, nil, http.StatusAccepted)
+}
@@ -0,0 +1,149 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	. "launchpad.net/gocheck"
+	"strings"
+)
+
+const quotaSetBody = `{"quota_set": {"id": "123tenant", "cores": 20, "fixed_ips": -1, "floating_ips": 10, "injected_file_content_bytes": 10240, "injected_file_path_bytes": 255, "injected_files": 5, "instances": 10, "key_pairs": 100, "metadata_items": 128, "networks": 3, "ram": 51200, "security_group_rules": 20, "security_groups": 10, "server_group_members": 10, "server_groups": 10}}`
+
+var quotaSet = QuotaSet{
+	TenantId:                 "123tenant",
+	Cores:                    20,
+	Instances:                10,
+	RAM:                      51200,
+	KeyPairs:                 100,
+	MetadataItems:            128,
+	InjectedFiles:            5,
+	InjectedFileContentBytes: 10240,
+	InjectedFilePathBytes:    255,
+	ServerGroups:             10,
+	ServerGroupMembers:       10,
+	FixedIPs:                 -1,
+	FloatingIPs:              10,
+	SecurityGroups:           10,
+	SecurityGroupRules:       20,
+	Networks:                 3,
+}
+
+func (s *S) TestGetQuotas(c *C) {
+	testServer.PrepareResponse(200, nil, quotaSetBody)
+	client := newTestClient()
+	quotas, err := client.GetQuotas("123tenant")
+	c.Assert(err, IsNil)
+	c.Assert(*quotas, DeepEquals, quotaSet)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-quota-sets/123tenant")
+}
+
+func (s *S) TestGetQuotasFailure(c *C) {
+	testServer.PrepareResponse(403, nil, "Policy doesn't allow it")
+	client := newTestClient()
+	_, err := client.GetQuotas("456tenant")
+	c.Assert(err, ErrorMatches, "^Failed to get the quotas of the tenant 456tenant, status: 403.\nBody: Policy doesn't allow it.$")
+}
+
+func (s *S) TestGetDefaultQuotas(c *C) {
+	testServer.PrepareResponse(200, nil, quotaSetBody)
+	client := newTestClient()
+	quotas, err := client.GetDefaultQuotas("123tenant")
+	c.Assert(err, IsNil)
+	c.Assert(quotas.Instances, Equals, 10)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-quota-sets/123tenant/defaults")
+}
+
+func (s *S) TestGetQuotaDetail(c *C) {
+	testServer.PrepareResponse(200, nil, `{"quota_set": {"id": "123tenant", "cores": {"in_use": 12, "limit": 20, "reserved": 2}, "instances": {"in_use": 10, "limit": 10, "reserved": 0}, "ram": {"in_use": 0, "limit": -1, "reserved": 0}}}`)
+	client := newTestClient()
+	detail, err := client.GetQuotaDetail("123tenant")
+	c.Assert(err, IsNil)
+	c.Assert(detail.TenantId, Equals, "123tenant")
+	c.Assert(detail.Cores, DeepEquals, QuotaUsage{Limit: 20, InUse: 12, Reserved: 2})
+	c.Assert(detail.Cores.Available(), Equals, 6)
+	c.Assert(detail.Instances.Available(), Equals, 0)
+	c.Assert(detail.RAM.Available(), Equals, -1)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-quota-sets/123tenant/detail")
+}
+
+func (s *S) TestUpdateQuotas(c *C) {
+	testServer.PrepareResponse(200, nil, `{"quota_set": {"cores": 40, "instances": 20}}`)
+	client := newTestClient()
+	quotas, err := client.UpdateQuotas("456tenant", UpdateQuotasOpts{Cores: Quota(40), Instances: Quota(20), FixedIPs: Quota(-1), KeyPairs: Quota(0)})
+	c.Assert(err, IsNil)
+	c.Assert(quotas.TenantId, Equals, "456tenant")
+	c.Assert(quotas.Cores, Equals, 40)
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "PUT")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-quota-sets/456tenant")
+	c.Assert(string(b), Equals, `{"quota_set":{"cores":40,"fixed_ips":-1,"instances":20,"key_pairs":0}}`)
+}
+
+// allQuotas sets every quota, including the ones removed by microversions.
+var allQuotas = UpdateQuotasOpts{
+	Cores:                    Quota(20),
+	Instances:                Quota(10),
+	RAM:                      Quota(51200),
+	KeyPairs:                 Quota(100),
+	MetadataItems:            Quota(128),
+	InjectedFiles:            Quota(5),
+	InjectedFileContentBytes: Quota(10240),
+	InjectedFilePathBytes:    Quota(255),
+	ServerGroups:             Quota(10),
+	ServerGroupMembers:       Quota(10),
+	FixedIPs:                 Quota(-1),
+	FloatingIPs:              Quota(10),
+	SecurityGroups:           Quota(10),
+	SecurityGroupRules:       Quota(20),
+	Networks:                 Quota(3),
+}
+
+func (s *S) TestUpdateQuotasWithMicroversion(c *C) {
+	testServer.PrepareResponse(200, nil, versionsBody)
+	testServer.PrepareResponse(200, nil, `{"quota_set": {"cores": 40}}`)
+	client := newUndiscoveredTestClient()
+	client.Microversion = "2.36"
+	_, err := client.UpdateQuotas("456tenant", allQuotas)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/")
+	_, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `{"quota_set":{"cores":20,"injected_file_content_bytes":10240,"injected_file_path_bytes":255,"injected_files":5,"instances":10,"key_pairs":100,"metadata_items":128,"ram":51200,"server_group_members":10,"server_groups":10}}`)
+}
+
+func (s *S) TestUpdateQuotasWithoutInjectedFiles(c *C) {
+	testServer.PrepareResponse(300, nil, strings.Replace(versionsBody, `"version": "2.53"`, `"version": "2.79"`, 1))
+	testServer.PrepareResponse(200, nil, `{"quota_set": {"cores": 20}}`)
+	client := newUndiscoveredTestClient()
+	client.Microversion = LatestMicroversion
+	_, err := client.UpdateQuotas("456tenant", allQuotas)
+	c.Assert(err, IsNil)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("X-OpenStack-Nova-API-Version"), Equals, "2.79")
+	c.Assert(string(b), Equals, `{"quota_set":{"cores":20,"instances":10,"key_pairs":100,"metadata_items":128,"ram":51200,"server_group_members":10,"server_groups":10}}`)
+}
+
+func (s *S) TestDeleteQuotas(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.DeleteQuotas("456tenant")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-quota-sets/456tenant")
+}
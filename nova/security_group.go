@@ -0,0 +1,244 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// SecurityGroup represents a security group: a named collection of firewall
+// rules applied to servers.
+type SecurityGroup struct {
+	Id          string
+	Name        string
+	Description string
+	TenantId    string
+	Rules       []SecurityGroupRule
+}
+
+// UnmarshalJSON decodes a security group, accepting both numeric and string
+// ids.
+func (g *SecurityGroup) UnmarshalJSON(b []byte) error {
+	var group struct {
+		Id          interface{}
+		Name        string
+		Description string
+		TenantId    string `json:"tenant_id"`
+		Rules       []SecurityGroupRule
+	}
+	if err := json.Unmarshal(b, &group); err != nil {
+		return err
+	}
+	*g = SecurityGroup{
+		Id:          idString(group.Id),
+		Name:        group.Name,
+		Description: group.Description,
+		TenantId:    group.TenantId,
+		Rules:       group.Rules,
+	}
+	return nil
+}
+
+// SecurityGroupRule represents a rule in a security group. A rule allows
+// incoming traffic in the given protocol and port range, either from the
+// addresses in CIDR or from servers in the group named Group.
+type SecurityGroupRule struct {
+	Id            string
+	ParentGroupId string
+	IPProtocol    string
+	FromPort      int
+	ToPort        int
+	CIDR          string
+	Group         string
+}
+
+// UnmarshalJSON decodes a security group rule, accepting both numeric and
+// string ids.
+func (r *SecurityGroupRule) UnmarshalJSON(b []byte) error {
+	var rule struct {
+		Id            interface{}
+		ParentGroupId interface{} `json:"parent_group_id"`
+		IPProtocol    string      `json:"ip_protocol"`
+		FromPort      int         `json:"from_port"`
+		ToPort        int         `json:"to_port"`
+		IPRange       struct {
+			CIDR string
+		} `json:"ip_range"`
+		Group struct {
+			Name string
+		}
+	}
+	if err := json.Unmarshal(b, &rule); err != nil {
+		return err
+	}
+	*r = SecurityGroupRule{
+		Id:            idString(rule.Id),
+		ParentGroupId: idString(rule.ParentGroupId),
+		IPProtocol:    rule.IPProtocol,
+		FromPort:      rule.FromPort,
+		ToPort:        rule.ToPort,
+		CIDR:          rule.IPRange.CIDR,
+		Group:         rule.Group.Name,
+	}
+	return nil
+}
+
+// ListSecurityGroups returns all security groups of the tenant.
+func (c *Client) ListSecurityGroups() ([]SecurityGroup, error) {
+	var result struct {
+		SecurityGroups []SecurityGroup `json:"security_groups"`
+	}
+	err := c.request("get the list of security groups", "GET", "/os-security-groups", nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return result.SecurityGroups, nil
+}
+
+// GetSecurityGroup returns the security group with the given id.
+func (c *Client) GetSecurityGroup(id string) (*SecurityGroup, error) {
+	var result struct {
+		SecurityGroup SecurityGroup `json:"security_group"`
+	}
+	err := c.request("get the security group "+id, "GET", "/os-security-groups/"+id, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.SecurityGroup, nil
+}
+
+// CreateSecurityGroup creates a new security group, without rules.
+func (c *Client) CreateSecurityGroup(name, description string) (*SecurityGroup, error) {
+	var result struct {
+		SecurityGroup SecurityGroup `json:"security_group"`
+	}
+	body := map[string]interface{}{
+		"security_group": map[string]string{"name": name, "description": description},
+	}
+	err := c.request("create the security group "+name, "POST", "/os-security-groups", body, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.SecurityGroup, nil
+}
+
+// DeleteSecurityGroup deletes a security group.
+func (c *Client) DeleteSecurityGroup(id string) error {
+	return c.request("delete the security group "+id, "DELETE", "/os-security-groups/"+id, nil, nil, http.StatusAccepted)
+}
+
+// RuleOpts contains the attributes of a new security group rule.
+//
+// ParentGroupId, IPProtocol ("tcp", "udp" or "icmp"), FromPort and ToPort are
+// required. For ICMP rules, FromPort and ToPort are the ICMP type and code,
+// and -1 matches any type or code. The source of the traffic is either a CIDR
+// or a security group (GroupId), but not both. When neither is given, nova
+// uses 0.0.0.0/0.
+type RuleOpts struct {
+	ParentGroupId string
+	IPProtocol    string
+	FromPort      int
+	ToPort        int
+	CIDR          string
+	GroupId       string
+}
+
+func (opts *RuleOpts) validate() error {
+	if opts.ParentGroupId == "" {
+		return errors.New("Invalid rule: the parent group is required.")
+	}
+	if opts.CIDR != "" && opts.GroupId != "" {
+		return errors.New("Invalid rule: CIDR and GroupId are mutually exclusive.")
+	}
+	switch opts.IPProtocol {
+	case "tcp", "udp":
+		if opts.FromPort < 1 || opts.ToPort > 65535 {
+			return errors.New("Invalid rule: the port range must be within 1 and 65535.")
+		}
+		if opts.FromPort > opts.ToPort {
+			return errors.New("Invalid rule: FromPort must not be greater than ToPort.")
+		}
+	case "icmp":
+		if opts.FromPort < -1 || opts.FromPort > 255 || opts.ToPort < -1 || opts.ToPort > 255 {
+			return errors.New("Invalid rule: ICMP type and code must be within -1 and 255.")
+		}
+	default:
+		return errors.New("Invalid rule: the protocol must be tcp, udp or icmp.")
+	}
+	return nil
+}
+
+// CreateSecurityGroupRule adds a new rule to a security group.
+//
+// Example of use (allowing SSH from anywhere):
+//
+//     rule, err := client.CreateSecurityGroupRule(nova.RuleOpts{
+//         ParentGroupId: group.Id,
+//         IPProtocol:    "tcp",
+//         FromPort:      22,
+//         ToPort:        22,
+//         CIDR:          "0.0.0.0/0",
+//     })
+func (c *Client) CreateSecurityGroupRule(opts RuleOpts) (*SecurityGroupRule, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	rule := map[string]interface{}{
+		"parent_group_id": opts.ParentGroupId,
+		"ip_protocol":     opts.IPProtocol,
+		"from_port":       opts.FromPort,
+		"to_port":         opts.ToPort,
+	}
+	if opts.CIDR != "" {
+		rule["cidr"] = opts.CIDR
+	}
+	if opts.GroupId != "" {
+		rule["group_id"] = opts.GroupId
+	}
+	var result struct {
+		Rule SecurityGroupRule `json:"security_group_rule"`
+	}
+	body := map[string]interface{}{"security_group_rule": rule}
+	op := "add a rule to the security group " + opts.ParentGroupId
+	err := c.request(op, "POST", "/os-security-group-rules", body, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Rule, nil
+}
+
+// DeleteSecurityGroupRule removes a rule from its security group.
+func (c *Client) DeleteSecurityGroupRule(id string) error {
+	return c.request("delete the security group rule "+id, "DELETE", "/os-security-group-rules/"+id, nil, nil, http.StatusAccepted)
+}
+
+// ListServerSecurityGroups returns the security groups attached to a server.
+func (c *Client) ListServerSecurityGroups(serverId string) ([]SecurityGroup, error) {
+	var result struct {
+		SecurityGroups []SecurityGroup `json:"security_groups"`
+	}
+	op := "get the security groups of the server " + serverId
+	err := c.request(op, "GET", "/servers/"+serverId+"/os-security-groups", nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return result.SecurityGroups, nil
+}
+
+// AddServerSecurityGroup attaches the security group with the given name to a
+// server.
+func (c *Client) AddServerSecurityGroup(serverId, name string) error {
+	body := map[string]interface{}{"addSecurityGroup": map[string]string{"name": name}}
+	return c.action("add the security group "+name+" to the server "+serverId, serverId, body, nil)
+}
+
+// RemoveServerSecurityGroup detaches the security group with the given name
+// from a server.
+func (c *Client) RemoveServerSecurityGroup(serverId, name string) error {
+	body := map[string]interface{}{"removeSecurityGroup": map[string]string{"name": name}}
+	return c.action("remove the security group "+name+" from the server "+serverId, serverId, body, nil)
+}
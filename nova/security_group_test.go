@@ -0,0 +1,142 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	. "launchpad.net/gocheck"
+)
+
+const securityGroupBody = `{"security_group": {"id": 1, "name": "default", "description": "default", "tenant_id": "123tenant", "rules": [{"id": 10, "parent_group_id": 1, "ip_protocol": "tcp", "from_port": 22, "to_port": 22, "ip_range": {"cidr": "0.0.0.0/0"}, "group": {}}, {"id": 11, "parent_group_id": 1, "ip_protocol": "tcp", "from_port": 1, "to_port": 65535, "ip_range": {}, "group": {"name": "default", "tenant_id": "123tenant"}}]}}`
+
+func (s *S) TestGetSecurityGroup(c *C) {
+	testServer.PrepareResponse(200, nil, securityGroupBody)
+	client := newTestClient()
+	group, err := client.GetSecurityGroup("1")
+	c.Assert(err, IsNil)
+	expected := &SecurityGroup{
+		Id:          "1",
+		Name:        "default",
+		Description: "default",
+		TenantId:    "123tenant",
+		Rules: []SecurityGroupRule{
+			{Id: "10", ParentGroupId: "1", IPProtocol: "tcp", FromPort: 22, ToPort: 22, CIDR: "0.0.0.0/0"},
+			{Id: "11", ParentGroupId: "1", IPProtocol: "tcp", FromPort: 1, ToPort: 65535, Group: "default"},
+		},
+	}
+	c.Assert(group, DeepEquals, expected)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-security-groups/1")
+}
+
+func (s *S) TestListSecurityGroups(c *C) {
+	testServer.PrepareResponse(200, nil, `{"security_groups": [{"id": "85cc3048-abc3-43cc-89b3-377341426ac5", "name": "web", "description": "web servers", "tenant_id": "123tenant", "rules": []}]}`)
+	client := newTestClient()
+	groups, err := client.ListSecurityGroups()
+	c.Assert(err, IsNil)
+	c.Assert(groups, HasLen, 1)
+	c.Assert(groups[0].Id, Equals, "85cc3048-abc3-43cc-89b3-377341426ac5")
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-security-groups")
+}
+
+func (s *S) TestCreateSecurityGroup(c *C) {
+	testServer.PrepareResponse(200, nil, `{"security_group": {"id": 2, "name": "web", "description": "web servers", "tenant_id": "123tenant", "rules": []}}`)
+	client := newTestClient()
+	group, err := client.CreateSecurityGroup("web", "web servers")
+	c.Assert(err, IsNil)
+	c.Assert(group.Id, Equals, "2")
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "POST")
+	c.Assert(string(b), Equals, `{"security_group":{"description":"web servers","name":"web"}}`)
+}
+
+func (s *S) TestDeleteSecurityGroup(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.DeleteSecurityGroup("2")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-security-groups/2")
+}
+
+func (s *S) TestCreateSecurityGroupRule(c *C) {
+	testServer.PrepareResponse(200, nil, `{"security_group_rule": {"id": 12, "parent_group_id": 2, "ip_protocol": "tcp", "from_port": 80, "to_port": 443, "ip_range": {"cidr": "10.0.0.0/8"}, "group": {}}}`)
+	client := newTestClient()
+	rule, err := client.CreateSecurityGroupRule(RuleOpts{ParentGroupId: "2", IPProtocol: "tcp", FromPort: 80, ToPort: 443, CIDR: "10.0.0.0/8"})
+	c.Assert(err, IsNil)
+	c.Assert(rule, DeepEquals, &SecurityGroupRule{Id: "12", ParentGroupId: "2", IPProtocol: "tcp", FromPort: 80, ToPort: 443, CIDR: "10.0.0.0/8"})
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-security-group-rules")
+	c.Assert(string(b), Equals, `{"security_group_rule":{"cidr":"10.0.0.0/8","from_port":80,"ip_protocol":"tcp","parent_group_id":"2","to_port":443}}`)
+}
+
+func (s *S) TestCreateSecurityGroupRuleFromGroup(c *C) {
+	testServer.PrepareResponse(200, nil, `{"security_group_rule": {"id": 13, "parent_group_id": 2, "ip_protocol": "icmp", "from_port": -1, "to_port": -1, "ip_range": {}, "group": {"name": "default"}}}`)
+	client := newTestClient()
+	rule, err := client.CreateSecurityGroupRule(RuleOpts{ParentGroupId: "2", IPProtocol: "icmp", FromPort: -1, ToPort: -1, GroupId: "1"})
+	c.Assert(err, IsNil)
+	c.Assert(rule.Group, Equals, "default")
+	_, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `{"security_group_rule":{"from_port":-1,"group_id":"1","ip_protocol":"icmp","parent_group_id":"2","to_port":-1}}`)
+}
+
+func (s *S) TestCreateSecurityGroupRuleValidation(c *C) {
+	client := newTestClient()
+	var tests = []struct {
+		opts RuleOpts
+		msg  string
+	}{
+		{RuleOpts{IPProtocol: "tcp", FromPort: 22, ToPort: 22}, "^Invalid rule: the parent group is required.$"},
+		{RuleOpts{ParentGroupId: "2", IPProtocol: "tcp", FromPort: 22, ToPort: 22, CIDR: "0.0.0.0/0", GroupId: "1"}, "^Invalid rule: CIDR and GroupId are mutually exclusive.$"},
+		{RuleOpts{ParentGroupId: "2", IPProtocol: "tcp", FromPort: 443, ToPort: 80}, "^Invalid rule: FromPort must not be greater than ToPort.$"},
+		{RuleOpts{ParentGroupId: "2", IPProtocol: "tcp", FromPort: 22, ToPort: 65536}, "^Invalid rule: the port range must be within 1 and 65535.$"},
+		{RuleOpts{ParentGroupId: "2", IPProtocol: "udp", FromPort: 0, ToPort: 80}, "^Invalid rule: the port range must be within 1 and 65535.$"},
+		{RuleOpts{ParentGroupId: "2", IPProtocol: "icmp", FromPort: -2, ToPort: 0}, "^Invalid rule: ICMP type and code must be within -1 and 255.$"},
+		{RuleOpts{ParentGroupId: "2", IPProtocol: "gre"}, "^Invalid rule: the protocol must be tcp, udp or icmp.$"},
+	}
+	for _, t := range tests {
+		_, err := client.CreateSecurityGroupRule(t.opts)
+		c.Check(err, ErrorMatches, t.msg)
+	}
+}
+
+func (s *S) TestDeleteSecurityGroupRule(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.DeleteSecurityGroupRule("12")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-security-group-rules/12")
+}
+
+func (s *S) TestServerSecurityGroups(c *C) {
+	testServer.PrepareResponse(200, nil, `{"security_groups": [{"id": 1, "name": "default", "description": "default", "tenant_id": "123tenant", "rules": []}]}`)
+	testServer.PrepareResponse(202, nil, "")
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	groups, err := client.ListServerSecurityGroups("f5dc173b")
+	c.Assert(err, IsNil)
+	c.Assert(groups, HasLen, 1)
+	err = client.AddServerSecurityGroup("f5dc173b", "web")
+	c.Assert(err, IsNil)
+	err = client.RemoveServerSecurityGroup("f5dc173b", "default")
+	c.Assert(err, IsNil)
+	req, _, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/os-security-groups")
+	req, b, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/action")
+	c.Assert(string(b), Equals, `{"addSecurityGroup":{"name":"web"}}`)
+	_, b, _ = testServer.WaitRequest(1e9)
+	c.Assert(string(b), Equals, `{"removeSecurityGroup":{"name":"default"}}`)
+}
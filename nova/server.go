@@ -0,0 +1,460 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/globocom/go-openstack/keystone"
+	"github.com/globocom/go-openstack/pagination"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RebootType is the type of reboot performed by RebootServer.
+type RebootType string
+
+const (
+	// SoftReboot asks the guest operating system to restart.
+	SoftReboot RebootType = "SOFT"
+
+	// HardReboot power cycles the server.
+	HardReboot RebootType = "HARD"
+)
+
+// Ref is a reference to another resource (like the image or the flavor of a
+// server).
+type Ref struct {
+	Id    string
+	Links []keystone.Link
+}
+
+// UnmarshalJSON decodes a reference. Servers booted from volume have an empty
+// string instead of the reference to the image, which is decoded as an empty
+// Ref.
+func (r *Ref) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		*r = Ref{}
+		return nil
+	}
+	var ref struct {
+		Id    string
+		Links []keystone.Link
+	}
+	if err := json.Unmarshal(b, &ref); err != nil {
+		return err
+	}
+	*r = Ref(ref)
+	return nil
+}
+
+// Address represents an IP address of a server in one of its networks.
+type Address struct {
+	Addr    string
+	Version int
+	Type    string `json:"OS-EXT-IPS:type"`
+	MacAddr string `json:"OS-EXT-IPS-MAC:mac_addr"`
+}
+
+// Fault describes the last failure of a server. It is available only when
+// the server is in the ERROR state.
+type Fault struct {
+	Code    int
+	Message string
+	Details string
+	Created time.Time
+}
+
+// Server represents a nova server (an instance).
+type Server struct {
+	Id               string
+	Name             string
+	Status           string
+	TenantId         string `json:"tenant_id"`
+	UserId           string `json:"user_id"`
+	HostId           string `json:"hostId"`
+	Image            Ref
+	Flavor           Ref
+	Addresses        map[string][]Address
+	Metadata         map[string]string
+	AccessIPv4       string `json:"accessIPv4"`
+	AccessIPv6       string `json:"accessIPv6"`
+	KeyName          string `json:"key_name"`
+	Progress         int
+	Created          time.Time
+	Updated          time.Time
+	Fault            *Fault
+	AvailabilityZone string `json:"OS-EXT-AZ:availability_zone"`
+	Host             string `json:"OS-EXT-SRV-ATTR:host"`
+	PowerState       int    `json:"OS-EXT-STS:power_state"`
+	TaskState        string `json:"OS-EXT-STS:task_state"`
+	VmState          string `json:"OS-EXT-STS:vm_state"`
+	VolumesAttached  []Ref  `json:"os-extended-volumes:volumes_attached"`
+	Links            []keystone.Link
+
+	// AdminPass is the administrative password of the server. It is
+	// available only in the server returned by CreateServer and
+	// RebuildServer.
+	AdminPass string `json:"adminPass"`
+}
+
+// ServerNetwork represents a network the server will be attached to. Only one
+// of the fields should be used to identify the network.
+type ServerNetwork struct {
+	UUID    string
+	Port    string
+	FixedIP string
+}
+
+// PersonalityFile represents a file injected in the server by nova when it is
+// created.
+type PersonalityFile struct {
+	Path     string
+	Contents []byte
+}
+
+// MaxUserDataSize is the maximum size of the user data of a server, after
+// base64 encoding.
+const MaxUserDataSize = 65535
+
+// ServerOpts contains the options for creating a server. Name, ImageRef and
+// FlavorRef are required, all other fields are optional.
+//
+// UserData contains the raw user data (e.g. a cloud-init script), that the
+// client encodes in base64. Personality contains files to be injected in the
+// server; nova removed personality files in the microversion 2.57, so
+// UserData should be preferred.
+//
+// ServerGroup is the id of a server group to add the server to. The scheduler
+// places the server according to the policy of the group.
+type ServerOpts struct {
+	Name             string
+	ImageRef         string
+	FlavorRef        string
+	KeyName          string
+	AdminPass        string
+	AvailabilityZone string
+	SecurityGroups   []string
+	Networks         []ServerNetwork
+	Metadata         map[string]string
+	UserData         []byte
+	Personality      []PersonalityFile
+	ServerGroup      string
+}
+
+// validate checks the sizes of the user data and personality files. The
+// personality limits come from the tenant's absolute limits, and are only
+// checked when limits is not nil.
+func (opts *ServerOpts) validate(limits *AbsoluteLimits) error {
+	if size := base64.StdEncoding.EncodedLen(len(opts.UserData)); size > MaxUserDataSize {
+		return fmt.Errorf("Invalid server: the user data has %d bytes encoded, the maximum is %d.", size, MaxUserDataSize)
+	}
+	if limits == nil {
+		return nil
+	}
+	if limits.MaxPersonality >= 0 && len(opts.Personality) > limits.MaxPersonality {
+		return fmt.Errorf("Invalid server: %d personality files given, the maximum is %d.", len(opts.Personality), limits.MaxPersonality)
+	}
+	for _, file := range opts.Personality {
+		if limits.MaxPersonalitySize >= 0 && len(file.Contents) > limits.MaxPersonalitySize {
+			return fmt.Errorf("Invalid server: the personality file %s has %d bytes, the maximum is %d.", file.Path, len(file.Contents), limits.MaxPersonalitySize)
+		}
+	}
+	return nil
+}
+
+func (opts *ServerOpts) toMap() map[string]interface{} {
+	server := map[string]interface{}{
+		"name":      opts.Name,
+		"imageRef":  opts.ImageRef,
+		"flavorRef": opts.FlavorRef,
+	}
+	if opts.KeyName != "" {
+		server["key_name"] = opts.KeyName
+	}
+	if opts.AdminPass != "" {
+		server["adminPass"] = opts.AdminPass
+	}
+	if opts.AvailabilityZone != "" {
+		server["availability_zone"] = opts.AvailabilityZone
+	}
+	if len(opts.SecurityGroups) > 0 {
+		groups := make([]map[string]string, len(opts.SecurityGroups))
+		for i, name := range opts.SecurityGroups {
+			groups[i] = map[string]string{"name": name}
+		}
+		server["security_groups"] = groups
+	}
+	if len(opts.Networks) > 0 {
+		networks := make([]map[string]string, len(opts.Networks))
+		for i, net := range opts.Networks {
+			networks[i] = map[string]string{}
+			if net.UUID != "" {
+				networks[i]["uuid"] = net.UUID
+			}
+			if net.Port != "" {
+				networks[i]["port"] = net.Port
+			}
+			if net.FixedIP != "" {
+				networks[i]["fixed_ip"] = net.FixedIP
+			}
+		}
+		server["networks"] = networks
+	}
+	if len(opts.Metadata) > 0 {
+		server["metadata"] = opts.Metadata
+	}
+	if len(opts.UserData) > 0 {
+		server["user_data"] = base64.StdEncoding.EncodeToString(opts.UserData)
+	}
+	if len(opts.Personality) > 0 {
+		personality := make([]map[string]string, len(opts.Personality))
+		for i, file := range opts.Personality {
+			personality[i] = map[string]string{
+				"path":     file.Path,
+				"contents": base64.StdEncoding.EncodeToString(file.Contents),
+			}
+		}
+		server["personality"] = personality
+	}
+	return server
+}
+
+// ListServersOpts contains the filters and pagination parameters for listing
+// servers. Empty fields are ignored.
+//
+// Limit and Marker control pagination: the server returns at most Limit
+// servers, starting after the server with the id Marker.
+type ListServersOpts struct {
+	Name         string
+	Status       string
+	Image        string
+	Flavor       string
+	Host         string
+	ChangesSince time.Time
+	AllTenants   bool
+	TenantId     string
+	Limit        int
+	Marker       string
+}
+
+func (opts *ListServersOpts) query() url.Values {
+	q := url.Values{}
+	params := map[string]string{
+		"name":      opts.Name,
+		"status":    opts.Status,
+		"image":     opts.Image,
+		"flavor":    opts.Flavor,
+		"host":      opts.Host,
+		"tenant_id": opts.TenantId,
+		"marker":    opts.Marker,
+	}
+	for k, v := range params {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	if !opts.ChangesSince.IsZero() {
+		q.Set("changes-since", opts.ChangesSince.UTC().Format(time.RFC3339))
+	}
+	if opts.AllTenants {
+		q.Set("all_tenants", "1")
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	return q
+}
+
+// UpdateServerOpts contains the attributes of a server that can be changed by
+// UpdateServer. Empty fields are not changed.
+type UpdateServerOpts struct {
+	Name       string
+	AccessIPv4 string
+	AccessIPv6 string
+}
+
+// RebuildOpts contains the options for rebuilding a server. ImageRef is
+// required, all other fields are optional.
+type RebuildOpts struct {
+	ImageRef          string
+	Name              string
+	AdminPass         string
+	Metadata          map[string]string
+	PreserveEphemeral bool
+}
+
+// CreateServer creates a new server. Nova creates servers asynchronously, so
+// the returned server is usually in the BUILD state.
+//
+// The sizes of the user data and personality files are checked before the
+// server is created. Checking personality files requires getting the limits
+// of the tenant, so it costs an additional request. Personality files are
+// rejected when the negotiated microversion is 2.57 or newer.
+func (c *Client) CreateServer(opts ServerOpts) (*Server, error) {
+	var limits *AbsoluteLimits
+	if len(opts.Personality) > 0 {
+		removed, err := c.SupportsMicroversion("2.57")
+		if err != nil {
+			return nil, err
+		}
+		if removed {
+			return nil, errors.New("Invalid server: personality files were removed in the microversion 2.57, use UserData instead.")
+		}
+		l, err := c.Limits()
+		if err != nil {
+			return nil, err
+		}
+		limits = &l.Absolute
+	}
+	if err := opts.validate(limits); err != nil {
+		return nil, err
+	}
+	var result struct{ Server Server }
+	body := map[string]interface{}{"server": opts.toMap()}
+	if opts.ServerGroup != "" {
+		body["os:scheduler_hints"] = map[string]string{"group": opts.ServerGroup}
+	}
+	err := c.request("create the server "+opts.Name, "POST", "/servers", body, &result, http.StatusAccepted)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Server, nil
+}
+
+func (c *Client) listServers(ctx context.Context, opts ListServersOpts) (*pagination.Page[Server], error) {
+	var result struct {
+		Servers []Server
+		Links   []pagination.Link `json:"servers_links"`
+	}
+	path := "/servers/detail"
+	if q := opts.query().Encode(); q != "" {
+		path += "?" + q
+	}
+	err := c.requestContext(ctx, "get the list of servers", "GET", path, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &pagination.Page[Server]{Items: result.Servers, Next: pagination.NextMarker(result.Links)}, nil
+}
+
+// ListServers returns the detailed list of servers that match the given
+// options, following all pages of the listing. The listing starts after
+// opts.Marker, and opts.Limit is the size of each page.
+func (c *Client) ListServers(opts ListServersOpts) ([]Server, error) {
+	return c.ServerPager(opts).All(context.Background())
+}
+
+// ServerPager returns a pager over the servers that match the given options,
+// starting after opts.Marker. opts.Limit is the size of each page; when zero,
+// nova uses its maximum page size.
+func (c *Client) ServerPager(opts ListServersOpts) *pagination.Pager[Server] {
+	return pagination.New(func(ctx context.Context, marker string) (*pagination.Page[Server], error) {
+		if marker != "" {
+			opts.Marker = marker
+		}
+		return c.listServers(ctx, opts)
+	})
+}
+
+// GetServer returns the server with the given id.
+func (c *Client) GetServer(id string) (*Server, error) {
+	return c.getServer(context.Background(), id)
+}
+
+func (c *Client) getServer(ctx context.Context, id string) (*Server, error) {
+	var result struct{ Server Server }
+	err := c.requestContext(ctx, "get the server "+id, "GET", "/servers/"+id, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Server, nil
+}
+
+// UpdateServer changes the name or the access addresses of a server.
+func (c *Client) UpdateServer(id string, opts UpdateServerOpts) (*Server, error) {
+	server := map[string]string{}
+	if opts.Name != "" {
+		server["name"] = opts.Name
+	}
+	if opts.AccessIPv4 != "" {
+		server["accessIPv4"] = opts.AccessIPv4
+	}
+	if opts.AccessIPv6 != "" {
+		server["accessIPv6"] = opts.AccessIPv6
+	}
+	var result struct{ Server Server }
+	body := map[string]interface{}{"server": server}
+	err := c.request("update the server "+id, "PUT", "/servers/"+id, body, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Server, nil
+}
+
+// DeleteServer deletes a server. Nova deletes servers asynchronously, so the
+// server may still be listed for a while after this call.
+func (c *Client) DeleteServer(id string) error {
+	return c.request("delete the server "+id, "DELETE", "/servers/"+id, nil, nil, http.StatusNoContent)
+}
+
+// action sends an action to the server with the given id. The op parameter
+// describes the action and is used in error messages.
+func (c *Client) action(op, id string, body, out interface{}) error {
+	return c.request(op, "POST", "/servers/"+id+"/action", body, out, http.StatusOK, http.StatusAccepted, http.StatusNoContent)
+}
+
+// RebuildServer rebuilds a server from the given image, replacing its disk.
+func (c *Client) RebuildServer(id string, opts RebuildOpts) (*Server, error) {
+	rebuild := map[string]interface{}{"imageRef": opts.ImageRef}
+	if opts.Name != "" {
+		rebuild["name"] = opts.Name
+	}
+	if opts.AdminPass != "" {
+		rebuild["adminPass"] = opts.AdminPass
+	}
+	if len(opts.Metadata) > 0 {
+		rebuild["metadata"] = opts.Metadata
+	}
+	if opts.PreserveEphemeral {
+		rebuild["preserve_ephemeral"] = true
+	}
+	var result struct{ Server Server }
+	err := c.action("rebuild the server "+id, id, map[string]interface{}{"rebuild": rebuild}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Server, nil
+}
+
+// ResizeServer changes the flavor of a server. After the resize, the server
+// goes to the VERIFY_RESIZE state, and the resize must be confirmed (see
+// ConfirmResize) or reverted (see RevertResize).
+func (c *Client) ResizeServer(id, flavorRef string) error {
+	body := map[string]interface{}{"resize": map[string]string{"flavorRef": flavorRef}}
+	return c.action("resize the server "+id, id, body, nil)
+}
+
+// ConfirmResize confirms a pending resize.
+func (c *Client) ConfirmResize(id string) error {
+	return c.action("confirm the resize of the server "+id, id, map[string]interface{}{"confirmResize": nil}, nil)
+}
+
+// RevertResize reverts a pending resize, bringing the server back to its
+// original flavor.
+func (c *Client) RevertResize(id string) error {
+	return c.action("revert the resize of the server "+id, id, map[string]interface{}{"revertResize": nil}, nil)
+}
+
+// RebootServer reboots a server, using the given type of reboot.
+func (c *Client) RebootServer(id string, rebootType RebootType) error {
+	body := map[string]interface{}{"reboot": map[string]string{"type": string(rebootType)}}
+	return c.action("reboot the server "+id, id, body, nil)
+}
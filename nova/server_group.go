@@ -0,0 +1,203 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/globocom/go-openstack/pagination"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Policies of server groups.
+const (
+	// AffinityPolicy places all servers of the group in the same host.
+	AffinityPolicy = "affinity"
+	// AntiAffinityPolicy places each server of the group in a different
+	// host.
+	AntiAffinityPolicy = "anti-affinity"
+	// SoftAffinityPolicy places the servers of the group in the same host
+	// when possible.
+	SoftAffinityPolicy = "soft-affinity"
+	// SoftAntiAffinityPolicy places the servers of the group in different
+	// hosts when possible.
+	SoftAntiAffinityPolicy = "soft-anti-affinity"
+)
+
+// ServerGroup represents a server group: a set of servers that the scheduler
+// places according to Policy. Members contains the ids of the servers in the
+// group.
+//
+// MaxServerPerHost is a rule of the anti-affinity policy, that allows up to
+// that many servers of the group in the same host. It is zero when the group
+// does not define the rule.
+type ServerGroup struct {
+	Id               string
+	Name             string
+	Policy           string
+	MaxServerPerHost int
+	Members          []string
+	TenantId         string
+	UserId           string
+}
+
+// UnmarshalJSON decodes a server group. Before the microversion 2.64, nova
+// returns a list of policies (with a single policy) instead of the policy and
+// its rules.
+func (g *ServerGroup) UnmarshalJSON(b []byte) error {
+	var group struct {
+		Id       string
+		Name     string
+		Policy   string
+		Policies []string
+		Rules    struct {
+			MaxServerPerHost int `json:"max_server_per_host"`
+		}
+		Members  []string
+		TenantId string `json:"project_id"`
+		UserId   string `json:"user_id"`
+	}
+	if err := json.Unmarshal(b, &group); err != nil {
+		return err
+	}
+	*g = ServerGroup{
+		Id:               group.Id,
+		Name:             group.Name,
+		Policy:           group.Policy,
+		MaxServerPerHost: group.Rules.MaxServerPerHost,
+		Members:          group.Members,
+		TenantId:         group.TenantId,
+		UserId:           group.UserId,
+	}
+	if g.Policy == "" && len(group.Policies) > 0 {
+		g.Policy = group.Policies[0]
+	}
+	return nil
+}
+
+// ServerGroupOpts contains the attributes of a new server group. Name and
+// Policy are required. MaxServerPerHost is optional, is only valid with the
+// anti-affinity policy and requires the microversion 2.64 or newer.
+type ServerGroupOpts struct {
+	Name             string
+	Policy           string
+	MaxServerPerHost int
+}
+
+// ListServerGroups returns the server groups of the tenant, or of all tenants
+// when allTenants is true (admin only), following all pages of the listing.
+func (c *Client) ListServerGroups(allTenants bool) ([]ServerGroup, error) {
+	return c.ServerGroupPager(allTenants, 0).All(context.Background())
+}
+
+// ServerGroupPager returns a pager over the server groups of the tenant, or of
+// all tenants when allTenants is true (admin only), with pages of the given
+// size. When limit is zero, nova uses its maximum page size.
+//
+// Unlike other listings, server groups are paginated by offset and nova does
+// not return a link to the next page, so the pager requests pages until it
+// gets an empty one.
+func (c *Client) ServerGroupPager(allTenants bool, limit int) *pagination.Pager[ServerGroup] {
+	return pagination.New(func(ctx context.Context, marker string) (*pagination.Page[ServerGroup], error) {
+		var offset int
+		if marker != "" {
+			var err error
+			if offset, err = strconv.Atoi(marker); err != nil {
+				return nil, err
+			}
+		}
+		q := url.Values{}
+		if allTenants {
+			q.Set("all_projects", "True")
+		}
+		if limit > 0 {
+			q.Set("limit", strconv.Itoa(limit))
+		}
+		if offset > 0 {
+			q.Set("offset", strconv.Itoa(offset))
+		}
+		path := "/os-server-groups"
+		if len(q) > 0 {
+			path += "?" + q.Encode()
+		}
+		var result struct {
+			ServerGroups []ServerGroup `json:"server_groups"`
+		}
+		err := c.requestContext(ctx, "get the list of server groups", "GET", path, nil, &result, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+		next := strconv.Itoa(offset + len(result.ServerGroups))
+		return &pagination.Page[ServerGroup]{Items: result.ServerGroups, Next: next}, nil
+	})
+}
+
+// GetServerGroup returns the server group with the given id.
+func (c *Client) GetServerGroup(id string) (*ServerGroup, error) {
+	var result struct {
+		ServerGroup ServerGroup `json:"server_group"`
+	}
+	err := c.request("get the server group "+id, "GET", "/os-server-groups/"+id, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.ServerGroup, nil
+}
+
+// CreateServerGroup creates a new server group. Servers are added to the
+// group when they are created, using the ServerGroup field of ServerOpts.
+//
+// Example of use (spreading replicas across hosts):
+//
+//     group, err := client.CreateServerGroup(nova.ServerGroupOpts{
+//         Name:   "web",
+//         Policy: nova.AntiAffinityPolicy,
+//     })
+//     // handle err
+//     for i := 0; i < 3; i++ {
+//         _, err = client.CreateServer(nova.ServerOpts{
+//             Name:        fmt.Sprintf("web%d", i),
+//             ImageRef:    imageId,
+//             FlavorRef:   flavorId,
+//             ServerGroup: group.Id,
+//         })
+//         // handle err
+//     }
+func (c *Client) CreateServerGroup(opts ServerGroupOpts) (*ServerGroup, error) {
+	group := map[string]interface{}{"name": opts.Name}
+	ok, err := c.SupportsMicroversion("2.64")
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		group["policy"] = opts.Policy
+		if opts.MaxServerPerHost > 0 {
+			group["rules"] = map[string]int{"max_server_per_host": opts.MaxServerPerHost}
+		}
+	} else {
+		if opts.MaxServerPerHost > 0 {
+			return nil, errors.New("Invalid server group: MaxServerPerHost requires the microversion 2.64.")
+		}
+		group["policies"] = []string{opts.Policy}
+	}
+	var result struct {
+		ServerGroup ServerGroup `json:"server_group"`
+	}
+	body := map[string]interface{}{"server_group": group}
+	err = c.request("create the server group "+opts.Name, "POST", "/os-server-groups", body, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.ServerGroup, nil
+}
+
+// DeleteServerGroup deletes a server group. The servers of the group are not
+// deleted.
+func (c *Client) DeleteServerGroup(id string) error {
+	return c.request("delete the server group "+id, "DELETE", "/os-server-groups/"+id, nil, nil, http.StatusNoContent)
+}
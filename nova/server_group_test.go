@@ -0,0 +1,144 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"context"
+	. "launchpad.net/gocheck"
+	"strings"
+)
+
+func (s *S) TestGetServerGroup(c *C) {
+	testServer.PrepareResponse(200, nil, `{"server_group": {"id": "5bbcc3c4", "name": "web", "policies": ["anti-affinity"], "members": ["f5dc173b"], "metadata": {}, "project_id": "123tenant", "user_id": "123user"}}`)
+	client := newTestClient()
+	group, err := client.GetServerGroup("5bbcc3c4")
+	c.Assert(err, IsNil)
+	expected := &ServerGroup{
+		Id:       "5bbcc3c4",
+		Name:     "web",
+		Policy:   AntiAffinityPolicy,
+		Members:  []string{"f5dc173b"},
+		TenantId: "123tenant",
+		UserId:   "123user",
+	}
+	c.Assert(group, DeepEquals, expected)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-server-groups/5bbcc3c4")
+}
+
+func (s *S) TestGetServerGroupWithRules(c *C) {
+	testServer.PrepareResponse(200, nil, `{"server_group": {"id": "5bbcc3c4", "name": "web", "policy": "anti-affinity", "rules": {"max_server_per_host": 3}, "members": []}}`)
+	client := newTestClient()
+	group, err := client.GetServerGroup("5bbcc3c4")
+	c.Assert(err, IsNil)
+	c.Assert(group.Policy, Equals, AntiAffinityPolicy)
+	c.Assert(group.MaxServerPerHost, Equals, 3)
+}
+
+func (s *S) TestListServerGroups(c *C) {
+	testServer.PrepareResponse(200, nil, `{"server_groups": [{"id": "5bbcc3c4", "name": "web", "policies": ["affinity"], "members": []}]}`)
+	testServer.PrepareResponse(200, nil, `{"server_groups": []}`)
+	testServer.PrepareResponse(200, nil, `{"server_groups": []}`)
+	client := newTestClient()
+	groups, err := client.ListServerGroups(false)
+	c.Assert(err, IsNil)
+	c.Assert(groups, HasLen, 1)
+	c.Assert(groups[0].Policy, Equals, AffinityPolicy)
+	_, err = client.ListServerGroups(true)
+	c.Assert(err, IsNil)
+	req, _, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-server-groups")
+	c.Assert(req.URL.RawQuery, Equals, "")
+	req, _, _ = testServer.WaitRequest(1e9)
+	c.Assert(req.URL.RawQuery, Equals, "offset=1")
+	req, _, _ = testServer.WaitRequest(1e9)
+	c.Assert(req.URL.RawQuery, Equals, "all_projects=True")
+}
+
+func (s *S) TestServerGroupPager(c *C) {
+	testServer.PrepareResponse(200, nil, `{"server_groups": [{"id": "1"}, {"id": "2"}]}`)
+	testServer.PrepareResponse(200, nil, `{"server_groups": [{"id": "3"}]}`)
+	testServer.PrepareResponse(200, nil, `{"server_groups": []}`)
+	client := newTestClient()
+	groups, err := client.ServerGroupPager(true, 2).All(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(groups, HasLen, 3)
+	c.Assert(groups[2].Id, Equals, "3")
+	req, _, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.URL.RawQuery, Equals, "all_projects=True&limit=2")
+	req, _, _ = testServer.WaitRequest(1e9)
+	c.Assert(req.URL.RawQuery, Equals, "all_projects=True&limit=2&offset=2")
+	req, _, _ = testServer.WaitRequest(1e9)
+	c.Assert(req.URL.RawQuery, Equals, "all_projects=True&limit=2&offset=3")
+}
+
+func (s *S) TestCreateServerGroup(c *C) {
+	testServer.PrepareResponse(200, nil, `{"server_group": {"id": "5bbcc3c4", "name": "web", "policies": ["anti-affinity"], "members": []}}`)
+	client := newTestClient()
+	group, err := client.CreateServerGroup(ServerGroupOpts{Name: "web", Policy: AntiAffinityPolicy})
+	c.Assert(err, IsNil)
+	c.Assert(group.Id, Equals, "5bbcc3c4")
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "POST")
+	c.Assert(string(b), Equals, `{"server_group":{"name":"web","policies":["anti-affinity"]}}`)
+}
+
+func (s *S) TestCreateServerGroupWithRules(c *C) {
+	client := newTestClient()
+	_, err := client.CreateServerGroup(ServerGroupOpts{Name: "web", Policy: AntiAffinityPolicy, MaxServerPerHost: 2})
+	c.Assert(err, ErrorMatches, "^Invalid server group: MaxServerPerHost requires the microversion 2.64.$")
+	testServer.PrepareResponse(200, nil, strings.Replace(versionsBody, `"version": "2.53"`, `"version": "2.79"`, 1))
+	testServer.PrepareResponse(200, nil, `{"server_group": {"id": "5bbcc3c4", "name": "web", "policy": "anti-affinity", "rules": {"max_server_per_host": 2}}}`)
+	client = newUndiscoveredTestClient()
+	client.Microversion = "2.64"
+	group, err := client.CreateServerGroup(ServerGroupOpts{Name: "web", Policy: AntiAffinityPolicy, MaxServerPerHost: 2})
+	c.Assert(err, IsNil)
+	c.Assert(group.MaxServerPerHost, Equals, 2)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/")
+	_, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `{"server_group":{"name":"web","policy":"anti-affinity","rules":{"max_server_per_host":2}}}`)
+}
+
+func (s *S) TestCreateServerGroupWithLatestMicroversion(c *C) {
+	testServer.PrepareResponse(300, nil, strings.Replace(versionsBody, `"version": "2.53"`, `"version": "2.79"`, 1))
+	testServer.PrepareResponse(200, nil, `{"server_group": {"id": "5bbcc3c4", "name": "web", "policy": "anti-affinity"}}`)
+	client := newUndiscoveredTestClient()
+	client.Microversion = LatestMicroversion
+	group, err := client.CreateServerGroup(ServerGroupOpts{Name: "web", Policy: AntiAffinityPolicy})
+	c.Assert(err, IsNil)
+	c.Assert(group.Policy, Equals, AntiAffinityPolicy)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("X-OpenStack-Nova-API-Version"), Equals, "2.79")
+	c.Assert(string(b), Equals, `{"server_group":{"name":"web","policy":"anti-affinity"}}`)
+}
+
+func (s *S) TestDeleteServerGroup(c *C) {
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	err := client.DeleteServerGroup("5bbcc3c4")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-server-groups/5bbcc3c4")
+}
+
+func (s *S) TestCreateServerInServerGroup(c *C) {
+	testServer.PrepareResponse(202, nil, `{"server": {"id": "f5dc173b", "links": []}}`)
+	client := newTestClient()
+	_, err := client.CreateServer(ServerOpts{Name: "web1", ImageRef: "70a599e0", FlavorRef: "1", ServerGroup: "5bbcc3c4"})
+	c.Assert(err, IsNil)
+	_, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `{"os:scheduler_hints":{"group":"5bbcc3c4"},"server":{"flavorRef":"1","imageRef":"70a599e0","name":"web1"}}`)
+}
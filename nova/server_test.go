@@ -0,0 +1,342 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"context"
+	"encoding/json"
+	. "launchpad.net/gocheck"
+	"strings"
+	"time"
+)
+
+const serverBody = `{"server": {"id": "f5dc173b-6804-445a-a6d8-c705dad5b5eb", "name": "myserver", "status": "ACTIVE", "tenant_id": "123tenant", "user_id": "fake", "hostId": "b9d3", "image": {"id": "70a599e0-31e7-49b7-b260-868f441e862b", "links": [{"href": "http://localhost:5555/123tenant/images/70a599e0", "rel": "bookmark"}]}, "flavor": {"id": "1", "links": []}, "addresses": {"private": [{"addr": "192.168.0.3", "version": 4, "OS-EXT-IPS:type": "fixed", "OS-EXT-IPS-MAC:mac_addr": "aa:bb:cc:dd:ee:ff"}]}, "metadata": {"owner": "tsuru"}, "accessIPv4": "", "accessIPv6": "", "key_name": "mykey", "progress": 0, "created": "2012-09-07T16:56:37Z", "updated": "2012-09-07T16:56:39Z", "OS-EXT-AZ:availability_zone": "nova", "OS-EXT-STS:power_state": 1, "OS-EXT-STS:task_state": null, "OS-EXT-STS:vm_state": "active"}}`
+
+func (s *S) TestRefUnmarshalEmptyString(c *C) {
+	var server Server
+	err := json.Unmarshal([]byte(`{"image": "", "flavor": {"id": "1"}}`), &server)
+	c.Assert(err, IsNil)
+	c.Assert(server.Image, DeepEquals, Ref{})
+	c.Assert(server.Flavor.Id, Equals, "1")
+}
+
+func (s *S) TestCreateServer(c *C) {
+	testServer.PrepareResponse(202, nil, `{"server": {"id": "f5dc173b", "adminPass": "secret", "links": []}}`)
+	client := newTestClient()
+	server, err := client.CreateServer(ServerOpts{
+		Name:           "myserver",
+		ImageRef:       "70a599e0",
+		FlavorRef:      "1",
+		KeyName:        "mykey",
+		SecurityGroups: []string{"default"},
+		Networks:       []ServerNetwork{{UUID: "net-123"}},
+		Metadata:       map[string]string{"owner": "tsuru"},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(server.Id, Equals, "f5dc173b")
+	c.Assert(server.AdminPass, Equals, "secret")
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "POST")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers")
+	c.Assert(req.Header.Get("Content-Type"), Equals, "application/json")
+	var body map[string]map[string]interface{}
+	c.Assert(json.Unmarshal(b, &body), IsNil)
+	c.Assert(body["server"]["name"], Equals, "myserver")
+	c.Assert(body["server"]["imageRef"], Equals, "70a599e0")
+	c.Assert(body["server"]["flavorRef"], Equals, "1")
+	c.Assert(body["server"]["key_name"], Equals, "mykey")
+	c.Assert(body["server"]["security_groups"], DeepEquals, []interface{}{map[string]interface{}{"name": "default"}})
+	c.Assert(body["server"]["networks"], DeepEquals, []interface{}{map[string]interface{}{"uuid": "net-123"}})
+	c.Assert(body["server"]["metadata"], DeepEquals, map[string]interface{}{"owner": "tsuru"})
+	_, ok := body["server"]["adminPass"]
+	c.Assert(ok, Equals, false)
+}
+
+func (s *S) TestCreateServerFailure(c *C) {
+	testServer.PrepareResponse(400, nil, `{"badRequest": {"message": "Invalid flavorRef provided.", "code": 400}}`)
+	client := newTestClient()
+	_, err := client.CreateServer(ServerOpts{Name: "myserver", ImageRef: "70a599e0", FlavorRef: "xx"})
+	c.Assert(err, NotNil)
+	e, ok := err.(*Error)
+	c.Assert(ok, Equals, true)
+	c.Assert(e.StatusCode, Equals, 400)
+	c.Assert(err, ErrorMatches, `(?s)^Failed to create the server myserver, status: 400.*Invalid flavorRef provided.*`)
+}
+
+func (s *S) TestCreateServerWithUserData(c *C) {
+	testServer.PrepareResponse(202, nil, `{"server": {"id": "f5dc173b", "links": []}}`)
+	client := newTestClient()
+	_, err := client.CreateServer(ServerOpts{
+		Name:      "myserver",
+		ImageRef:  "70a599e0",
+		FlavorRef: "1",
+		UserData:  []byte("#!/bin/sh\necho hello\n"),
+	})
+	c.Assert(err, IsNil)
+	_, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	var body map[string]map[string]interface{}
+	c.Assert(json.Unmarshal(b, &body), IsNil)
+	c.Assert(body["server"]["user_data"], Equals, "IyEvYmluL3NoCmVjaG8gaGVsbG8K")
+}
+
+func (s *S) TestCreateServerUserDataTooLarge(c *C) {
+	client := newTestClient()
+	_, err := client.CreateServer(ServerOpts{
+		Name:      "myserver",
+		ImageRef:  "70a599e0",
+		FlavorRef: "1",
+		UserData:  make([]byte, 50000),
+	})
+	c.Assert(err, ErrorMatches, "^Invalid server: the user data has 66668 bytes encoded, the maximum is 65535.$")
+}
+
+func (s *S) TestCreateServerWithPersonality(c *C) {
+	testServer.PrepareResponse(200, nil, limitsBody)
+	testServer.PrepareResponse(202, nil, `{"server": {"id": "f5dc173b", "links": []}}`)
+	client := newTestClient()
+	_, err := client.CreateServer(ServerOpts{
+		Name:        "myserver",
+		ImageRef:    "70a599e0",
+		FlavorRef:   "1",
+		Personality: []PersonalityFile{{Path: "/etc/motd", Contents: []byte("hello")}},
+	})
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/limits")
+	_, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	var body struct {
+		Server struct{ Personality []map[string]string }
+	}
+	c.Assert(json.Unmarshal(b, &body), IsNil)
+	c.Assert(body.Server.Personality, DeepEquals, []map[string]string{{"path": "/etc/motd", "contents": "aGVsbG8="}})
+}
+
+func (s *S) TestCreateServerWithPersonalityAfterRemoval(c *C) {
+	testServer.PrepareResponse(300, nil, strings.Replace(versionsBody, `"version": "2.53"`, `"version": "2.79"`, 1))
+	client := newUndiscoveredTestClient()
+	client.Microversion = LatestMicroversion
+	_, err := client.CreateServer(ServerOpts{
+		Name:        "myserver",
+		ImageRef:    "70a599e0",
+		FlavorRef:   "1",
+		Personality: []PersonalityFile{{Path: "/etc/motd", Contents: []byte("hello")}},
+	})
+	c.Assert(err, ErrorMatches, "^Invalid server: personality files were removed in the microversion 2.57, use UserData instead.$")
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	_, _, err = testServer.WaitRequest(1e8)
+	c.Assert(err, NotNil)
+}
+
+func (s *S) TestCreateServerPersonalityValidation(c *C) {
+	client := newTestClient()
+	var tests = []struct {
+		personality []PersonalityFile
+		msg         string
+	}{
+		{make([]PersonalityFile, 6), "^Invalid server: 6 personality files given, the maximum is 5.$"},
+		{[]PersonalityFile{{Path: "/etc/big", Contents: make([]byte, 10241)}}, "^Invalid server: the personality file /etc/big has 10241 bytes, the maximum is 10240.$"},
+	}
+	for _, t := range tests {
+		testServer.PrepareResponse(200, nil, limitsBody)
+		_, err := client.CreateServer(ServerOpts{Name: "myserver", ImageRef: "70a599e0", FlavorRef: "1", Personality: t.personality})
+		c.Check(err, ErrorMatches, t.msg)
+		testServer.WaitRequest(1e9)
+	}
+}
+
+func (s *S) TestListServers(c *C) {
+	testServer.PrepareResponse(200, nil, `{"servers": [{"id": "1", "name": "a", "status": "ACTIVE"}, {"id": "2", "name": "b", "status": "ACTIVE"}]}`)
+	client := newTestClient()
+	servers, err := client.ListServers(ListServersOpts{
+		Status:       "ACTIVE",
+		AllTenants:   true,
+		ChangesSince: time.Date(2012, 9, 7, 16, 0, 0, 0, time.UTC),
+		Limit:        2,
+		Marker:       "0",
+	})
+	c.Assert(err, IsNil)
+	c.Assert(servers, HasLen, 2)
+	c.Assert(servers[1].Name, Equals, "b")
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/detail")
+	q := req.URL.Query()
+	c.Assert(q.Get("status"), Equals, "ACTIVE")
+	c.Assert(q.Get("all_tenants"), Equals, "1")
+	c.Assert(q.Get("changes-since"), Equals, "2012-09-07T16:00:00Z")
+	c.Assert(q.Get("limit"), Equals, "2")
+	c.Assert(q.Get("marker"), Equals, "0")
+	_, ok := q["name"]
+	c.Assert(ok, Equals, false)
+}
+
+func (s *S) TestServerPager(c *C) {
+	testServer.PrepareResponse(200, nil, `{"servers": [{"id": "1", "name": "a"}, {"id": "2", "name": "b"}], "servers_links": [{"href": "http://localhost:5555/v2.1/123tenant/servers/detail?limit=2&marker=2", "rel": "next"}]}`)
+	testServer.PrepareResponse(200, nil, `{"servers": [{"id": "3", "name": "c"}]}`)
+	client := newTestClient()
+	servers, err := client.ServerPager(ListServersOpts{Limit: 2}).All(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(servers, HasLen, 3)
+	c.Assert(servers[2].Name, Equals, "c")
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.RawQuery, Equals, "limit=2")
+	req, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Query().Get("marker"), Equals, "2")
+	c.Assert(req.URL.Query().Get("limit"), Equals, "2")
+}
+
+func (s *S) TestListServersFollowsPages(c *C) {
+	testServer.PrepareResponse(200, nil, `{"servers": [{"id": "1", "name": "a"}], "servers_links": [{"href": "http://localhost:5555/v2.1/123tenant/servers/detail?marker=1", "rel": "next"}]}`)
+	testServer.PrepareResponse(200, nil, `{"servers": [{"id": "2", "name": "b"}]}`)
+	client := newTestClient()
+	servers, err := client.ListServers(ListServersOpts{})
+	c.Assert(err, IsNil)
+	c.Assert(servers, HasLen, 2)
+	c.Assert(servers[1].Name, Equals, "b")
+	testServer.WaitRequest(1e9)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.RawQuery, Equals, "marker=1")
+}
+
+func (s *S) TestServerPagerCancelsHangingRequest(c *C) {
+	client := newTestClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.ServerPager(ListServersOpts{}).NextPage(ctx)
+	c.Assert(err, ErrorMatches, ".*context deadline exceeded.*")
+	c.Assert(time.Since(start) < 900*time.Millisecond, Equals, true)
+	// Releases the request that is still waiting for a response.
+	testServer.PrepareResponse(200, nil, `{"servers": []}`)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+}
+
+func (s *S) TestGetServer(c *C) {
+	testServer.PrepareResponse(200, nil, serverBody)
+	client := newTestClient()
+	server, err := client.GetServer("f5dc173b-6804-445a-a6d8-c705dad5b5eb")
+	c.Assert(err, IsNil)
+	c.Assert(server.Name, Equals, "myserver")
+	c.Assert(server.Status, Equals, "ACTIVE")
+	c.Assert(server.Image.Id, Equals, "70a599e0-31e7-49b7-b260-868f441e862b")
+	c.Assert(server.Flavor.Id, Equals, "1")
+	c.Assert(server.Addresses["private"], DeepEquals, []Address{{Addr: "192.168.0.3", Version: 4, Type: "fixed", MacAddr: "aa:bb:cc:dd:ee:ff"}})
+	c.Assert(server.Metadata, DeepEquals, map[string]string{"owner": "tsuru"})
+	c.Assert(server.Created.Equal(time.Date(2012, 9, 7, 16, 56, 37, 0, time.UTC)), Equals, true)
+	c.Assert(server.AvailabilityZone, Equals, "nova")
+	c.Assert(server.PowerState, Equals, 1)
+	c.Assert(server.VmState, Equals, "active")
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b-6804-445a-a6d8-c705dad5b5eb")
+	c.Assert(req.Header.Get("X-Auth-Token"), Equals, "123token")
+}
+
+func (s *S) TestGetServerNotFound(c *C) {
+	testServer.PrepareResponse(404, nil, `{"itemNotFound": {"message": "Instance could not be found", "code": 404}}`)
+	client := newTestClient()
+	_, err := client.GetServer("unknown")
+	c.Assert(IsNotFound(err), Equals, true)
+}
+
+func (s *S) TestUpdateServer(c *C) {
+	testServer.PrepareResponse(200, nil, serverBody)
+	client := newTestClient()
+	_, err := client.UpdateServer("f5dc173b", UpdateServerOpts{Name: "myserver"})
+	c.Assert(err, IsNil)
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "PUT")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b")
+	c.Assert(string(b), Equals, `{"server":{"name":"myserver"}}`)
+}
+
+func (s *S) TestDeleteServer(c *C) {
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	err := client.DeleteServer("f5dc173b")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b")
+}
+
+func (s *S) TestDeleteServerFailure(c *C) {
+	testServer.PrepareResponse(409, nil, "Cannot delete a locked server.")
+	client := newTestClient()
+	err := client.DeleteServer("f5dc173b")
+	c.Assert(err, ErrorMatches, "^Failed to delete the server f5dc173b, status: 409.\nBody: Cannot delete a locked server..$")
+}
+
+func (s *S) TestRebuildServer(c *C) {
+	testServer.PrepareResponse(202, nil, serverBody)
+	client := newTestClient()
+	server, err := client.RebuildServer("f5dc173b", RebuildOpts{ImageRef: "70a599e0", PreserveEphemeral: true})
+	c.Assert(err, IsNil)
+	c.Assert(server.Name, Equals, "myserver")
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/action")
+	c.Assert(string(b), Equals, `{"rebuild":{"imageRef":"70a599e0","preserve_ephemeral":true}}`)
+}
+
+func (s *S) TestResizeServer(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.ResizeServer("f5dc173b", "2")
+	c.Assert(err, IsNil)
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/action")
+	c.Assert(string(b), Equals, `{"resize":{"flavorRef":"2"}}`)
+}
+
+func (s *S) TestConfirmResize(c *C) {
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	err := client.ConfirmResize("f5dc173b")
+	c.Assert(err, IsNil)
+	_, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `{"confirmResize":null}`)
+}
+
+func (s *S) TestRevertResize(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.RevertResize("f5dc173b")
+	c.Assert(err, IsNil)
+	_, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `{"revertResize":null}`)
+}
+
+func (s *S) TestRebootServer(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.RebootServer("f5dc173b", HardReboot)
+	c.Assert(err, IsNil)
+	_, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `{"reboot":{"type":"HARD"}}`)
+}
+
+func (s *S) TestRebootServerFailure(c *C) {
+	testServer.PrepareResponse(409, nil, "Instance is in task_state rebooting.")
+	client := newTestClient()
+	err := client.RebootServer("f5dc173b", SoftReboot)
+	c.Assert(err, NotNil)
+	c.Assert(err.(*Error).StatusCode, Equals, 409)
+}
@@ -0,0 +1,144 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"encoding/json"
+	"github.com/globocom/go-openstack/internal/timeutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Service represents a compute service (e.g. nova-compute or
+// nova-conductor) running in a host.
+//
+// Status is "enabled" or "disabled", and tells whether the scheduler uses the
+// service. State is "up" or "down", and tells whether the service is
+// reporting to nova. ForcedDown is true when an operator marked the service
+// as down (see ForceDownService).
+type Service struct {
+	Id             string
+	Binary         string
+	Host           string
+	Zone           string
+	Status         string
+	State          string
+	DisabledReason string
+	ForcedDown     bool
+	Updated        time.Time
+}
+
+// UnmarshalJSON decodes a service, accepting both numeric and string ids and
+// parsing the timestamp in nova's format.
+func (s *Service) UnmarshalJSON(b []byte) error {
+	var service struct {
+		Id             interface{}
+		Binary         string
+		Host           string
+		Zone           string
+		Status         string
+		State          string
+		DisabledReason string `json:"disabled_reason"`
+		ForcedDown     bool   `json:"forced_down"`
+		UpdatedAt      string `json:"updated_at"`
+	}
+	if err := json.Unmarshal(b, &service); err != nil {
+		return err
+	}
+	*s = Service{
+		Id:             idString(service.Id),
+		Binary:         service.Binary,
+		Host:           service.Host,
+		Zone:           service.Zone,
+		Status:         service.Status,
+		State:          service.State,
+		DisabledReason: service.DisabledReason,
+		ForcedDown:     service.ForcedDown,
+		Updated:        timeutil.Parse(service.UpdatedAt),
+	}
+	return nil
+}
+
+// ListServices returns the compute services. The host and binary parameters
+// are optional filters.
+func (c *Client) ListServices(host, binary string) ([]Service, error) {
+	q := url.Values{}
+	if host != "" {
+		q.Set("host", host)
+	}
+	if binary != "" {
+		q.Set("binary", binary)
+	}
+	path := "/os-services"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var result struct{ Services []Service }
+	err := c.request("get the list of services", "GET", path, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return result.Services, nil
+}
+
+// updateService changes a service. Since the microversion 2.53, nova
+// identifies services by id; before it, by host and binary, with a different
+// path for each change (given by legacyPath).
+func (c *Client) updateService(op string, service *Service, legacyPath string, changes map[string]interface{}) error {
+	ok, err := c.SupportsMicroversion("2.53")
+	if err != nil {
+		return err
+	}
+	if ok {
+		return c.request(op, "PUT", "/os-services/"+service.Id, changes, nil, http.StatusOK)
+	}
+	body := map[string]interface{}{"host": service.Host, "binary": service.Binary}
+	for k, v := range changes {
+		if k != "status" {
+			body[k] = v
+		}
+	}
+	return c.request(op, "PUT", "/os-services/"+legacyPath, body, nil, http.StatusOK)
+}
+
+// EnableService enables a service, so the scheduler places servers in its
+// host again. The service is usually one returned by ListServices: its Id is
+// used with the microversion 2.53 or newer, and its Host and Binary with
+// older microversions.
+func (c *Client) EnableService(service Service) error {
+	op := "enable the service " + service.Binary + " in " + service.Host
+	return c.updateService(op, &service, "enable", map[string]interface{}{"status": "enabled"})
+}
+
+// DisableService disables a service, so the scheduler no longer places
+// servers in its host. The reason is optional. See EnableService for the
+// fields of the service that are used.
+func (c *Client) DisableService(service Service, reason string) error {
+	op := "disable the service " + service.Binary + " in " + service.Host
+	changes := map[string]interface{}{"status": "disabled"}
+	legacyPath := "disable"
+	if reason != "" {
+		changes["disabled_reason"] = reason
+		legacyPath = "disable-log-reason"
+	}
+	return c.updateService(op, &service, legacyPath, changes)
+}
+
+// ForceDownService marks a service as down (or clears the mark, when down is
+// false) without waiting for nova to notice it is not reporting, e.g. to
+// evacuate the servers of a failed host right away. It requires the
+// microversion 2.11 or newer. See EnableService for the fields of the service
+// that are used.
+func (c *Client) ForceDownService(service Service, down bool) error {
+	op := "force down the service " + service.Binary + " in " + service.Host
+	return c.updateService(op, &service, "force-down", map[string]interface{}{"forced_down": down})
+}
+
+// DeleteService deletes a service, e.g. a compute service of a host that was
+// removed from the cloud.
+func (c *Client) DeleteService(id string) error {
+	return c.request("delete the service "+id, "DELETE", "/os-services/"+id, nil, nil, http.StatusNoContent)
+}
@@ -0,0 +1,96 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	. "launchpad.net/gocheck"
+	"time"
+)
+
+func (s *S) TestListServices(c *C) {
+	testServer.PrepareResponse(200, nil, `{"services": [{"binary": "nova-compute", "disabled_reason": "maintenance", "host": "compute1", "id": 4, "state": "up", "status": "disabled", "updated_at": "2012-10-29T13:42:05.000000", "forced_down": false, "zone": "nova"}]}`)
+	client := newTestClient()
+	services, err := client.ListServices("compute1", "nova-compute")
+	c.Assert(err, IsNil)
+	expected := []Service{{
+		Id:             "4",
+		Binary:         "nova-compute",
+		Host:           "compute1",
+		Zone:           "nova",
+		Status:         "disabled",
+		State:          "up",
+		DisabledReason: "maintenance",
+		Updated:        time.Date(2012, 10, 29, 13, 42, 5, 0, time.UTC),
+	}}
+	c.Assert(services, DeepEquals, expected)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-services")
+	c.Assert(req.URL.RawQuery, Equals, "binary=nova-compute&host=compute1")
+}
+
+func (s *S) TestListServicesFailure(c *C) {
+	testServer.PrepareResponse(500, nil, "Internal error")
+	client := newTestClient()
+	_, err := client.ListServices("", "")
+	c.Assert(err, ErrorMatches, "^Failed to get the list of services, status: 500.\nBody: Internal error.$")
+}
+
+func (s *S) TestUpdateService(c *C) {
+	client := newTestClient()
+	service := Service{Id: "4", Host: "compute1", Binary: "nova-compute"}
+	var tests = []struct {
+		update func() error
+		path   string
+		body   string
+	}{
+		{func() error { return client.EnableService(service) }, "/os-services/enable", `{"binary":"nova-compute","host":"compute1"}`},
+		{func() error { return client.DisableService(service, "") }, "/os-services/disable", `{"binary":"nova-compute","host":"compute1"}`},
+		{func() error { return client.DisableService(service, "maintenance") }, "/os-services/disable-log-reason", `{"binary":"nova-compute","disabled_reason":"maintenance","host":"compute1"}`},
+		{func() error { return client.ForceDownService(service, true) }, "/os-services/force-down", `{"binary":"nova-compute","forced_down":true,"host":"compute1"}`},
+	}
+	for _, t := range tests {
+		testServer.PrepareResponse(200, nil, `{"service": {}}`)
+		err := t.update()
+		c.Assert(err, IsNil)
+		req, b, err := testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+		c.Assert(req.Method, Equals, "PUT")
+		c.Assert(req.URL.Path, Equals, "/v2.1/123tenant"+t.path)
+		c.Assert(string(b), Equals, t.body)
+	}
+}
+
+func (s *S) TestUpdateServiceWithMicroversion(c *C) {
+	testServer.PrepareResponse(200, nil, versionsBody)
+	testServer.PrepareResponse(200, nil, `{"service": {}}`)
+	testServer.PrepareResponse(200, nil, `{"service": {}}`)
+	client := newUndiscoveredTestClient()
+	client.Microversion = LatestMicroversion
+	service := Service{Id: "e81d66a4", Host: "compute1", Binary: "nova-compute"}
+	err := client.DisableService(service, "maintenance")
+	c.Assert(err, IsNil)
+	err = client.ForceDownService(service, false)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/")
+	req, b, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-services/e81d66a4")
+	c.Assert(string(b), Equals, `{"disabled_reason":"maintenance","status":"disabled"}`)
+	_, b, _ = testServer.WaitRequest(1e9)
+	c.Assert(string(b), Equals, `{"forced_down":false}`)
+}
+
+func (s *S) TestDeleteService(c *C) {
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	err := client.DeleteService("4")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-services/4")
+}
@@ -0,0 +1,239 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/globocom/go-openstack/internal/timeutil"
+	"github.com/globocom/go-openstack/pagination"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const usageTimeLayout = "2006-01-02T15:04:05.000000"
+
+// ServerUsage represents the usage of a server in the period of a usage
+// report. Hours is the number of hours the server existed within the period.
+// EndedAt is zero for servers that were not deleted.
+type ServerUsage struct {
+	InstanceId string
+	Name       string
+	TenantId   string
+	Flavor     string
+	State      string
+	Hours      float64
+	VCPUs      int
+	MemoryMB   int
+	LocalGB    int
+	Uptime     int
+	StartedAt  time.Time
+	EndedAt    time.Time
+}
+
+// UnmarshalJSON decodes a server usage, parsing the timestamps in nova's
+// format.
+func (u *ServerUsage) UnmarshalJSON(b []byte) error {
+	var usage struct {
+		InstanceId string `json:"instance_id"`
+		Name       string
+		TenantId   string `json:"tenant_id"`
+		Flavor     string
+		State      string
+		Hours      float64
+		VCPUs      int
+		MemoryMB   int `json:"memory_mb"`
+		LocalGB    int `json:"local_gb"`
+		Uptime     int
+		StartedAt  string `json:"started_at"`
+		EndedAt    string `json:"ended_at"`
+	}
+	if err := json.Unmarshal(b, &usage); err != nil {
+		return err
+	}
+	*u = ServerUsage{
+		InstanceId: usage.InstanceId,
+		Name:       usage.Name,
+		TenantId:   usage.TenantId,
+		Flavor:     usage.Flavor,
+		State:      usage.State,
+		Hours:      usage.Hours,
+		VCPUs:      usage.VCPUs,
+		MemoryMB:   usage.MemoryMB,
+		LocalGB:    usage.LocalGB,
+		Uptime:     usage.Uptime,
+		StartedAt:  timeutil.Parse(usage.StartedAt),
+		EndedAt:    timeutil.Parse(usage.EndedAt),
+	}
+	return nil
+}
+
+// TenantUsage represents the usage of a tenant in a period: the total hours of
+// all servers, and the total vCPU-hours, memory (MB-hours) and local disk
+// (GB-hours). ServerUsages is only filled in detailed reports.
+type TenantUsage struct {
+	TenantId           string
+	Start              time.Time
+	Stop               time.Time
+	TotalHours         float64
+	TotalVCPUsUsage    float64
+	TotalMemoryMBUsage float64
+	TotalLocalGBUsage  float64
+	ServerUsages       []ServerUsage
+}
+
+// UnmarshalJSON decodes a tenant usage, parsing the timestamps in nova's
+// format.
+func (u *TenantUsage) UnmarshalJSON(b []byte) error {
+	var usage struct {
+		TenantId           string        `json:"tenant_id"`
+		Start              string        `json:"start"`
+		Stop               string        `json:"stop"`
+		TotalHours         float64       `json:"total_hours"`
+		TotalVCPUsUsage    float64       `json:"total_vcpus_usage"`
+		TotalMemoryMBUsage float64       `json:"total_memory_mb_usage"`
+		TotalLocalGBUsage  float64       `json:"total_local_gb_usage"`
+		ServerUsages       []ServerUsage `json:"server_usages"`
+	}
+	if err := json.Unmarshal(b, &usage); err != nil {
+		return err
+	}
+	*u = TenantUsage{
+		TenantId:           usage.TenantId,
+		Start:              timeutil.Parse(usage.Start),
+		Stop:               timeutil.Parse(usage.Stop),
+		TotalHours:         usage.TotalHours,
+		TotalVCPUsUsage:    usage.TotalVCPUsUsage,
+		TotalMemoryMBUsage: usage.TotalMemoryMBUsage,
+		TotalLocalGBUsage:  usage.TotalLocalGBUsage,
+		ServerUsages:       usage.ServerUsages,
+	}
+	return nil
+}
+
+// merge adds the totals and server usages of other, that is another page of
+// the same tenant's report, to u.
+func (u *TenantUsage) merge(other *TenantUsage) {
+	u.TotalHours += other.TotalHours
+	u.TotalVCPUsUsage += other.TotalVCPUsUsage
+	u.TotalMemoryMBUsage += other.TotalMemoryMBUsage
+	u.TotalLocalGBUsage += other.TotalLocalGBUsage
+	u.ServerUsages = append(u.ServerUsages, other.ServerUsages...)
+}
+
+// UsageOpts contains the options of a usage report. Start and End define the
+// period of the report; when zero, nova uses the beginning of the current
+// month and the current time.
+//
+// Detailed includes the usage of each server in reports of all tenants
+// (reports of a single tenant always include it). PageSize limits the number
+// of servers in each page of the report (requires the microversion 2.40);
+// the client fetches and merges all pages.
+type UsageOpts struct {
+	Start    time.Time
+	End      time.Time
+	Detailed bool
+	PageSize int
+}
+
+func (opts *UsageOpts) query(marker string) url.Values {
+	q := url.Values{}
+	if !opts.Start.IsZero() {
+		q.Set("start", opts.Start.UTC().Format(usageTimeLayout))
+	}
+	if !opts.End.IsZero() {
+		q.Set("end", opts.End.UTC().Format(usageTimeLayout))
+	}
+	if opts.Detailed {
+		q.Set("detailed", "1")
+	}
+	if opts.PageSize > 0 {
+		q.Set("limit", strconv.Itoa(opts.PageSize))
+	}
+	if marker != "" {
+		q.Set("marker", marker)
+	}
+	return q
+}
+
+// ListTenantUsages returns the usage of all tenants in the given period, one
+// TenantUsage per tenant with servers in the period.
+func (c *Client) ListTenantUsages(opts UsageOpts) ([]TenantUsage, error) {
+	pager := pagination.New(func(ctx context.Context, marker string) (*pagination.Page[TenantUsage], error) {
+		var result struct {
+			Usages []TenantUsage   `json:"tenant_usages"`
+			Links  []pagination.Link `json:"tenant_usages_links"`
+		}
+		path := "/os-simple-tenant-usage"
+		if q := opts.query(marker).Encode(); q != "" {
+			path += "?" + q
+		}
+		err := c.requestContext(ctx, "get the usage of all tenants", "GET", path, nil, &result, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+		return &pagination.Page[TenantUsage]{Items: result.Usages, Next: pagination.NextMarker(result.Links)}, nil
+	})
+	pages, err := pager.All(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	// The same tenant appears in more than one page when its servers are
+	// split across pages.
+	var usages []TenantUsage
+	index := map[string]int{}
+	for i := range pages {
+		usage := &pages[i]
+		if j, ok := index[usage.TenantId]; ok {
+			usages[j].merge(usage)
+			continue
+		}
+		index[usage.TenantId] = len(usages)
+		usages = append(usages, *usage)
+	}
+	return usages, nil
+}
+
+// GetTenantUsage returns the usage of the given tenant in the given period,
+// including the usage of each server.
+//
+// Example of use (usage of the last month):
+//
+//     end := time.Now()
+//     usage, err := client.GetTenantUsage(tenantId, nova.UsageOpts{
+//         Start: end.AddDate(0, -1, 0),
+//         End:   end,
+//     })
+//     // handle err
+//     fmt.Printf("%.2f vCPU-hours\n", usage.TotalVCPUsUsage)
+func (c *Client) GetTenantUsage(tenantId string, opts UsageOpts) (*TenantUsage, error) {
+	opts.Detailed = false
+	pager := pagination.New(func(ctx context.Context, marker string) (*pagination.Page[TenantUsage], error) {
+		var result struct {
+			Usage TenantUsage     `json:"tenant_usage"`
+			Links []pagination.Link `json:"tenant_usage_links"`
+		}
+		path := "/os-simple-tenant-usage/" + tenantId
+		if q := opts.query(marker).Encode(); q != "" {
+			path += "?" + q
+		}
+		err := c.requestContext(ctx, "get the usage of the tenant "+tenantId, "GET", path, nil, &result, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+		return &pagination.Page[TenantUsage]{Items: []TenantUsage{result.Usage}, Next: pagination.NextMarker(result.Links)}, nil
+	})
+	pages, err := pager.All(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	usage := &pages[0]
+	for i := 1; i < len(pages); i++ {
+		usage.merge(&pages[i])
+	}
+	return usage, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	. "launchpad.net/gocheck"
+	"time"
+)
+
+func (s *S) TestGetTenantUsage(c *C) {
+	testServer.PrepareResponse(200, nil, `{"tenant_usage": {"tenant_id": "123tenant", "start": "2012-10-01T00:00:00", "stop": "2012-11-01T00:00:00", "total_hours": 744.0, "total_vcpus_usage": 1488.0, "total_memory_mb_usage": 3047424.0, "total_local_gb_usage": 14880.0, "server_usages": [{"instance_id": "f5dc173b", "name": "web1", "tenant_id": "123tenant", "flavor": "m1.small", "state": "active", "hours": 744.0, "vcpus": 2, "memory_mb": 4096, "local_gb": 20, "uptime": 2678400, "started_at": "2012-09-20T21:11:09.000000", "ended_at": null}]}}`)
+	client := newTestClient()
+	start := time.Date(2012, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2012, 11, 1, 0, 0, 0, 0, time.UTC)
+	usage, err := client.GetTenantUsage("123tenant", UsageOpts{Start: start, End: end})
+	c.Assert(err, IsNil)
+	expected := &TenantUsage{
+		TenantId:           "123tenant",
+		Start:              start,
+		Stop:               end,
+		TotalHours:         744,
+		TotalVCPUsUsage:    1488,
+		TotalMemoryMBUsage: 3047424,
+		TotalLocalGBUsage:  14880,
+		ServerUsages: []ServerUsage{{
+			InstanceId: "f5dc173b",
+			Name:       "web1",
+			TenantId:   "123tenant",
+			Flavor:     "m1.small",
+			State:      "active",
+			Hours:      744,
+			VCPUs:      2,
+			MemoryMB:   4096,
+			LocalGB:    20,
+			Uptime:     2678400,
+			StartedAt:  time.Date(2012, 9, 20, 21, 11, 9, 0, time.UTC),
+		}},
+	}
+	c.Assert(usage, DeepEquals, expected)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-simple-tenant-usage/123tenant")
+	c.Assert(req.URL.Query().Get("start"), Equals, "2012-10-01T00:00:00.000000")
+	c.Assert(req.URL.Query().Get("end"), Equals, "2012-11-01T00:00:00.000000")
+}
+
+func (s *S) TestGetTenantUsagePagination(c *C) {
+	testServer.PrepareResponse(200, nil, `{"tenant_usage": {"tenant_id": "123tenant", "total_hours": 10.0, "total_vcpus_usage": 10.0, "server_usages": [{"instance_id": "a"}]}, "tenant_usage_links": [{"href": "http://localhost:5555/v2.1/123tenant/os-simple-tenant-usage/123tenant?limit=1&marker=a", "rel": "next"}]}`)
+	testServer.PrepareResponse(200, nil, `{"tenant_usage": {"tenant_id": "123tenant", "total_hours": 5.0, "total_vcpus_usage": 20.0, "server_usages": [{"instance_id": "b"}]}}`)
+	client := newTestClient()
+	usage, err := client.GetTenantUsage("123tenant", UsageOpts{PageSize: 1})
+	c.Assert(err, IsNil)
+	c.Assert(usage.TotalHours, Equals, 15.0)
+	c.Assert(usage.TotalVCPUsUsage, Equals, 30.0)
+	c.Assert(usage.ServerUsages, HasLen, 2)
+	c.Assert(usage.ServerUsages[1].InstanceId, Equals, "b")
+	req, _, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.URL.RawQuery, Equals, "limit=1")
+	req, _, _ = testServer.WaitRequest(1e9)
+	c.Assert(req.URL.RawQuery, Equals, "limit=1&marker=a")
+}
+
+func (s *S) TestGetTenantUsageFailure(c *C) {
+	testServer.PrepareResponse(400, nil, "Invalid start time")
+	client := newTestClient()
+	_, err := client.GetTenantUsage("123tenant", UsageOpts{})
+	c.Assert(err, ErrorMatches, "^Failed to get the usage of the tenant 123tenant, status: 400.\nBody: Invalid start time.$")
+}
+
+func (s *S) TestListTenantUsages(c *C) {
+	testServer.PrepareResponse(200, nil, `{"tenant_usages": [{"tenant_id": "123tenant", "total_hours": 10.0, "server_usages": [{"instance_id": "a"}]}, {"tenant_id": "456tenant", "total_hours": 3.0, "server_usages": [{"instance_id": "b"}]}], "tenant_usages_links": [{"href": "http://localhost:5555/v2.1/123tenant/os-simple-tenant-usage?detailed=1&limit=2&marker=b", "rel": "next"}]}`)
+	testServer.PrepareResponse(200, nil, `{"tenant_usages": [{"tenant_id": "456tenant", "total_hours": 4.0, "server_usages": [{"instance_id": "c"}]}], "tenant_usages_links": [{"href": "http://localhost:5555/v2.1/123tenant/os-simple-tenant-usage?detailed=1&limit=2&marker=c", "rel": "next"}]}`)
+	testServer.PrepareResponse(200, nil, `{"tenant_usages": []}`)
+	client := newTestClient()
+	usages, err := client.ListTenantUsages(UsageOpts{Detailed: true, PageSize: 2})
+	c.Assert(err, IsNil)
+	c.Assert(usages, HasLen, 2)
+	c.Assert(usages[0].TenantId, Equals, "123tenant")
+	c.Assert(usages[1].TenantId, Equals, "456tenant")
+	c.Assert(usages[1].TotalHours, Equals, 7.0)
+	c.Assert(usages[1].ServerUsages, HasLen, 2)
+	req, _, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/os-simple-tenant-usage")
+	c.Assert(req.URL.RawQuery, Equals, "detailed=1&limit=2")
+	_, _, _ = testServer.WaitRequest(1e9)
+	req, _, _ = testServer.WaitRequest(1e9)
+	c.Assert(req.URL.RawQuery, Equals, "detailed=1&limit=2&marker=c")
+}
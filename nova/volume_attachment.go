@@ -0,0 +1,126 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"context"
+	"net/http"
+)
+
+// VolumeAttachment represents a volume attached to a server. Device is the
+// device name of the volume in the server (e.g. /dev/vdb).
+type VolumeAttachment struct {
+	Id       string
+	VolumeId string `json:"volumeId"`
+	ServerId string `json:"serverId"`
+	Device   string
+}
+
+// ListVolumeAttachments returns the volumes attached to a server.
+func (c *Client) ListVolumeAttachments(serverId string) ([]VolumeAttachment, error) {
+	var result struct {
+		Attachments []VolumeAttachment `json:"volumeAttachments"`
+	}
+	op := "get the volume attachments of the server " + serverId
+	err := c.request(op, "GET", "/servers/"+serverId+"/os-volume_attachments", nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return result.Attachments, nil
+}
+
+// GetVolumeAttachment returns the attachment of the given volume to a server.
+func (c *Client) GetVolumeAttachment(serverId, volumeId string) (*VolumeAttachment, error) {
+	var result struct {
+		Attachment VolumeAttachment `json:"volumeAttachment"`
+	}
+	op := "get the attachment of the volume " + volumeId + " to the server " + serverId
+	err := c.request(op, "GET", "/servers/"+serverId+"/os-volume_attachments/"+volumeId, nil, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Attachment, nil
+}
+
+// AttachVolume attaches a volume to a server. The device parameter is an
+// optional hint for the device name (e.g. /dev/vdb), that some hypervisors
+// ignore; the device actually used is in the returned attachment.
+//
+// Nova attaches volumes asynchronously, see WaitForVolumeAttached.
+func (c *Client) AttachVolume(serverId, volumeId, device string) (*VolumeAttachment, error) {
+	attachment := map[string]string{"volumeId": volumeId}
+	if device != "" {
+		attachment["device"] = device
+	}
+	var result struct {
+		Attachment VolumeAttachment `json:"volumeAttachment"`
+	}
+	body := map[string]interface{}{"volumeAttachment": attachment}
+	op := "attach the volume " + volumeId + " to the server " + serverId
+	err := c.request(op, "POST", "/servers/"+serverId+"/os-volume_attachments", body, &result, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Attachment, nil
+}
+
+// SwapVolume replaces an attached volume with another volume, copying the
+// data of the old volume to the new one. It is usually done by the block
+// storage service during volume migrations, and is admin only by default.
+func (c *Client) SwapVolume(serverId, oldVolumeId, newVolumeId string) error {
+	body := map[string]interface{}{"volumeAttachment": map[string]string{"volumeId": newVolumeId}}
+	op := "swap the volume " + oldVolumeId + " of the server " + serverId
+	return c.request(op, "PUT", "/servers/"+serverId+"/os-volume_attachments/"+oldVolumeId, body, nil, http.StatusAccepted)
+}
+
+// DetachVolume detaches a volume from a server. Nova detaches volumes
+// asynchronously, see WaitForVolumeDetached.
+func (c *Client) DetachVolume(serverId, volumeId string) error {
+	op := "detach the volume " + volumeId + " from the server " + serverId
+	return c.request(op, "DELETE", "/servers/"+serverId+"/os-volume_attachments/"+volumeId, nil, nil, http.StatusAccepted)
+}
+
+func (c *Client) waitForVolume(ctx context.Context, serverId, volumeId string, attached bool, opts *WaitOpts) error {
+	return poll(ctx, opts, func() (bool, error) {
+		server, err := c.getServer(ctx, serverId)
+		if err != nil {
+			return false, err
+		}
+		if server.Status == StatusError {
+			return false, &ServerError{Server: server}
+		}
+		found := false
+		for _, volume := range server.VolumesAttached {
+			if volume.Id == volumeId {
+				found = true
+				break
+			}
+		}
+		if attached {
+			return found && server.TaskState == "", nil
+		}
+		return !found, nil
+	})
+}
+
+// WaitForVolumeAttached polls a server until the given volume is listed in
+// its attached volumes and the server has no task in progress.
+//
+// Example of use:
+//
+//     _, err := client.AttachVolume(server.Id, volumeId, "")
+//     // handle err
+//     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+//     defer cancel()
+//     err = client.WaitForVolumeAttached(ctx, server.Id, volumeId, nil)
+func (c *Client) WaitForVolumeAttached(ctx context.Context, serverId, volumeId string, opts *WaitOpts) error {
+	return c.waitForVolume(ctx, serverId, volumeId, true, opts)
+}
+
+// WaitForVolumeDetached polls a server until the given volume is no longer
+// listed in its attached volumes.
+func (c *Client) WaitForVolumeDetached(ctx context.Context, serverId, volumeId string, opts *WaitOpts) error {
+	return c.waitForVolume(ctx, serverId, volumeId, false, opts)
+}
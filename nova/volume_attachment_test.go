@@ -0,0 +1,119 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"context"
+	. "launchpad.net/gocheck"
+)
+
+func (s *S) TestListVolumeAttachments(c *C) {
+	testServer.PrepareResponse(200, nil, `{"volumeAttachments": [{"device": "/dev/vdb", "id": "a26887c6", "serverId": "f5dc173b", "volumeId": "a26887c6"}]}`)
+	client := newTestClient()
+	attachments, err := client.ListVolumeAttachments("f5dc173b")
+	c.Assert(err, IsNil)
+	c.Assert(attachments, DeepEquals, []VolumeAttachment{{Id: "a26887c6", VolumeId: "a26887c6", ServerId: "f5dc173b", Device: "/dev/vdb"}})
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/os-volume_attachments")
+}
+
+func (s *S) TestGetVolumeAttachment(c *C) {
+	testServer.PrepareResponse(200, nil, `{"volumeAttachment": {"device": "/dev/vdb", "id": "a26887c6", "serverId": "f5dc173b", "volumeId": "a26887c6"}}`)
+	client := newTestClient()
+	attachment, err := client.GetVolumeAttachment("f5dc173b", "a26887c6")
+	c.Assert(err, IsNil)
+	c.Assert(attachment.Device, Equals, "/dev/vdb")
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/os-volume_attachments/a26887c6")
+}
+
+func (s *S) TestAttachVolume(c *C) {
+	testServer.PrepareResponse(200, nil, `{"volumeAttachment": {"device": "/dev/vdc", "id": "a26887c6", "serverId": "f5dc173b", "volumeId": "a26887c6"}}`)
+	testServer.PrepareResponse(200, nil, `{"volumeAttachment": {"device": "/dev/vdd", "id": "b4e3d1aa", "serverId": "f5dc173b", "volumeId": "b4e3d1aa"}}`)
+	client := newTestClient()
+	attachment, err := client.AttachVolume("f5dc173b", "a26887c6", "/dev/vdb")
+	c.Assert(err, IsNil)
+	c.Assert(attachment.Device, Equals, "/dev/vdc")
+	_, err = client.AttachVolume("f5dc173b", "b4e3d1aa", "")
+	c.Assert(err, IsNil)
+	req, b, _ := testServer.WaitRequest(1e9)
+	c.Assert(req.Method, Equals, "POST")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/os-volume_attachments")
+	c.Assert(string(b), Equals, `{"volumeAttachment":{"device":"/dev/vdb","volumeId":"a26887c6"}}`)
+	_, b, _ = testServer.WaitRequest(1e9)
+	c.Assert(string(b), Equals, `{"volumeAttachment":{"volumeId":"b4e3d1aa"}}`)
+}
+
+func (s *S) TestAttachVolumeFailure(c *C) {
+	testServer.PrepareResponse(400, nil, "Invalid volume: volume a26887c6 status must be available")
+	client := newTestClient()
+	_, err := client.AttachVolume("f5dc173b", "a26887c6", "")
+	c.Assert(err, ErrorMatches, "^Failed to attach the volume a26887c6 to the server f5dc173b, status: 400.\nBody: Invalid volume: volume a26887c6 status must be available.$")
+}
+
+func (s *S) TestSwapVolume(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.SwapVolume("f5dc173b", "a26887c6", "b4e3d1aa")
+	c.Assert(err, IsNil)
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "PUT")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/os-volume_attachments/a26887c6")
+	c.Assert(string(b), Equals, `{"volumeAttachment":{"volumeId":"b4e3d1aa"}}`)
+}
+
+func (s *S) TestDetachVolume(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.DetachVolume("f5dc173b", "a26887c6")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b/os-volume_attachments/a26887c6")
+}
+
+func (s *S) TestWaitForVolumeAttached(c *C) {
+	testServer.PrepareResponse(200, nil, `{"server": {"id": "f5dc173b", "status": "ACTIVE", "os-extended-volumes:volumes_attached": []}}`)
+	testServer.PrepareResponse(200, nil, `{"server": {"id": "f5dc173b", "status": "ACTIVE", "OS-EXT-STS:task_state": "updating", "os-extended-volumes:volumes_attached": [{"id": "a26887c6"}]}}`)
+	testServer.PrepareResponse(200, nil, `{"server": {"id": "f5dc173b", "status": "ACTIVE", "OS-EXT-STS:task_state": null, "os-extended-volumes:volumes_attached": [{"id": "a26887c6", "delete_on_termination": false}]}}`)
+	client := newTestClient()
+	err := client.WaitForVolumeAttached(context.Background(), "f5dc173b", "a26887c6", fastWait)
+	c.Assert(err, IsNil)
+	for i := 0; i < 3; i++ {
+		req, _, err := testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+		c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b")
+	}
+}
+
+func (s *S) TestWaitForVolumeAttachedServerError(c *C) {
+	testServer.PrepareResponse(200, nil, `{"server": {"id": "f5dc173b", "status": "ERROR", "fault": {"code": 500, "message": "Volume attach failed."}}}`)
+	client := newTestClient()
+	err := client.WaitForVolumeAttached(context.Background(), "f5dc173b", "a26887c6", fastWait)
+	c.Assert(err, ErrorMatches, "^Server f5dc173b is in the ERROR state: Volume attach failed.$")
+}
+
+func (s *S) TestWaitForVolumeDetached(c *C) {
+	testServer.PrepareResponse(200, nil, `{"server": {"id": "f5dc173b", "status": "ACTIVE", "os-extended-volumes:volumes_attached": [{"id": "a26887c6"}]}}`)
+	testServer.PrepareResponse(200, nil, `{"server": {"id": "f5dc173b", "status": "ACTIVE", "os-extended-volumes:volumes_attached": [{"id": "b4e3d1aa"}]}}`)
+	client := newTestClient()
+	err := client.WaitForVolumeDetached(context.Background(), "f5dc173b", "a26887c6", fastWait)
+	c.Assert(err, IsNil)
+	testServer.WaitRequest(1e9)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+}
+
+func (s *S) TestWaitForVolumeAttachedCancel(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client := newTestClient()
+	err := client.WaitForVolumeAttached(ctx, "f5dc173b", "a26887c6", fastWait)
+	c.Assert(err, Equals, context.Canceled)
+}
@@ -0,0 +1,145 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Statuses of servers.
+const (
+	StatusActive       = "ACTIVE"
+	StatusBuild        = "BUILD"
+	StatusShutoff      = "SHUTOFF"
+	StatusVerifyResize = "VERIFY_RESIZE"
+	StatusError        = "ERROR"
+
+	// StatusDeleted is the status of deleted servers. Most of the time,
+	// deleted servers are just not found, and WaitForStatus handles both
+	// cases.
+	StatusDeleted = "DELETED"
+)
+
+// Default values for WaitOpts.
+const (
+	DefaultWaitInterval    = 2 * time.Second
+	DefaultWaitMaxInterval = 30 * time.Second
+)
+
+// WaitOpts controls how often the wait helpers poll the server.
+//
+// The first poll happens immediately. After each poll, the interval is
+// multiplied by Backoff, up to MaxInterval. A nil *WaitOpts, or zero fields,
+// use the default values: an interval of DefaultWaitInterval, without backoff,
+// and a maximum interval of DefaultWaitMaxInterval.
+type WaitOpts struct {
+	Interval    time.Duration
+	Backoff     float64
+	MaxInterval time.Duration
+}
+
+// ServerError is returned by the wait helpers when the server goes to the
+// ERROR state.
+type ServerError struct {
+	Server *Server
+}
+
+func (e *ServerError) Error() string {
+	msg := fmt.Sprintf("Server %s is in the ERROR state", e.Server.Id)
+	if e.Server.Fault != nil && e.Server.Fault.Message != "" {
+		msg += ": " + strings.TrimSuffix(e.Server.Fault.Message, ".")
+	}
+	return msg + "."
+}
+
+// after is time.After, replaced in tests that check the intervals of poll.
+var after = time.After
+
+// poll calls check until it returns true or an error, or until the context is
+// done, sleeping between calls as configured in opts.
+func poll(ctx context.Context, opts *WaitOpts, check func() (bool, error)) error {
+	interval, backoff, max := DefaultWaitInterval, 1.0, DefaultWaitMaxInterval
+	if opts != nil {
+		if opts.Interval > 0 {
+			interval = opts.Interval
+		}
+		if opts.Backoff > 1 {
+			backoff = opts.Backoff
+		}
+		if opts.MaxInterval > 0 {
+			max = opts.MaxInterval
+		}
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		done, err := check()
+		if err != nil && ctx.Err() != nil {
+			// The check was aborted by the context.
+			return ctx.Err()
+		}
+		if err != nil || done {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-after(interval):
+		}
+		interval = time.Duration(float64(interval) * backoff)
+		if interval > max {
+			interval = max
+		}
+	}
+}
+
+// WaitForStatus polls the server with the given id until it reaches the given
+// status, returning the server in that status.
+//
+// If the server goes to the ERROR state, WaitForStatus returns a ServerError,
+// that includes the fault message reported by nova. When waiting for
+// StatusDeleted, a server that is not found counts as deleted, and the
+// returned server is nil.
+//
+// The context can be used for cancellation and timeouts:
+//
+//     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+//     defer cancel()
+//     server, err := client.WaitForStatus(ctx, id, nova.StatusActive, nil)
+func (c *Client) WaitForStatus(ctx context.Context, id, status string, opts *WaitOpts) (*Server, error) {
+	var server *Server
+	err := poll(ctx, opts, func() (bool, error) {
+		var err error
+		server, err = c.getServer(ctx, id)
+		if err != nil {
+			if status == StatusDeleted && IsNotFound(err) {
+				server = nil
+				return true, nil
+			}
+			return false, err
+		}
+		if server.Status == status {
+			return true, nil
+		}
+		if server.Status == StatusError {
+			return false, &ServerError{Server: server}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// WaitForDeleted polls the server with the given id until it is deleted.
+func (c *Client) WaitForDeleted(ctx context.Context, id string, opts *WaitOpts) error {
+	_, err := c.WaitForStatus(ctx, id, StatusDeleted, opts)
+	return err
+}
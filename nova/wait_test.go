@@ -0,0 +1,133 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nova
+
+import (
+	"context"
+	"errors"
+	. "launchpad.net/gocheck"
+	"time"
+)
+
+var fastWait = &WaitOpts{Interval: time.Millisecond}
+
+func (s *S) TestPollBackoff(c *C) {
+	var intervals []time.Duration
+	after = func(d time.Duration) <-chan time.Time {
+		intervals = append(intervals, d)
+		ch := make(chan time.Time, 1)
+		ch <- time.Now()
+		return ch
+	}
+	defer func() { after = time.After }()
+	calls := 0
+	opts := &WaitOpts{Interval: 10 * time.Millisecond, Backoff: 2, MaxInterval: 25 * time.Millisecond}
+	err := poll(context.Background(), opts, func() (bool, error) {
+		calls++
+		return calls == 5, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(calls, Equals, 5)
+	c.Assert(intervals, DeepEquals, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 25 * time.Millisecond, 25 * time.Millisecond})
+}
+
+func (s *S) TestPollStopsOnError(c *C) {
+	calls := 0
+	err := poll(context.Background(), fastWait, func() (bool, error) {
+		calls++
+		return false, errors.New("something went wrong")
+	})
+	c.Assert(err, ErrorMatches, "^something went wrong$")
+	c.Assert(calls, Equals, 1)
+}
+
+func (s *S) TestPollContextCancellation(c *C) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := poll(ctx, &WaitOpts{Interval: time.Hour}, func() (bool, error) {
+		return false, nil
+	})
+	c.Assert(err, Equals, context.DeadlineExceeded)
+}
+
+func (s *S) TestWaitForStatus(c *C) {
+	testServer.PrepareResponse(200, nil, `{"server": {"id": "f5dc173b", "status": "BUILD"}}`)
+	testServer.PrepareResponse(200, nil, `{"server": {"id": "f5dc173b", "status": "BUILD"}}`)
+	testServer.PrepareResponse(200, nil, `{"server": {"id": "f5dc173b", "status": "ACTIVE"}}`)
+	client := newTestClient()
+	server, err := client.WaitForStatus(context.Background(), "f5dc173b", StatusActive, fastWait)
+	c.Assert(err, IsNil)
+	c.Assert(server.Status, Equals, StatusActive)
+	for i := 0; i < 3; i++ {
+		req, _, err := testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+		c.Assert(req.URL.Path, Equals, "/v2.1/123tenant/servers/f5dc173b")
+	}
+}
+
+func (s *S) TestWaitForStatusReturnsTheFault(c *C) {
+	testServer.PrepareResponse(200, nil, `{"server": {"id": "f5dc173b", "status": "BUILD"}}`)
+	testServer.PrepareResponse(200, nil, `{"server": {"id": "f5dc173b", "status": "ERROR", "fault": {"code": 500, "message": "No valid host was found.", "created": "2012-09-07T16:56:39Z"}}}`)
+	client := newTestClient()
+	_, err := client.WaitForStatus(context.Background(), "f5dc173b", StatusActive, fastWait)
+	c.Assert(err, ErrorMatches, "^Server f5dc173b is in the ERROR state: No valid host was found.$")
+	e, ok := err.(*ServerError)
+	c.Assert(ok, Equals, true)
+	c.Assert(e.Server.Fault.Code, Equals, 500)
+}
+
+func (s *S) TestWaitForStatusFailure(c *C) {
+	testServer.PrepareResponse(404, nil, "Instance could not be found.")
+	client := newTestClient()
+	_, err := client.WaitForStatus(context.Background(), "f5dc173b", StatusShutoff, fastWait)
+	c.Assert(IsNotFound(err), Equals, true)
+}
+
+func (s *S) TestWaitForStatusCancelsHangingRequest(c *C) {
+	client := newTestClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.WaitForStatus(ctx, "f5dc173b", StatusActive, fastWait)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+	c.Assert(time.Since(start) < 900*time.Millisecond, Equals, true)
+	// Releases the request that is still waiting for a response.
+	testServer.PrepareResponse(200, nil, `{"server": {"id": "f5dc173b", "status": "ACTIVE"}}`)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+}
+
+func (s *S) TestWaitForStatusCancelsHangingNegotiation(c *C) {
+	client := newUndiscoveredTestClient()
+	client.Microversion = LatestMicroversion
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.WaitForStatus(ctx, "f5dc173b", StatusActive, fastWait)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+	c.Assert(time.Since(start) < 900*time.Millisecond, Equals, true)
+	c.Assert(client.NegotiatedMicroversion().IsZero(), Equals, true)
+	// Releases the request that is still waiting for a response.
+	testServer.PrepareResponse(300, nil, versionsBody)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/")
+}
+
+func (s *S) TestWaitForDeleted(c *C) {
+	testServer.PrepareResponse(200, nil, `{"server": {"id": "f5dc173b", "status": "ACTIVE", "OS-EXT-STS:task_state": "deleting"}}`)
+	testServer.PrepareResponse(404, nil, "Instance could not be found.")
+	client := newTestClient()
+	err := client.WaitForDeleted(context.Background(), "f5dc173b", fastWait)
+	c.Assert(err, IsNil)
+}
+
+func (s *S) TestWaitForDeletedWithDeletedStatus(c *C) {
+	testServer.PrepareResponse(200, nil, `{"server": {"id": "f5dc173b", "status": "DELETED"}}`)
+	client := newTestClient()
+	server, err := client.WaitForStatus(context.Background(), "f5dc173b", StatusDeleted, fastWait)
+	c.Assert(err, IsNil)
+	c.Assert(server.Status, Equals, StatusDeleted)
+}
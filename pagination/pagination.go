@@ -0,0 +1,150 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pagination provides a pager for the list APIs of OpenStack
+// services. These APIs return a limited number of items per request, along
+// with a marker (usually in a "next" link) that identifies the next page.
+//
+// A Pager fetches pages lazily, one request per page:
+//
+//     pager := client.ServerPager(nova.ListServersOpts{Limit: 100})
+//     err := pager.Each(ctx, func(server nova.Server) bool {
+//         fmt.Println(server.Name)
+//         return true // return false to stop early
+//     })
+//
+// or collects all items at once:
+//
+//     servers, err := pager.All(ctx)
+package pagination
+
+import (
+	"context"
+	"errors"
+	"net/url"
+)
+
+// ErrDone is returned by NextPage when there are no more pages.
+var ErrDone = errors.New("No more pages.")
+
+// Page is a page of items. Next is the marker of the next page, and is empty
+// in the last page.
+type Page[T any] struct {
+	Items []T
+	Next  string
+}
+
+// FetchFunc fetches the page that starts after the given marker. The marker
+// is empty for the first page.
+type FetchFunc[T any] func(ctx context.Context, marker string) (*Page[T], error)
+
+// Pager iterates over the pages of a list API. A Pager is not safe for
+// concurrent use, and can not be rewound: once a page is returned, the pager
+// moves to the next one.
+type Pager[T any] struct {
+	fetch  FetchFunc[T]
+	marker string
+	done   bool
+}
+
+// New returns a pager that fetches pages using the given function.
+func New[T any](fetch FetchFunc[T]) *Pager[T] {
+	return &Pager[T]{fetch: fetch}
+}
+
+// NextPage fetches and returns the items of the next page. It returns ErrDone
+// when there are no more pages, and the context error when the context is
+// done before the page is fetched.
+func (p *Pager[T]) NextPage(ctx context.Context) ([]T, error) {
+	if p.done {
+		return nil, ErrDone
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	page, err := p.fetch(ctx, p.marker)
+	if err != nil {
+		return nil, err
+	}
+	// An empty page, or a page that points to itself, would make the pager
+	// loop forever.
+	if page.Next == "" || page.Next == p.marker || len(page.Items) == 0 {
+		p.done = true
+	}
+	p.marker = page.Next
+	return page.Items, nil
+}
+
+// EachPage calls f with the items of each page, until f returns false or
+// there are no more pages.
+func (p *Pager[T]) EachPage(ctx context.Context, f func(items []T) bool) error {
+	for {
+		items, err := p.NextPage(ctx)
+		if err == ErrDone {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !f(items) {
+			return nil
+		}
+	}
+}
+
+// Each calls f with each item of each page, until f returns false or there
+// are no more items. Pages are fetched as needed, so stopping early saves
+// requests.
+func (p *Pager[T]) Each(ctx context.Context, f func(item T) bool) error {
+	return p.EachPage(ctx, func(items []T) bool {
+		for _, item := range items {
+			if !f(item) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// All fetches all remaining pages and returns their items.
+func (p *Pager[T]) All(ctx context.Context) ([]T, error) {
+	var all []T
+	err := p.EachPage(ctx, func(items []T) bool {
+		all = append(all, items...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// Marker returns the value of the marker parameter in the given URL, usually
+// the href of a "next" link. It returns an empty string if the URL is invalid
+// or has no marker.
+func Marker(href string) string {
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("marker")
+}
+
+// Link represents a link of a listing, like the "next" link in the
+// "servers_links" of a listing of servers.
+type Link struct {
+	Href string
+	Rel  string
+}
+
+// NextMarker returns the marker of the next page referenced by the "next"
+// link of a listing, or an empty string if there is no next page.
+func NextMarker(links []Link) string {
+	for _, link := range links {
+		if link.Rel == "next" {
+			return Marker(link.Href)
+		}
+	}
+	return ""
+}
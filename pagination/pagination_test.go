@@ -0,0 +1,149 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pagination
+
+import (
+	"context"
+	"errors"
+	. "launchpad.net/gocheck"
+	"testing"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type S struct{}
+
+var _ = Suite(&S{})
+
+// fakePages returns a FetchFunc that serves the given pages, each one
+// identified by the marker of the previous, recording the markers it was
+// called with.
+func fakePages(markers *[]string, pages ...[]int) FetchFunc[int] {
+	return func(ctx context.Context, marker string) (*Page[int], error) {
+		*markers = append(*markers, marker)
+		i := 0
+		if marker != "" {
+			i = len(marker)
+		}
+		page := &Page[int]{Items: pages[i]}
+		if i+1 < len(pages) {
+			page.Next = marker + "x"
+		}
+		return page, nil
+	}
+}
+
+func (s *S) TestNextPage(c *C) {
+	var markers []string
+	pager := New(fakePages(&markers, []int{1, 2}, []int{3}))
+	items, err := pager.NextPage(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(items, DeepEquals, []int{1, 2})
+	items, err = pager.NextPage(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(items, DeepEquals, []int{3})
+	_, err = pager.NextPage(context.Background())
+	c.Assert(err, Equals, ErrDone)
+	c.Assert(markers, DeepEquals, []string{"", "x"})
+}
+
+func (s *S) TestAll(c *C) {
+	var markers []string
+	pager := New(fakePages(&markers, []int{1, 2}, []int{3, 4}, []int{5}))
+	items, err := pager.All(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(items, DeepEquals, []int{1, 2, 3, 4, 5})
+	c.Assert(markers, DeepEquals, []string{"", "x", "xx"})
+}
+
+func (s *S) TestEachStopsEarly(c *C) {
+	var markers []string
+	pager := New(fakePages(&markers, []int{1, 2}, []int{3, 4}, []int{5}))
+	var items []int
+	err := pager.Each(context.Background(), func(item int) bool {
+		items = append(items, item)
+		return item < 3
+	})
+	c.Assert(err, IsNil)
+	c.Assert(items, DeepEquals, []int{1, 2, 3})
+	c.Assert(markers, DeepEquals, []string{"", "x"})
+}
+
+func (s *S) TestEachPage(c *C) {
+	var markers []string
+	pager := New(fakePages(&markers, []int{1, 2}, []int{3}))
+	var pages [][]int
+	err := pager.EachPage(context.Background(), func(items []int) bool {
+		pages = append(pages, items)
+		return true
+	})
+	c.Assert(err, IsNil)
+	c.Assert(pages, DeepEquals, [][]int{{1, 2}, {3}})
+}
+
+func (s *S) TestEmptyPageEndsIteration(c *C) {
+	calls := 0
+	pager := New(func(ctx context.Context, marker string) (*Page[int], error) {
+		calls++
+		return &Page[int]{Next: "x"}, nil
+	})
+	items, err := pager.All(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(items, HasLen, 0)
+	c.Assert(calls, Equals, 1)
+}
+
+func (s *S) TestRepeatedMarkerEndsIteration(c *C) {
+	calls := 0
+	pager := New(func(ctx context.Context, marker string) (*Page[int], error) {
+		calls++
+		return &Page[int]{Items: []int{calls}, Next: "x"}, nil
+	})
+	items, err := pager.All(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(items, DeepEquals, []int{1, 2})
+}
+
+func (s *S) TestFetchError(c *C) {
+	pager := New(func(ctx context.Context, marker string) (*Page[int], error) {
+		if marker != "" {
+			return nil, errors.New("something went wrong")
+		}
+		return &Page[int]{Items: []int{1}, Next: "x"}, nil
+	})
+	_, err := pager.All(context.Background())
+	c.Assert(err, ErrorMatches, "^something went wrong$")
+}
+
+func (s *S) TestContextCancellation(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var markers []string
+	pager := New(fakePages(&markers, []int{1}, []int{2}, []int{3}))
+	var items []int
+	err := pager.Each(ctx, func(item int) bool {
+		items = append(items, item)
+		cancel()
+		return true
+	})
+	c.Assert(err, Equals, context.Canceled)
+	c.Assert(items, DeepEquals, []int{1})
+	c.Assert(markers, DeepEquals, []string{""})
+}
+
+func (s *S) TestMarker(c *C) {
+	c.Assert(Marker("http://localhost:5555/v2.1/123tenant/servers/detail?limit=2&marker=f5dc173b"), Equals, "f5dc173b")
+	c.Assert(Marker("http://localhost:5555/v2.1/123tenant/servers/detail?limit=2"), Equals, "")
+	c.Assert(Marker(":"), Equals, "")
+}
+
+func (s *S) TestNextMarker(c *C) {
+	c.Assert(NextMarker(nil), Equals, "")
+	c.Assert(NextMarker([]Link{{Href: "http://localhost:5555/v2.1/123tenant/servers/detail?marker=9e4b1c2a", Rel: "previous"}}), Equals, "")
+	links := []Link{
+		{Href: "http://localhost:5555/v2.1/123tenant/servers/detail?marker=9e4b1c2a", Rel: "previous"},
+		{Href: "http://localhost:5555/v2.1/123tenant/servers/detail?limit=1&marker=f5dc173b", Rel: "next"},
+	}
+	c.Assert(NextMarker(links), Equals, "f5dc173b")
+}
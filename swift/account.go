@@ -0,0 +1,46 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package swift
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// AccountInfo contains the usage and the metadata of the account.
+type AccountInfo struct {
+	ContainerCount int64
+	ObjectCount    int64
+	BytesUsed      int64
+	Metadata       map[string]string
+}
+
+// GetAccountInfo returns the usage and the metadata of the account.
+func (c *Client) GetAccountInfo() (*AccountInfo, error) {
+	header, err := c.request("get the account info", "HEAD", "", nil, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return nil, err
+	}
+	info := AccountInfo{Metadata: parseMetadata(header, "X-Account-Meta-")}
+	info.ContainerCount, _ = strconv.ParseInt(header.Get("X-Account-Container-Count"), 10, 64)
+	info.ObjectCount, _ = strconv.ParseInt(header.Get("X-Account-Object-Count"), 10, 64)
+	info.BytesUsed, _ = strconv.ParseInt(header.Get("X-Account-Bytes-Used"), 10, 64)
+	return &info, nil
+}
+
+// SetAccountMetadata sets the given metadata of the account. Other metadata
+// keys are kept.
+func (c *Client) SetAccountMetadata(metadata map[string]string) error {
+	header := metadataHeaders(nil, "X-Account-Meta-", metadata)
+	_, err := c.request("set the account metadata", "POST", "", header, http.StatusNoContent)
+	return err
+}
+
+// DeleteAccountMetadata removes the given metadata keys of the account.
+func (c *Client) DeleteAccountMetadata(keys ...string) error {
+	header := removeMetadataHeaders("X-Remove-Account-Meta-", keys)
+	_, err := c.request("delete the account metadata", "POST", "", header, http.StatusNoContent)
+	return err
+}
@@ -0,0 +1,178 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package swift
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/globocom/go-openstack/pagination"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListOpts contains the filters and pagination parameters for listing
+// containers and objects. Empty fields are ignored.
+//
+// Only the names that start with Prefix are listed. When Delimiter is set,
+// the names that contain the delimiter after the prefix are rolled up into a
+// single pseudo-directory, listed in the Subdir field of Object.
+type ListOpts struct {
+	Prefix    string
+	Delimiter string
+
+	// Limit is the size of the pages of the listing. Swift lists at most
+	// 10000 names per request.
+	Limit int
+
+	// Marker and EndMarker limit the listing to the names greater than
+	// Marker and less than EndMarker.
+	Marker    string
+	EndMarker string
+}
+
+func (opts *ListOpts) query() url.Values {
+	q := url.Values{}
+	q.Set("format", "json")
+	if opts.Prefix != "" {
+		q.Set("prefix", opts.Prefix)
+	}
+	if opts.Delimiter != "" {
+		q.Set("delimiter", opts.Delimiter)
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Marker != "" {
+		q.Set("marker", opts.Marker)
+	}
+	if opts.EndMarker != "" {
+		q.Set("end_marker", opts.EndMarker)
+	}
+	return q
+}
+
+// list gets the JSON listing of the given path and decodes it into out. Swift
+// responds an empty listing with the status 204 and no body.
+func (c *Client) list(ctx context.Context, op, path string, opts ListOpts, out interface{}) error {
+	resp, err := c.sendContext(ctx, op, "GET", path+"?"+opts.query().Encode(), nil, nil, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Failed to %s: %s", op, err)
+	}
+	if len(b) > 0 {
+		if err = json.Unmarshal(b, out); err != nil {
+			return fmt.Errorf("Failed to %s, the server did not respond a valid JSON.", op)
+		}
+	}
+	return nil
+}
+
+// page returns a page of the given items. Swift listings do not have links:
+// the marker of the next page is the name of the last item, and the listing
+// ends when a page is not full.
+func page[T any](items []T, limit int, name func(T) string) *pagination.Page[T] {
+	p := pagination.Page[T]{Items: items}
+	if len(items) > 0 && (limit <= 0 || len(items) >= limit) {
+		p.Next = name(items[len(items)-1])
+	}
+	return &p
+}
+
+// Container represents a container, as listed in the account.
+type Container struct {
+	Name  string
+	Count int64
+	Bytes int64
+}
+
+func (c *Client) listContainers(ctx context.Context, opts ListOpts) (*pagination.Page[Container], error) {
+	var containers []Container
+	if err := c.list(ctx, "get the list of containers", "", opts, &containers); err != nil {
+		return nil, err
+	}
+	return page(containers, opts.Limit, func(c Container) string { return c.Name }), nil
+}
+
+// ListContainers returns the containers of the account that match the given
+// options, following all pages of the listing. The listing starts after
+// opts.Marker, and opts.Limit is the size of each page.
+func (c *Client) ListContainers(opts ListOpts) ([]Container, error) {
+	return c.ContainerPager(opts).All(context.Background())
+}
+
+// ContainerPager returns a pager over the containers that match the given
+// options, starting after opts.Marker. opts.Limit is the size of each page.
+func (c *Client) ContainerPager(opts ListOpts) *pagination.Pager[Container] {
+	return pagination.New(func(ctx context.Context, marker string) (*pagination.Page[Container], error) {
+		if marker != "" {
+			opts.Marker = marker
+		}
+		return c.listContainers(ctx, opts)
+	})
+}
+
+// ContainerInfo contains the usage, the access control lists and the metadata
+// of a container.
+type ContainerInfo struct {
+	ObjectCount int64
+	BytesUsed   int64
+	ReadACL     string
+	WriteACL    string
+	Metadata    map[string]string
+}
+
+// CreateContainer creates a container with the given metadata. Creating a
+// container that already exists updates its metadata.
+func (c *Client) CreateContainer(name string, metadata map[string]string) error {
+	header := metadataHeaders(nil, "X-Container-Meta-", metadata)
+	_, err := c.request("create the container "+name, "PUT", containerPath(name), header, http.StatusCreated, http.StatusAccepted)
+	return err
+}
+
+// GetContainerInfo returns the usage, the access control lists and the
+// metadata of the given container.
+func (c *Client) GetContainerInfo(name string) (*ContainerInfo, error) {
+	header, err := c.request("get the info of the container "+name, "HEAD", containerPath(name), nil, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return nil, err
+	}
+	info := ContainerInfo{
+		ReadACL:  header.Get("X-Container-Read"),
+		WriteACL: header.Get("X-Container-Write"),
+		Metadata: parseMetadata(header, "X-Container-Meta-"),
+	}
+	info.ObjectCount, _ = strconv.ParseInt(header.Get("X-Container-Object-Count"), 10, 64)
+	info.BytesUsed, _ = strconv.ParseInt(header.Get("X-Container-Bytes-Used"), 10, 64)
+	return &info, nil
+}
+
+// SetContainerMetadata sets the given metadata of the container. Other
+// metadata keys are kept.
+func (c *Client) SetContainerMetadata(name string, metadata map[string]string) error {
+	header := metadataHeaders(nil, "X-Container-Meta-", metadata)
+	_, err := c.request("set the metadata of the container "+name, "POST", containerPath(name), header, http.StatusNoContent)
+	return err
+}
+
+// DeleteContainerMetadata removes the given metadata keys of the container.
+func (c *Client) DeleteContainerMetadata(name string, keys ...string) error {
+	header := removeMetadataHeaders("X-Remove-Container-Meta-", keys)
+	_, err := c.request("delete the metadata of the container "+name, "POST", containerPath(name), header, http.StatusNoContent)
+	return err
+}
+
+// DeleteContainer deletes the given container. Swift refuses to delete
+// containers that are not empty, responding with the status 409.
+func (c *Client) DeleteContainer(name string) error {
+	_, err := c.request("delete the container "+name, "DELETE", containerPath(name), nil, http.StatusNoContent)
+	return err
+}
@@ -0,0 +1,123 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package swift
+
+import (
+	"context"
+	. "launchpad.net/gocheck"
+)
+
+func (s *S) TestListContainers(c *C) {
+	testServer.PrepareResponse(200, nil, `[{"name": "builds", "count": 3, "bytes": 1024}, {"name": "builds_segments", "count": 10, "bytes": 4096}]`)
+	client := newTestClient()
+	containers, err := client.ListContainers(ListOpts{Prefix: "builds", Limit: 10})
+	c.Assert(err, IsNil)
+	c.Assert(containers, DeepEquals, []Container{{Name: "builds", Count: 3, Bytes: 1024}, {Name: "builds_segments", Count: 10, Bytes: 4096}})
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v1/AUTH_123tenant")
+	c.Assert(req.URL.RawQuery, Equals, "format=json&limit=10&prefix=builds")
+}
+
+func (s *S) TestListContainersFollowsAllPages(c *C) {
+	testServer.PrepareResponse(200, nil, `[{"name": "a"}, {"name": "b"}]`)
+	testServer.PrepareResponse(200, nil, `[{"name": "c"}]`)
+	client := newTestClient()
+	containers, err := client.ListContainers(ListOpts{Limit: 2})
+	c.Assert(err, IsNil)
+	c.Assert(containers, DeepEquals, []Container{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Query().Get("marker"), Equals, "b")
+}
+
+func (s *S) TestListContainersEmpty(c *C) {
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	containers, err := client.ListContainers(ListOpts{})
+	c.Assert(err, IsNil)
+	c.Assert(containers, HasLen, 0)
+}
+
+func (s *S) TestContainerPager(c *C) {
+	testServer.PrepareResponse(200, nil, `[{"name": "a"}, {"name": "b"}]`)
+	testServer.PrepareResponse(200, nil, `[{"name": "c"}]`)
+	client := newTestClient()
+	containers, err := client.ContainerPager(ListOpts{Limit: 2}).All(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(containers, HasLen, 3)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Query().Get("marker"), Equals, "b")
+	_, _, err = testServer.WaitRequest(1e8)
+	c.Assert(err, NotNil)
+}
+
+func (s *S) TestContainerPagerWithoutLimit(c *C) {
+	testServer.PrepareResponse(200, nil, `[{"name": "a"}, {"name": "b"}]`)
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	containers, err := client.ContainerPager(ListOpts{}).All(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(containers, HasLen, 2)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Query().Get("marker"), Equals, "b")
+}
+
+func (s *S) TestCreateContainer(c *C) {
+	testServer.PrepareResponse(201, nil, "")
+	client := newTestClient()
+	err := client.CreateContainer("builds", map[string]string{"owner": "tsuru"})
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "PUT")
+	c.Assert(req.URL.Path, Equals, "/v1/AUTH_123tenant/builds")
+	c.Assert(req.Header.Get("X-Container-Meta-Owner"), Equals, "tsuru")
+}
+
+func (s *S) TestGetContainerInfo(c *C) {
+	headers := map[string]string{
+		"X-Container-Object-Count": "3",
+		"X-Container-Bytes-Used":   "1024",
+		"X-Container-Read":         ".r:*",
+		"X-Container-Meta-Owner":   "tsuru",
+	}
+	testServer.PrepareResponse(204, headers, "")
+	client := newTestClient()
+	info, err := client.GetContainerInfo("builds")
+	c.Assert(err, IsNil)
+	c.Assert(info, DeepEquals, &ContainerInfo{ObjectCount: 3, BytesUsed: 1024, ReadACL: ".r:*", Metadata: map[string]string{"owner": "tsuru"}})
+}
+
+func (s *S) TestContainerMetadata(c *C) {
+	testServer.PrepareResponse(204, nil, "")
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	err := client.SetContainerMetadata("builds", map[string]string{"owner": "tsuru"})
+	c.Assert(err, IsNil)
+	err = client.DeleteContainerMetadata("builds", "owner")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("X-Container-Meta-Owner"), Equals, "tsuru")
+	req, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("X-Remove-Container-Meta-Owner"), Equals, "x")
+}
+
+func (s *S) TestDeleteContainerNotEmpty(c *C) {
+	testServer.PrepareResponse(409, nil, "There was a conflict when trying to complete your request.")
+	client := newTestClient()
+	err := client.DeleteContainer("builds")
+	c.Assert(err, ErrorMatches, "^Failed to delete the container builds, status: 409.\nBody: .*")
+}
@@ -0,0 +1,254 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package swift
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSegmentSize is the size of the segments of large objects when
+// LargeObjectOpts.SegmentSize is zero. Swift refuses objects larger than
+// 5 GiB, so large objects must be split in smaller segments.
+const DefaultSegmentSize = 1 << 30
+
+// LargeObjectOpts contains the options for uploading large objects.
+type LargeObjectOpts struct {
+	PutObjectOpts
+
+	// SegmentSize is the size of each segment, in bytes.
+	SegmentSize int64
+
+	// SegmentContainer is the container of the segments. When empty, the
+	// segments are stored in the container "<container>_segments", which
+	// must exist.
+	SegmentContainer string
+
+	// Dynamic makes PutLargeObject create a dynamic large object (DLO),
+	// whose manifest references the segments by prefix. By default, a
+	// static large object (SLO) is created, whose manifest lists the
+	// segments and their checksums.
+	Dynamic bool
+}
+
+// segment is an entry of the manifest of a static large object.
+type segment struct {
+	Path      string `json:"path"`
+	ETag      string `json:"etag"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// PutLargeObject creates or replaces the given object as a large object,
+// reading its content from r until EOF and splitting it in segments. The
+// content is streamed, one segment at a time, and each segment is verified
+// as in PutObject.
+//
+// The segments are named "<name>/<timestamp>/<segment size>/<index>", with the
+// index padded with zeros, so they can be listed in order and do not mix with
+// the segments of other uploads of the same object. When the object replaces a
+// large object, the segments of the old object are deleted after the new
+// manifest is stored. When the upload fails, the segments already uploaded are
+// deleted on a best-effort basis. Empty content is stored as a regular empty
+// object. opts may be nil.
+func (c *Client) PutLargeObject(container, name string, r io.Reader, opts *LargeObjectOpts) error {
+	if opts == nil {
+		opts = &LargeObjectOpts{}
+	}
+	size := opts.SegmentSize
+	if size <= 0 {
+		size = DefaultSegmentSize
+	}
+	segmentContainer := opts.SegmentContainer
+	if segmentContainer == "" {
+		segmentContainer = container + "_segments"
+	}
+	info, err := c.GetObjectInfo(container, name)
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+	var oldSegments []string
+	if err == nil {
+		if oldSegments, err = c.segmentPaths(container, name, info); err != nil {
+			return err
+		}
+	}
+	br := bufio.NewReader(r)
+	if _, err := br.Peek(1); err == io.EOF {
+		if _, err = c.PutObject(container, name, br, &opts.PutObjectOpts); err != nil {
+			return err
+		}
+		return c.deleteSegments(oldSegments)
+	}
+	now := time.Now()
+	prefix := fmt.Sprintf("%s/%d.%06d/%d/", name, now.Unix(), now.Nanosecond()/1000, size)
+	var segments []segment
+	// uploaded also contains the segment being uploaded, which is stored
+	// even when its checksum does not match.
+	var uploaded []string
+	fail := func(err error) error {
+		c.deleteSegments(uploaded)
+		return err
+	}
+	// The segments expire along with the manifest, otherwise they would be
+	// left behind when swift deletes it.
+	segmentHeader := (&PutObjectOpts{DeleteAfter: opts.DeleteAfter}).header()
+	for i := 0; ; i++ {
+		if _, err := br.Peek(1); err == io.EOF {
+			break
+		} else if err != nil {
+			return fail(err)
+		}
+		segmentName := fmt.Sprintf("%s%08d", prefix, i)
+		uploaded = append(uploaded, segmentContainer+"/"+segmentName)
+		etag, n, err := c.putObject(segmentContainer, segmentName, io.LimitReader(br, size), segmentHeader)
+		if err != nil {
+			return fail(err)
+		}
+		segments = append(segments, segment{Path: segmentContainer + "/" + segmentName, ETag: etag, SizeBytes: n})
+	}
+	header := opts.PutObjectOpts.header()
+	path := objectPath(container, name)
+	var body io.Reader
+	if opts.Dynamic {
+		// Swift decodes the manifest, so it is encoded like object paths.
+		header.Set("X-Object-Manifest", strings.TrimPrefix(objectPath(segmentContainer, prefix), "/"))
+		body = strings.NewReader("")
+	} else {
+		b, err := json.Marshal(segments)
+		if err != nil {
+			return fail(err)
+		}
+		path += "?multipart-manifest=put"
+		body = bytes.NewReader(b)
+	}
+	resp, err := c.send("put the manifest of the object "+container+"/"+name, "PUT", path, header, body, http.StatusCreated)
+	if err != nil {
+		return fail(err)
+	}
+	resp.Body.Close()
+	return c.deleteSegments(oldSegments)
+}
+
+// segmentPaths returns the paths of the segments of the given large object,
+// in the form "container/object". Objects that are not large objects have no
+// segments.
+func (c *Client) segmentPaths(container, name string, info *ObjectInfo) ([]string, error) {
+	path := container + "/" + name
+	if info.StaticLargeObject {
+		op := "get the manifest of the object " + path
+		resp, err := c.send(op, "GET", objectPath(container, name)+"?multipart-manifest=get", nil, nil, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		var segments []struct{ Name string }
+		if err = json.NewDecoder(resp.Body).Decode(&segments); err != nil {
+			return nil, fmt.Errorf("Failed to %s, the server did not respond a valid JSON.", op)
+		}
+		paths := make([]string, len(segments))
+		for i, s := range segments {
+			paths[i] = strings.TrimPrefix(s.Name, "/")
+		}
+		return paths, nil
+	}
+	if info.Manifest == "" {
+		return nil, nil
+	}
+	parts := strings.SplitN(info.Manifest, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("Invalid manifest of the object %s: %q.", path, info.Manifest)
+	}
+	objects, err := c.ObjectPager(parts[0], ListOpts{Prefix: parts[1]}).All(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(objects))
+	for i, o := range objects {
+		paths[i] = parts[0] + "/" + o.Name
+	}
+	return paths, nil
+}
+
+// deleteSegments deletes the segments with the given paths. Segments that no
+// longer exist are ignored, so the deletion can be retried after a failure.
+func (c *Client) deleteSegments(paths []string) error {
+	for _, path := range paths {
+		parts := strings.SplitN(path, "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if err := c.DeleteObject(parts[0], parts[1]); err != nil && !IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteLargeObject deletes the given large object and its segments. Objects
+// that are not large objects are just deleted.
+func (c *Client) DeleteLargeObject(container, name string) error {
+	info, err := c.GetObjectInfo(container, name)
+	if err != nil {
+		return err
+	}
+	if info.StaticLargeObject {
+		return c.deleteStaticLargeObject(container, name)
+	}
+	segments, err := c.segmentPaths(container, name, info)
+	if err != nil {
+		return err
+	}
+	// Segments are deleted before the manifest, so the deletion can be
+	// retried with DeleteLargeObject after a failure.
+	if err = c.deleteSegments(segments); err != nil {
+		return err
+	}
+	return c.DeleteObject(container, name)
+}
+
+// deleteStaticLargeObject deletes the given static large object and its
+// segments with a single request. Swift deletes them like a bulk delete,
+// responding 200 even when some of the deletions fail, so the status and the
+// errors are read from the body of the response.
+func (c *Client) deleteStaticLargeObject(container, name string) error {
+	op := "delete the object " + container + "/" + name
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	resp, err := c.send(op, "DELETE", objectPath(container, name)+"?multipart-manifest=delete", header, nil, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Status string `json:"Response Status"`
+		Body   string `json:"Response Body"`
+		Errors [][]string
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("Failed to %s, the server did not respond a valid JSON.", op)
+	}
+	// The status is like "200 OK" or "400 Bad Request".
+	status, _ := strconv.Atoi(strings.SplitN(result.Status, " ", 2)[0])
+	if len(result.Errors) == 0 && status >= 200 && status < 300 {
+		return nil
+	}
+	body := result.Body
+	if len(result.Errors) > 0 {
+		errors := make([]string, len(result.Errors))
+		for i, e := range result.Errors {
+			errors[i] = strings.Join(e, ": ")
+		}
+		body = strings.Join(errors, "; ")
+	}
+	return &Error{Op: op, StatusCode: status, Body: body}
+}
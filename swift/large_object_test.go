@@ -0,0 +1,288 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package swift
+
+import (
+	. "launchpad.net/gocheck"
+	"path"
+	"strings"
+	"time"
+)
+
+// Checksums of the segments of the string "abcdefghij", split in segments of
+// 4 bytes.
+const (
+	segment0MD5 = "e2fc714c4727ee9395f324cd2e7f331f"
+	segment1MD5 = "1f7690ebdd9b4caf8fab49ca1757bf27"
+	segment2MD5 = "7bed657a775c37c2570786d0cbeefd88"
+)
+
+// uploadPrefix matches the unique prefix of the segments of an upload, with
+// segments of 4 bytes.
+const uploadPrefix = `\d+\.\d{6}/4/`
+
+func (s *S) TestPutLargeObject(c *C) {
+	testServer.PrepareResponse(404, nil, "")
+	testServer.PrepareResponse(201, map[string]string{"Etag": segment0MD5}, "")
+	testServer.PrepareResponse(201, map[string]string{"Etag": segment1MD5}, "")
+	testServer.PrepareResponse(201, map[string]string{"Etag": segment2MD5}, "")
+	testServer.PrepareResponse(201, nil, "")
+	client := newTestClient()
+	opts := LargeObjectOpts{SegmentSize: 4, PutObjectOpts: PutObjectOpts{ContentType: "text/plain"}}
+	err := client.PutLargeObject("builds", "artifact", strings.NewReader("abcdefghij"), &opts)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "HEAD")
+	var paths []string
+	for i, expected := range []string{"abcd", "efgh", "ij"} {
+		req, b, err := testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+		c.Assert(req.URL.Path, Matches, "/v1/AUTH_123tenant/builds_segments/artifact/"+uploadPrefix+"0000000"+string(rune('0'+i)))
+		c.Assert(string(b), Equals, expected)
+		paths = append(paths, strings.TrimPrefix(req.URL.Path, "/v1/AUTH_123tenant/"))
+	}
+	c.Assert(path.Dir(paths[0]), Equals, path.Dir(paths[2]))
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v1/AUTH_123tenant/builds/artifact")
+	c.Assert(req.URL.RawQuery, Equals, "multipart-manifest=put")
+	c.Assert(req.Header.Get("Content-Type"), Equals, "text/plain")
+	c.Assert(string(b), Equals, `[{"path":"`+paths[0]+`","etag":"`+segment0MD5+`","size_bytes":4},{"path":"`+paths[1]+`","etag":"`+segment1MD5+`","size_bytes":4},{"path":"`+paths[2]+`","etag":"`+segment2MD5+`","size_bytes":2}]`)
+}
+
+func (s *S) TestPutLargeObjectDynamic(c *C) {
+	testServer.PrepareResponse(404, nil, "")
+	testServer.PrepareResponse(201, map[string]string{"Etag": segment0MD5}, "")
+	testServer.PrepareResponse(201, nil, "")
+	client := newTestClient()
+	opts := LargeObjectOpts{SegmentSize: 4, SegmentContainer: "segments", Dynamic: true}
+	err := client.PutLargeObject("builds", "artifact", strings.NewReader("abcd"), &opts)
+	c.Assert(err, IsNil)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Matches, "/v1/AUTH_123tenant/segments/artifact/"+uploadPrefix+"00000000")
+	segment := req.URL.Path
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v1/AUTH_123tenant/builds/artifact")
+	c.Assert(req.Header.Get("X-Object-Manifest"), Equals, strings.TrimPrefix(path.Dir(segment), "/v1/AUTH_123tenant/")+"/")
+	c.Assert(string(b), Equals, "")
+}
+
+func (s *S) TestPutLargeObjectDeleteAfter(c *C) {
+	testServer.PrepareResponse(404, nil, "")
+	testServer.PrepareResponse(201, map[string]string{"Etag": segment0MD5}, "")
+	testServer.PrepareResponse(201, map[string]string{"Etag": segment1MD5}, "")
+	testServer.PrepareResponse(201, nil, "")
+	client := newTestClient()
+	opts := LargeObjectOpts{SegmentSize: 4, PutObjectOpts: PutObjectOpts{DeleteAfter: time.Hour, ContentType: "text/plain"}}
+	err := client.PutLargeObject("builds", "artifact", strings.NewReader("abcdefgh"), &opts)
+	c.Assert(err, IsNil)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	for i := 0; i < 2; i++ {
+		req, _, err := testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+		c.Assert(req.Header.Get("X-Delete-After"), Equals, "3600")
+		c.Assert(req.Header.Get("Content-Type"), Not(Equals), "text/plain")
+	}
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.RawQuery, Equals, "multipart-manifest=put")
+	c.Assert(req.Header.Get("X-Delete-After"), Equals, "3600")
+}
+
+func (s *S) TestPutLargeObjectDynamicEscapesManifest(c *C) {
+	testServer.PrepareResponse(404, nil, "")
+	testServer.PrepareResponse(201, map[string]string{"Etag": segment0MD5}, "")
+	testServer.PrepareResponse(201, nil, "")
+	client := newTestClient()
+	opts := LargeObjectOpts{SegmentSize: 4, SegmentContainer: "my segments", Dynamic: true}
+	err := client.PutLargeObject("builds", "build #1", strings.NewReader("abcd"), &opts)
+	c.Assert(err, IsNil)
+	for i := 0; i < 2; i++ {
+		_, _, err = testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+	}
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v1/AUTH_123tenant/builds/build #1")
+	c.Assert(req.Header.Get("X-Object-Manifest"), Matches, "my%20segments/build%20%231/"+uploadPrefix)
+}
+
+func (s *S) TestPutLargeObjectReplacesDynamicLargeObject(c *C) {
+	testServer.PrepareResponse(200, map[string]string{"X-Object-Manifest": "segments/artifact/"}, "")
+	testServer.PrepareResponse(200, nil, `[{"name": "artifact/00000000"}, {"name": "artifact/00000001"}]`)
+	testServer.PrepareResponse(204, nil, "")
+	testServer.PrepareResponse(201, map[string]string{"Etag": segment0MD5}, "")
+	testServer.PrepareResponse(201, nil, "")
+	testServer.PrepareResponse(204, nil, "")
+	testServer.PrepareResponse(404, nil, "")
+	client := newTestClient()
+	opts := LargeObjectOpts{SegmentSize: 4, SegmentContainer: "segments", Dynamic: true}
+	err := client.PutLargeObject("builds", "artifact", strings.NewReader("abcd"), &opts)
+	c.Assert(err, IsNil)
+	for i := 0; i < 3; i++ {
+		_, _, err = testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+	}
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Matches, "/v1/AUTH_123tenant/segments/artifact/"+uploadPrefix+"00000000")
+	req, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("X-Object-Manifest"), Matches, "segments/artifact/"+uploadPrefix)
+	// The old segments are deleted only after the new manifest is stored.
+	for _, path := range []string{"/segments/artifact/00000000", "/segments/artifact/00000001"} {
+		req, _, err = testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+		c.Assert(req.Method, Equals, "DELETE")
+		c.Assert(req.URL.Path, Equals, "/v1/AUTH_123tenant"+path)
+	}
+}
+
+func (s *S) TestPutLargeObjectReplacesStaticLargeObject(c *C) {
+	testServer.PrepareResponse(200, map[string]string{"X-Static-Large-Object": "True"}, "")
+	testServer.PrepareResponse(200, nil, `[{"name": "/builds_segments/artifact/1349625600.000000/4/00000000", "hash": "`+segment0MD5+`", "bytes": 4}]`)
+	testServer.PrepareResponse(201, map[string]string{"Etag": segment0MD5}, "")
+	testServer.PrepareResponse(201, nil, "")
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	err := client.PutLargeObject("builds", "artifact", strings.NewReader("abcd"), &LargeObjectOpts{SegmentSize: 4})
+	c.Assert(err, IsNil)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.RawQuery, Equals, "multipart-manifest=get")
+	req, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Not(Equals), "/v1/AUTH_123tenant/builds_segments/artifact/1349625600.000000/4/00000000")
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.Path, Equals, "/v1/AUTH_123tenant/builds_segments/artifact/1349625600.000000/4/00000000")
+}
+
+func (s *S) TestPutLargeObjectSegmentFailure(c *C) {
+	testServer.PrepareResponse(404, nil, "")
+	testServer.PrepareResponse(201, map[string]string{"Etag": "0123"}, "")
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	err := client.PutLargeObject("builds", "artifact", strings.NewReader("abcdefghij"), &LargeObjectOpts{SegmentSize: 4})
+	c.Assert(err, ErrorMatches, "^Checksum mismatch for the object builds_segments/artifact/"+uploadPrefix+"00000000: .*")
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	segment := req.URL.Path
+	req, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.Path, Equals, segment)
+}
+
+func (s *S) TestPutLargeObjectManifestFailure(c *C) {
+	testServer.PrepareResponse(404, nil, "")
+	testServer.PrepareResponse(201, map[string]string{"Etag": segment0MD5}, "")
+	testServer.PrepareResponse(201, map[string]string{"Etag": segment1MD5}, "")
+	testServer.PrepareResponse(400, nil, "Invalid manifest")
+	testServer.PrepareResponse(204, nil, "")
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	err := client.PutLargeObject("builds", "artifact", strings.NewReader("abcdefgh"), &LargeObjectOpts{SegmentSize: 4})
+	c.Assert(err, ErrorMatches, "(?s)^Failed to put the manifest of the object builds/artifact, status: 400.*")
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	var segments []string
+	for i := 0; i < 2; i++ {
+		req, _, err := testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+		segments = append(segments, req.URL.Path)
+	}
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	for _, segment := range segments {
+		req, _, err := testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+		c.Assert(req.Method, Equals, "DELETE")
+		c.Assert(req.URL.Path, Equals, segment)
+	}
+}
+
+func (s *S) TestPutLargeObjectEmpty(c *C) {
+	testServer.PrepareResponse(404, nil, "")
+	testServer.PrepareResponse(201, map[string]string{"Etag": "d41d8cd98f00b204e9800998ecf8427e"}, "")
+	client := newTestClient()
+	err := client.PutLargeObject("builds", "artifact", strings.NewReader(""), nil)
+	c.Assert(err, IsNil)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v1/AUTH_123tenant/builds/artifact")
+	c.Assert(req.URL.RawQuery, Equals, "")
+}
+
+func (s *S) TestDeleteStaticLargeObject(c *C) {
+	testServer.PrepareResponse(200, map[string]string{"X-Static-Large-Object": "True"}, "")
+	testServer.PrepareResponse(200, nil, `{"Number Deleted": 4, "Number Not Found": 0, "Response Status": "200 OK", "Response Body": "", "Errors": []}`)
+	client := newTestClient()
+	err := client.DeleteLargeObject("builds", "artifact")
+	c.Assert(err, IsNil)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.RawQuery, Equals, "multipart-manifest=delete")
+	c.Assert(req.Header.Get("Accept"), Equals, "application/json")
+}
+
+func (s *S) TestDeleteStaticLargeObjectFailure(c *C) {
+	testServer.PrepareResponse(200, map[string]string{"X-Static-Large-Object": "True"}, "")
+	testServer.PrepareResponse(200, nil, `{"Number Deleted": 1, "Number Not Found": 0, "Response Status": "400 Bad Request", "Response Body": "", "Errors": [["/builds_segments/artifact/00000001", "409 Conflict"]]}`)
+	client := newTestClient()
+	err := client.DeleteLargeObject("builds", "artifact")
+	c.Assert(err, ErrorMatches, "(?s)^Failed to delete the object builds/artifact, status: 400.\nBody: /builds_segments/artifact/00000001: 409 Conflict.$")
+	e, ok := err.(*Error)
+	c.Assert(ok, Equals, true)
+	c.Assert(e.StatusCode, Equals, 400)
+}
+
+func (s *S) TestDeleteDynamicLargeObject(c *C) {
+	testServer.PrepareResponse(200, map[string]string{"X-Object-Manifest": "segments/artifact/"}, "")
+	testServer.PrepareResponse(200, nil, `[{"name": "artifact/00000000"}, {"name": "artifact/00000001"}]`)
+	testServer.PrepareResponse(204, nil, "")
+	testServer.PrepareResponse(204, nil, "")
+	testServer.PrepareResponse(204, nil, "")
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	err := client.DeleteLargeObject("builds", "artifact")
+	c.Assert(err, IsNil)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v1/AUTH_123tenant/segments")
+	c.Assert(req.URL.Query().Get("prefix"), Equals, "artifact/")
+	req, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v1/AUTH_123tenant/segments")
+	for _, path := range []string{"/segments/artifact/00000000", "/segments/artifact/00000001"} {
+		req, _, err = testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+		c.Assert(req.Method, Equals, "DELETE")
+		c.Assert(req.URL.Path, Equals, "/v1/AUTH_123tenant"+path)
+	}
+	req, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v1/AUTH_123tenant/builds/artifact")
+}
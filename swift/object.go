@@ -0,0 +1,273 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package swift
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/globocom/go-openstack/internal/checksum"
+	"github.com/globocom/go-openstack/internal/timeutil"
+	"github.com/globocom/go-openstack/pagination"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Object represents an object, as listed in a container. When the listing
+// uses a delimiter, pseudo-directories are listed as objects with only the
+// Subdir field.
+type Object struct {
+	Name         string
+	Subdir       string
+	Hash         string
+	Bytes        int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// UnmarshalJSON decodes an object of a listing, parsing the timestamp in
+// swift's format, that does not include the time zone.
+func (o *Object) UnmarshalJSON(b []byte) error {
+	var object struct {
+		Name         string
+		Subdir       string
+		Hash         string
+		Bytes        int64
+		ContentType  string `json:"content_type"`
+		LastModified string `json:"last_modified"`
+	}
+	if err := json.Unmarshal(b, &object); err != nil {
+		return err
+	}
+	*o = Object{
+		Name:         object.Name,
+		Subdir:       object.Subdir,
+		Hash:         object.Hash,
+		Bytes:        object.Bytes,
+		ContentType:  object.ContentType,
+		LastModified: timeutil.Parse(object.LastModified),
+	}
+	return nil
+}
+
+func (c *Client) listObjects(ctx context.Context, container string, opts ListOpts) (*pagination.Page[Object], error) {
+	var objects []Object
+	if err := c.list(ctx, "get the list of objects of the container "+container, containerPath(container), opts, &objects); err != nil {
+		return nil, err
+	}
+	return page(objects, opts.Limit, func(o Object) string { return o.Name + o.Subdir }), nil
+}
+
+// ListObjects returns the objects of the container that match the given
+// options, following all pages of the listing. The listing starts after
+// opts.Marker, and opts.Limit is the size of each page.
+func (c *Client) ListObjects(container string, opts ListOpts) ([]Object, error) {
+	return c.ObjectPager(container, opts).All(context.Background())
+}
+
+// ObjectPager returns a pager over the objects of the container that match
+// the given options, starting after opts.Marker. opts.Limit is the size of
+// each page.
+//
+// Example of use, listing the pseudo-directories of the container:
+//
+//     pager := client.ObjectPager("builds", swift.ListOpts{Prefix: "2012/", Delimiter: "/"})
+//     pager.Each(ctx, func(object swift.Object) bool {
+//         fmt.Println(object.Subdir)
+//         return true
+//     })
+func (c *Client) ObjectPager(container string, opts ListOpts) *pagination.Pager[Object] {
+	return pagination.New(func(ctx context.Context, marker string) (*pagination.Page[Object], error) {
+		if marker != "" {
+			opts.Marker = marker
+		}
+		return c.listObjects(ctx, container, opts)
+	})
+}
+
+// ObjectInfo contains the attributes and the metadata of an object.
+//
+// For large objects, Manifest is the unescaped container/prefix of the
+// segments of a dynamic large object, and StaticLargeObject is true for static
+// large objects. The ETag of a large object is not the checksum of its
+// content.
+type ObjectInfo struct {
+	ContentType       string
+	ContentLength     int64
+	ETag              string
+	LastModified      time.Time
+	Metadata          map[string]string
+	Manifest          string
+	StaticLargeObject bool
+}
+
+func objectInfo(header http.Header) *ObjectInfo {
+	info := ObjectInfo{
+		ContentType:       header.Get("Content-Type"),
+		ETag:              strings.Trim(header.Get("Etag"), `"`),
+		Metadata:          parseMetadata(header, "X-Object-Meta-"),
+		Manifest:          header.Get("X-Object-Manifest"),
+		StaticLargeObject: strings.EqualFold(header.Get("X-Static-Large-Object"), "true"),
+	}
+	if manifest, err := url.PathUnescape(info.Manifest); err == nil {
+		info.Manifest = manifest
+	}
+	info.ContentLength, _ = strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	info.LastModified, _ = http.ParseTime(header.Get("Last-Modified"))
+	return &info
+}
+
+func (info *ObjectInfo) isLarge() bool {
+	return info.Manifest != "" || info.StaticLargeObject
+}
+
+// PutObjectOpts contains the optional attributes of a new object.
+type PutObjectOpts struct {
+	// ContentType is the type of the object. When empty, swift guesses it
+	// from the name of the object.
+	ContentType string
+
+	Metadata map[string]string
+
+	// DeleteAfter makes swift delete the object after the given duration.
+	DeleteAfter time.Duration
+}
+
+func (opts *PutObjectOpts) header() http.Header {
+	header := http.Header{}
+	if opts == nil {
+		return header
+	}
+	if opts.ContentType != "" {
+		header.Set("Content-Type", opts.ContentType)
+	}
+	if opts.DeleteAfter > 0 {
+		header.Set("X-Delete-After", strconv.FormatInt(int64(opts.DeleteAfter/time.Second), 10))
+	}
+	return metadataHeaders(header, "X-Object-Meta-", opts.Metadata)
+}
+
+// PutObject creates or replaces the given object, reading its content from r
+// until EOF. The content is streamed, and not buffered in memory. opts may be
+// nil.
+//
+// The MD5 checksum of the content is computed while it is sent, and compared
+// with the ETag returned by swift. PutObject returns the ETag, or a
+// *ChecksumError if they differ.
+func (c *Client) PutObject(container, name string, r io.Reader, opts *PutObjectOpts) (string, error) {
+	etag, _, err := c.putObject(container, name, r, opts.header())
+	return etag, err
+}
+
+// putObject works like PutObject, also returning the size of the content.
+func (c *Client) putObject(container, name string, r io.Reader, header http.Header) (string, int64, error) {
+	sum := md5.New()
+	counter := &countingWriter{}
+	body := io.TeeReader(r, io.MultiWriter(sum, counter))
+	path := container + "/" + name
+	resp, err := c.send("put the object "+path, "PUT", objectPath(container, name), header, body, http.StatusCreated)
+	if err != nil {
+		return "", 0, err
+	}
+	resp.Body.Close()
+	actual := hex.EncodeToString(sum.Sum(nil))
+	etag := strings.Trim(resp.Header.Get("Etag"), `"`)
+	if etag != actual {
+		return "", 0, &ChecksumError{Path: path, Expected: etag, Actual: actual}
+	}
+	return etag, counter.n, nil
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// GetObject returns a reader of the content of the given object, along with
+// its attributes. The content is streamed from swift, and the caller must
+// close the reader.
+//
+// The reader verifies the MD5 checksum of the content against the ETag of the
+// object: if they differ, the read that reaches the end of the content returns
+// a *ChecksumError instead of io.EOF. The ETag of large objects is not the
+// checksum of their content, so their content is not verified, and neither is
+// the content of objects without an ETag.
+func (c *Client) GetObject(container, name string) (io.ReadCloser, *ObjectInfo, error) {
+	path := container + "/" + name
+	resp, err := c.send("get the object "+path, "GET", objectPath(container, name), nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, nil, err
+	}
+	info := objectInfo(resp.Header)
+	if info.isLarge() {
+		return resp.Body, info, nil
+	}
+	mismatch := func(expected, actual string) error {
+		return &ChecksumError{Path: path, Expected: expected, Actual: actual}
+	}
+	return checksum.NewReader(resp.Body, md5.New(), info.ETag, mismatch), info, nil
+}
+
+// GetObjectRange returns a reader of length bytes of the content of the given
+// object, starting at offset. A length of 0 reads until the end of the
+// object, and a negative offset reads the last -offset bytes. The caller must
+// close the reader.
+//
+// The ETag is the checksum of the whole object, so partial reads are not
+// verified.
+func (c *Client) GetObjectRange(container, name string, offset, length int64) (io.ReadCloser, *ObjectInfo, error) {
+	var byteRange string
+	switch {
+	case offset < 0:
+		byteRange = fmt.Sprintf("bytes=%d", offset)
+	case length > 0:
+		byteRange = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	default:
+		byteRange = fmt.Sprintf("bytes=%d-", offset)
+	}
+	header := http.Header{}
+	header.Set("Range", byteRange)
+	path := container + "/" + name
+	resp, err := c.send("get the object "+path, "GET", objectPath(container, name), header, nil, http.StatusOK, http.StatusPartialContent)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Body, objectInfo(resp.Header), nil
+}
+
+// GetObjectInfo returns the attributes and the metadata of the given object.
+func (c *Client) GetObjectInfo(container, name string) (*ObjectInfo, error) {
+	header, err := c.request("get the info of the object "+container+"/"+name, "HEAD", objectPath(container, name), nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return objectInfo(header), nil
+}
+
+// SetObjectMetadata replaces the metadata of the given object. Unlike the
+// metadata of accounts and containers, the metadata keys that are not given
+// are removed.
+func (c *Client) SetObjectMetadata(container, name string, metadata map[string]string) error {
+	header := metadataHeaders(nil, "X-Object-Meta-", metadata)
+	_, err := c.request("set the metadata of the object "+container+"/"+name, "POST", objectPath(container, name), header, http.StatusAccepted)
+	return err
+}
+
+// DeleteObject deletes the given object. Deleting the manifest of a large
+// object does not delete its segments; use DeleteLargeObject for that.
+func (c *Client) DeleteObject(container, name string) error {
+	_, err := c.request("delete the object "+container+"/"+name, "DELETE", objectPath(container, name), nil, http.StatusNoContent)
+	return err
+}
@@ -0,0 +1,212 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package swift
+
+import (
+	"context"
+	"io/ioutil"
+	. "launchpad.net/gocheck"
+	"strings"
+	"time"
+)
+
+// artifactMD5 is the checksum of the string "artifact".
+const artifactMD5 = "8e5b948a454515dbabfc7eb718daa52f"
+
+func (s *S) TestListObjects(c *C) {
+	testServer.PrepareResponse(200, nil, `[{"subdir": "2012/09/"}, {"name": "2012/build.tar.gz", "hash": "`+artifactMD5+`", "bytes": 8, "content_type": "application/x-gzip", "last_modified": "2012-09-07T16:56:37.123456"}]`)
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	objects, err := client.ListObjects("builds", ListOpts{Prefix: "2012/", Delimiter: "/"})
+	c.Assert(err, IsNil)
+	c.Assert(objects, DeepEquals, []Object{
+		{Subdir: "2012/09/"},
+		{Name: "2012/build.tar.gz", Hash: artifactMD5, Bytes: 8, ContentType: "application/x-gzip", LastModified: time.Date(2012, 9, 7, 16, 56, 37, 123456000, time.UTC)},
+	})
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Path, Equals, "/v1/AUTH_123tenant/builds")
+	c.Assert(req.URL.RawQuery, Equals, "delimiter=%2F&format=json&prefix=2012%2F")
+	req, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Query().Get("marker"), Equals, "2012/build.tar.gz")
+}
+
+func (s *S) TestListObjectsFollowsAllPages(c *C) {
+	testServer.PrepareResponse(200, nil, `[{"name": "a"}, {"name": "b"}]`)
+	testServer.PrepareResponse(200, nil, `[{"name": "c"}, {"name": "d"}]`)
+	testServer.PrepareResponse(200, nil, `[{"name": "e"}]`)
+	client := newTestClient()
+	objects, err := client.ListObjects("builds", ListOpts{Limit: 2})
+	c.Assert(err, IsNil)
+	c.Assert(objects, DeepEquals, []Object{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}})
+	for _, marker := range []string{"", "b", "d"} {
+		req, _, err := testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+		c.Assert(req.URL.Query().Get("marker"), Equals, marker)
+	}
+}
+
+func (s *S) TestObjectPagerCancelsHangingRequest(c *C) {
+	client := newTestClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.ObjectPager("builds", ListOpts{}).All(ctx)
+	c.Assert(err, NotNil)
+	c.Assert(ctx.Err(), Equals, context.DeadlineExceeded)
+	c.Assert(time.Since(start) < 900*time.Millisecond, Equals, true)
+	// Releases the request that is still waiting for a response.
+	testServer.PrepareResponse(204, nil, "")
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+}
+
+func (s *S) TestObjectPagerWithSubdirs(c *C) {
+	testServer.PrepareResponse(200, nil, `[{"name": "a"}, {"subdir": "b/"}]`)
+	testServer.PrepareResponse(200, nil, `[{"name": "c"}]`)
+	client := newTestClient()
+	objects, err := client.ObjectPager("builds", ListOpts{Delimiter: "/", Limit: 2}).All(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(objects, HasLen, 3)
+	_, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.URL.Query().Get("marker"), Equals, "b/")
+}
+
+func (s *S) TestPutObject(c *C) {
+	testServer.PrepareResponse(201, map[string]string{"Etag": artifactMD5}, "")
+	client := newTestClient()
+	opts := PutObjectOpts{ContentType: "text/plain", Metadata: map[string]string{"build-id": "42"}, DeleteAfter: time.Hour}
+	etag, err := client.PutObject("builds", "2012/artifact.txt", strings.NewReader("artifact"), &opts)
+	c.Assert(err, IsNil)
+	c.Assert(etag, Equals, artifactMD5)
+	req, b, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "PUT")
+	c.Assert(req.URL.Path, Equals, "/v1/AUTH_123tenant/builds/2012/artifact.txt")
+	c.Assert(req.Header.Get("Content-Type"), Equals, "text/plain")
+	c.Assert(req.Header.Get("X-Object-Meta-Build-Id"), Equals, "42")
+	c.Assert(req.Header.Get("X-Delete-After"), Equals, "3600")
+	c.Assert(string(b), Equals, "artifact")
+}
+
+func (s *S) TestPutObjectChecksumMismatch(c *C) {
+	testServer.PrepareResponse(201, map[string]string{"Etag": "0123"}, "")
+	client := newTestClient()
+	_, err := client.PutObject("builds", "artifact", strings.NewReader("artifact"), nil)
+	c.Assert(err, FitsTypeOf, &ChecksumError{})
+	c.Assert(err, ErrorMatches, "^Checksum mismatch for the object builds/artifact: expected 0123, got "+artifactMD5+".$")
+}
+
+func (s *S) TestGetObject(c *C) {
+	headers := map[string]string{
+		"Etag":                   `"` + artifactMD5 + `"`,
+		"Content-Type":           "text/plain",
+		"Last-Modified":          "Fri, 07 Sep 2012 16:56:37 GMT",
+		"X-Object-Meta-Build-Id": "42",
+	}
+	testServer.PrepareResponse(200, headers, "artifact")
+	client := newTestClient()
+	r, info, err := client.GetObject("builds", "artifact")
+	c.Assert(err, IsNil)
+	defer r.Close()
+	c.Assert(info.ETag, Equals, artifactMD5)
+	c.Assert(info.ContentLength, Equals, int64(8))
+	c.Assert(info.LastModified, Equals, time.Date(2012, 9, 7, 16, 56, 37, 0, time.UTC))
+	c.Assert(info.Metadata, DeepEquals, map[string]string{"build-id": "42"})
+	b, err := ioutil.ReadAll(r)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, "artifact")
+}
+
+func (s *S) TestGetObjectChecksumMismatch(c *C) {
+	testServer.PrepareResponse(200, map[string]string{"Etag": artifactMD5}, "corrupted")
+	client := newTestClient()
+	r, _, err := client.GetObject("builds", "artifact")
+	c.Assert(err, IsNil)
+	defer r.Close()
+	_, err = ioutil.ReadAll(r)
+	c.Assert(err, FitsTypeOf, &ChecksumError{})
+}
+
+func (s *S) TestGetObjectWithoutETagIsNotVerified(c *C) {
+	testServer.PrepareResponse(200, nil, "artifact")
+	client := newTestClient()
+	r, _, err := client.GetObject("builds", "artifact")
+	c.Assert(err, IsNil)
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, "artifact")
+}
+
+func (s *S) TestGetObjectLargeObjectIsNotVerified(c *C) {
+	testServer.PrepareResponse(200, map[string]string{"Etag": `"0123"`, "X-Static-Large-Object": "True"}, "artifact")
+	client := newTestClient()
+	r, info, err := client.GetObject("builds", "artifact")
+	c.Assert(err, IsNil)
+	defer r.Close()
+	c.Assert(info.StaticLargeObject, Equals, true)
+	b, err := ioutil.ReadAll(r)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, "artifact")
+}
+
+func (s *S) TestGetObjectInfoUnescapesManifest(c *C) {
+	testServer.PrepareResponse(200, map[string]string{"X-Object-Manifest": "my%20segments/build%20%231/"}, "")
+	client := newTestClient()
+	info, err := client.GetObjectInfo("builds", "build #1")
+	c.Assert(err, IsNil)
+	c.Assert(info.Manifest, Equals, "my segments/build #1/")
+	c.Assert(info.isLarge(), Equals, true)
+}
+
+func (s *S) TestGetObjectRange(c *C) {
+	testServer.PrepareResponse(206, nil, "tifa")
+	testServer.PrepareResponse(206, nil, "fact")
+	testServer.PrepareResponse(206, nil, "fact")
+	client := newTestClient()
+	r, _, err := client.GetObjectRange("builds", "artifact", 2, 4)
+	c.Assert(err, IsNil)
+	b, _ := ioutil.ReadAll(r)
+	r.Close()
+	c.Assert(string(b), Equals, "tifa")
+	r, _, err = client.GetObjectRange("builds", "artifact", 4, 0)
+	c.Assert(err, IsNil)
+	r.Close()
+	r, _, err = client.GetObjectRange("builds", "artifact", -4, 0)
+	c.Assert(err, IsNil)
+	r.Close()
+	for _, expected := range []string{"bytes=2-5", "bytes=4-", "bytes=-4"} {
+		req, _, err := testServer.WaitRequest(1e9)
+		c.Assert(err, IsNil)
+		c.Assert(req.Header.Get("Range"), Equals, expected)
+	}
+}
+
+func (s *S) TestSetObjectMetadata(c *C) {
+	testServer.PrepareResponse(202, nil, "")
+	client := newTestClient()
+	err := client.SetObjectMetadata("builds", "artifact", map[string]string{"build-id": "43"})
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "POST")
+	c.Assert(req.Header.Get("X-Object-Meta-Build-Id"), Equals, "43")
+}
+
+func (s *S) TestDeleteObject(c *C) {
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	err := client.DeleteObject("builds", "artifact")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.URL.Path, Equals, "/v1/AUTH_123tenant/builds/artifact")
+}
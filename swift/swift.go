@@ -0,0 +1,176 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package swift provides types, methods and functions for interactions with the
+// Swift Object Storage API v1.
+package swift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/globocom/go-openstack/keystone"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client represents a client for the Swift API. It encapsulates a
+// keystone.Client instance that provides the token and the endpoint of the
+// "object-store" service used by this client.
+type Client struct {
+	KeystoneClient *keystone.Client
+}
+
+// endpoint returns the URL of the account of the tenant. Unlike the other
+// services, the admin endpoint of swift does not include the account, so the
+// public endpoint is used.
+func (c *Client) endpoint() (string, error) {
+	if c.KeystoneClient == nil {
+		return "", errors.New("KeystoneClient is nil.")
+	}
+	endpoint := strings.TrimSuffix(c.KeystoneClient.Endpoint("object-store", "public"), "/")
+	if endpoint == "" {
+		return "", errors.New("Object storage endpoint not found in the service catalog.")
+	}
+	return endpoint, nil
+}
+
+// Error is returned when the object storage API responds a request with an
+// unexpected status.
+type Error struct {
+	// Op describes the operation that failed, like "get the object a/b".
+	Op string
+
+	StatusCode int
+	Body       string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("Failed to %s, status: %d.\nBody: %s.", e.Op, e.StatusCode, e.Body)
+}
+
+// IsNotFound reports whether err is an Error with the status 404.
+func IsNotFound(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// ChecksumError is returned when the ETag of an object, which is the MD5
+// checksum of its content, does not match the checksum computed while
+// uploading or downloading it.
+type ChecksumError struct {
+	// Path is the path of the object, in the form "container/object".
+	Path string
+
+	// Expected is the ETag of the object in swift, and Actual is the checksum
+	// computed from the content that was sent or received.
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("Checksum mismatch for the object %s: expected %s, got %s.", e.Path, e.Expected, e.Actual)
+}
+
+// containerPath returns the escaped path of the given container.
+func containerPath(container string) string {
+	return "/" + url.PathEscape(container)
+}
+
+// objectPath returns the escaped path of the given object. The slashes in the
+// name of the object are kept, as they are usually used as pseudo-directories.
+func objectPath(container, name string) string {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return containerPath(container) + "/" + strings.Join(parts, "/")
+}
+
+// send sends a request to the given path of the account, with the given
+// headers and body. It returns an Error if the status of the response is not
+// one of the expected statuses. Otherwise, the caller must close the body of
+// the returned response.
+func (c *Client) send(op, method, path string, header http.Header, body io.Reader, expected ...int) (*http.Response, error) {
+	return c.sendContext(context.Background(), op, method, path, header, body, expected...)
+}
+
+// sendContext works like send, but aborts the request when the given context
+// is done.
+func (c *Client) sendContext(ctx context.Context, op, method, path string, header http.Header, body io.Reader, expected ...int) (*http.Response, error) {
+	endpoint, err := c.endpoint()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint+path, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("X-Auth-Token", c.KeystoneClient.Token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to %s: %s", op, err)
+	}
+	for _, e := range expected {
+		if resp.StatusCode == e {
+			return resp, nil
+		}
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	return nil, &Error{Op: op, StatusCode: resp.StatusCode, Body: string(b)}
+}
+
+// request works like send, but discards the body of the response, returning
+// only its headers.
+func (c *Client) request(op, method, path string, header http.Header, expected ...int) (http.Header, error) {
+	resp, err := c.send(op, method, path, header, nil, expected...)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	return resp.Header, nil
+}
+
+// metadataHeaders returns the headers that set the given metadata, using the
+// given prefix, like "X-Object-Meta-".
+func metadataHeaders(header http.Header, prefix string, metadata map[string]string) http.Header {
+	if header == nil {
+		header = http.Header{}
+	}
+	for k, v := range metadata {
+		header.Set(prefix+k, v)
+	}
+	return header
+}
+
+// removeMetadataHeaders returns the headers that remove the given metadata
+// keys, using the given prefix, like "X-Remove-Container-Meta-".
+func removeMetadataHeaders(prefix string, keys []string) http.Header {
+	header := http.Header{}
+	for _, k := range keys {
+		header.Set(prefix+k, "x")
+	}
+	return header
+}
+
+// parseMetadata extracts the metadata with the given prefix from the headers.
+// Swift metadata keys are case insensitive, so they are returned in lower
+// case.
+func parseMetadata(header http.Header, prefix string) map[string]string {
+	metadata := make(map[string]string)
+	for k := range header {
+		if strings.HasPrefix(k, prefix) {
+			metadata[strings.ToLower(k[len(prefix):])] = header.Get(k)
+		}
+	}
+	return metadata
+}
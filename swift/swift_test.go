@@ -0,0 +1,115 @@
+// Copyright 2012 go-openstack authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package swift
+
+import (
+	"github.com/globocom/go-openstack/keystone"
+	ostesting "github.com/globocom/go-openstack/testing"
+	. "launchpad.net/gocheck"
+	"net/http"
+	"testing"
+)
+
+type S struct{}
+
+var _ = Suite(&S{})
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var testServer = ostesting.NewTestHTTPServer("http://localhost:7777", 1e9)
+
+func (s *S) SetUpSuite(c *C) {
+	testServer.Start()
+}
+
+func (s *S) TearDownTest(c *C) {
+	testServer.FlushRequests()
+}
+
+// newTestClient returns a client that sends its requests to the test server.
+func newTestClient() *Client {
+	kclient := keystone.Client{
+		Token: "123token",
+		Catalogs: []keystone.ServiceCatalog{
+			{
+				Name: "Object Storage Service",
+				Type: "object-store",
+				Endpoints: []keystone.Endpoint{
+					{Interface: "admin", URL: "http://localhost:7777"},
+					{Interface: "public", URL: "http://localhost:7777/v1/AUTH_123tenant"},
+				},
+			},
+		},
+	}
+	return &Client{KeystoneClient: &kclient}
+}
+
+func (s *S) TestEndpoint(c *C) {
+	client := newTestClient()
+	endpoint, err := client.endpoint()
+	c.Assert(err, IsNil)
+	c.Assert(endpoint, Equals, "http://localhost:7777/v1/AUTH_123tenant")
+	client = &Client{KeystoneClient: &keystone.Client{}}
+	_, err = client.endpoint()
+	c.Assert(err, ErrorMatches, "^Object storage endpoint not found in the service catalog.$")
+}
+
+func (s *S) TestObjectPath(c *C) {
+	c.Assert(objectPath("my container", "2012/09/build #1.tar.gz"), Equals, "/my%20container/2012/09/build%20%231.tar.gz")
+}
+
+func (s *S) TestParseMetadata(c *C) {
+	header := http.Header{}
+	header.Set("X-Object-Meta-Owner", "tsuru")
+	header.Set("X-Object-Meta-Build-Id", "42")
+	header.Set("Content-Type", "text/plain")
+	c.Assert(parseMetadata(header, "X-Object-Meta-"), DeepEquals, map[string]string{"owner": "tsuru", "build-id": "42"})
+}
+
+func (s *S) TestGetAccountInfo(c *C) {
+	headers := map[string]string{
+		"X-Account-Container-Count": "2",
+		"X-Account-Object-Count":    "10",
+		"X-Account-Bytes-Used":      "4096",
+		"X-Account-Meta-Quota":      "1TB",
+	}
+	testServer.PrepareResponse(204, headers, "")
+	client := newTestClient()
+	info, err := client.GetAccountInfo()
+	c.Assert(err, IsNil)
+	c.Assert(info, DeepEquals, &AccountInfo{ContainerCount: 2, ObjectCount: 10, BytesUsed: 4096, Metadata: map[string]string{"quota": "1TB"}})
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "HEAD")
+	c.Assert(req.URL.Path, Equals, "/v1/AUTH_123tenant")
+	c.Assert(req.Header.Get("X-Auth-Token"), Equals, "123token")
+}
+
+func (s *S) TestAccountMetadata(c *C) {
+	testServer.PrepareResponse(204, nil, "")
+	testServer.PrepareResponse(204, nil, "")
+	client := newTestClient()
+	err := client.SetAccountMetadata(map[string]string{"quota": "1TB"})
+	c.Assert(err, IsNil)
+	err = client.DeleteAccountMetadata("quota")
+	c.Assert(err, IsNil)
+	req, _, err := testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Method, Equals, "POST")
+	c.Assert(req.Header.Get("X-Account-Meta-Quota"), Equals, "1TB")
+	req, _, err = testServer.WaitRequest(1e9)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("X-Remove-Account-Meta-Quota"), Equals, "x")
+}
+
+func (s *S) TestIsNotFound(c *C) {
+	testServer.PrepareResponse(404, nil, "Not Found")
+	client := newTestClient()
+	_, _, err := client.GetObject("builds", "missing")
+	c.Assert(IsNotFound(err), Equals, true)
+	c.Assert(err, ErrorMatches, "^Failed to get the object builds/missing, status: 404.\nBody: Not Found.$")
+}
@@ -80,6 +80,7 @@ func (s *TestHTTPServer) FlushRequests() {
 	for {
 		select {
 		case <-s.Request:
+			<-s.body // the body is always sent before the request
 		default:
 			return
 		}
@@ -100,6 +101,9 @@ func (s *TestHTTPServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	case <-time.After(s.timeout):
 		log.Panicf("No response from server after %s.", s.timeout)
 	}
+	for k, v := range resp.Headers {
+		w.Header().Set(k, v)
+	}
 	if resp.Status != 0 {
 		w.WriteHeader(resp.Status)
 	}